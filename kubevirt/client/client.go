@@ -18,15 +18,19 @@ package client
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"log"
+	"time"
 
+	k8sv1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	unstructured "k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/runtime/serializer"
 	pkgApi "k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/dynamic"
 	restclient "k8s.io/client-go/rest"
@@ -43,6 +47,30 @@ type Client interface {
 	GetVirtualMachine(namespace string, name string) (*kubevirtapiv1.VirtualMachine, error)
 	UpdateVirtualMachine(namespace string, name string, vm *kubevirtapiv1.VirtualMachine, data []byte) error
 	DeleteVirtualMachine(namespace string, name string) error
+	ApplyVirtualMachine(vm *kubevirtapiv1.VirtualMachine) error
+
+	// VirtualMachine lifecycle operations
+
+	StartVirtualMachine(namespace string, name string) error
+	StopVirtualMachine(namespace string, name string) error
+	RestartVirtualMachine(namespace string, name string) error
+	PauseVirtualMachineInstance(namespace string, name string) error
+	UnpauseVirtualMachineInstance(namespace string, name string) error
+	SoftRebootVirtualMachineInstance(namespace string, name string) error
+
+	// VirtualMachineInstance CRUD operations
+
+	CreateVirtualMachineInstance(vmi *kubevirtapiv1.VirtualMachineInstance) error
+	GetVirtualMachineInstance(namespace string, name string) (*kubevirtapiv1.VirtualMachineInstance, error)
+	UpdateVirtualMachineInstance(namespace string, name string, vmi *kubevirtapiv1.VirtualMachineInstance, data []byte) error
+	DeleteVirtualMachineInstance(namespace string, name string) error
+	ApplyVirtualMachineInstance(vmi *kubevirtapiv1.VirtualMachineInstance) error
+
+	// VirtualMachineInstance guest agent operations
+
+	GetVirtualMachineInstanceGuestOSInfo(namespace string, name string) (*kubevirtapiv1.VirtualMachineInstanceGuestAgentInfo, error)
+	GetVirtualMachineInstanceUserList(namespace string, name string) (*kubevirtapiv1.VirtualMachineInstanceGuestOSUserList, error)
+	GetVirtualMachineInstanceFileSystemList(namespace string, name string) (*kubevirtapiv1.VirtualMachineInstanceFileSystemList, error)
 
 	// DataVolume CRUD operations
 
@@ -50,18 +78,48 @@ type Client interface {
 	GetDataVolume(namespace string, name string) (*cdiv1.DataVolume, error)
 	UpdateDataVolume(namespace string, name string, dv *cdiv1.DataVolume, data []byte) error
 	DeleteDataVolume(namespace string, name string) error
+	ApplyDataVolume(dv *cdiv1.DataVolume) error
+
+	// Secret operations, for the Secrets the provider manages on behalf of other resources
+
+	GetSecret(namespace string, name string) (*k8sv1.Secret, error)
+	ApplySecret(secret *k8sv1.Secret) error
+	DeleteSecret(namespace string, name string) error
+
+	// Wait operations, watching the object until it reaches the awaited state or the timeout expires
+
+	WaitForDataVolumePhase(namespace string, name string, phase cdiv1.DataVolumePhase, timeout time.Duration) (*cdiv1.DataVolume, error)
+	WaitForVMCondition(namespace string, name string, condition VirtualMachineConditionFunc, timeout time.Duration) (*kubevirtapiv1.VirtualMachine, error)
+	WaitForVMICondition(namespace string, name string, condition VirtualMachineInstanceConditionFunc, timeout time.Duration) (*kubevirtapiv1.VirtualMachineInstance, error)
+	WaitForDeletion(resource schema.GroupVersionResource, namespace string, name string, timeout time.Duration) error
+
+	// ServerSideApply reports whether resources should be written using server-side apply
+	ServerSideApply() bool
 
 	// Get dynamic client for custom resources
 	GetDynamicClient() dynamic.Interface
 }
 
+// FieldManager is the name the provider uses to claim ownership of fields when using server-side apply.
+const FieldManager = "terraform-provider-kubevirt"
+
+// Options holds the client settings configured at the provider level.
+type Options struct {
+	// ServerSideApply makes resources create and update objects using server-side apply instead of JSON patches.
+	ServerSideApply bool
+	// ForceConflicts makes server-side apply take over fields owned by other field managers instead of failing.
+	ForceConflicts bool
+}
+
 type client struct {
-	dynamicClient dynamic.Interface
+	dynamicClient     dynamic.Interface
+	subresourceClient restclient.Interface
+	options           Options
 }
 
 // New creates our client wrapper object for the actual kubeVirt and kubernetes clients we use.
-func NewClient(cfg *restclient.Config) (Client, error) {
-	result := &client{}
+func NewClient(cfg *restclient.Config, options Options) (Client, error) {
+	result := &client{options: options}
 	c, err := dynamic.NewForConfig(cfg)
 	if err != nil {
 		msg := fmt.Sprintf("Failed to create client, with error: %v", err)
@@ -69,9 +127,26 @@ func NewClient(cfg *restclient.Config) (Client, error) {
 		return nil, fmt.Errorf(msg)
 	}
 	result.dynamicClient = c
+	sc, err := newSubresourceClient(cfg)
+	if err != nil {
+		msg := fmt.Sprintf("Failed to create subresource client, with error: %v", err)
+		log.Printf("[Error] %s", msg)
+		return nil, fmt.Errorf(msg)
+	}
+	result.subresourceClient = sc
 	return result, nil
 }
 
+// newSubresourceClient creates a REST client for the subresources.kubevirt.io API group,
+// which serves the lifecycle and guest agent endpoints the dynamic client can't reach.
+func newSubresourceClient(cfg *restclient.Config) (*restclient.RESTClient, error) {
+	subresourceCfg := restclient.CopyConfig(cfg)
+	subresourceCfg.GroupVersion = &kubevirtapiv1.SubresourceStorageGroupVersion
+	subresourceCfg.APIPath = "/apis"
+	subresourceCfg.NegotiatedSerializer = serializer.NewCodecFactory(runtime.NewScheme()).WithoutConversion()
+	return restclient.RESTClientFor(subresourceCfg)
+}
+
 // VirtualMachine CRUD operations
 
 func (c *client) CreateVirtualMachine(vm *kubevirtapiv1.VirtualMachine) error {
@@ -109,6 +184,37 @@ func (c *client) DeleteVirtualMachine(namespace string, name string) error {
 	return c.deleteResource(namespace, name, vmRes())
 }
 
+func (c *client) ApplyVirtualMachine(vm *kubevirtapiv1.VirtualMachine) error {
+	vmUpdateTypeMeta(vm)
+	return c.applyResource(vm, vm.Namespace, vm.Name, vmRes())
+}
+
+// VirtualMachine lifecycle operations
+
+func (c *client) StartVirtualMachine(namespace string, name string) error {
+	return c.putSubresource(namespace, name, "virtualmachines", "start", &kubevirtapiv1.StartOptions{})
+}
+
+func (c *client) StopVirtualMachine(namespace string, name string) error {
+	return c.putSubresource(namespace, name, "virtualmachines", "stop", &kubevirtapiv1.StopOptions{})
+}
+
+func (c *client) RestartVirtualMachine(namespace string, name string) error {
+	return c.putSubresource(namespace, name, "virtualmachines", "restart", &kubevirtapiv1.RestartOptions{})
+}
+
+func (c *client) PauseVirtualMachineInstance(namespace string, name string) error {
+	return c.putSubresource(namespace, name, "virtualmachineinstances", "pause", &kubevirtapiv1.PauseOptions{})
+}
+
+func (c *client) UnpauseVirtualMachineInstance(namespace string, name string) error {
+	return c.putSubresource(namespace, name, "virtualmachineinstances", "unpause", &kubevirtapiv1.UnpauseOptions{})
+}
+
+func (c *client) SoftRebootVirtualMachineInstance(namespace string, name string) error {
+	return c.putSubresource(namespace, name, "virtualmachineinstances", "softreboot", nil)
+}
+
 func vmUpdateTypeMeta(vm *kubevirtapiv1.VirtualMachine) {
 	vm.TypeMeta = metav1.TypeMeta{
 		Kind:       "VirtualMachine",
@@ -125,6 +231,89 @@ func vmRes() schema.GroupVersionResource {
 
 }
 
+// VirtualMachineInstance CRUD operations
+
+func (c *client) CreateVirtualMachineInstance(vmi *kubevirtapiv1.VirtualMachineInstance) error {
+	vmiUpdateTypeMeta(vmi)
+	return c.createResource(vmi, vmi.Namespace, vmiRes())
+}
+
+func (c *client) GetVirtualMachineInstance(namespace string, name string) (*kubevirtapiv1.VirtualMachineInstance, error) {
+	var vmi kubevirtapiv1.VirtualMachineInstance
+	resp, err := c.getResource(namespace, name, vmiRes())
+	if err != nil {
+		if errors.IsNotFound(err) {
+			log.Printf("[Warning] VirtualMachineInstance %s not found (namespace=%s)", name, namespace)
+			return nil, err
+		}
+		msg := fmt.Sprintf("Failed to get VirtualMachineInstance, with error: %v", err)
+		log.Printf("[Error] %s", msg)
+		return nil, fmt.Errorf(msg)
+	}
+	unstructured := resp.UnstructuredContent()
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(unstructured, &vmi); err != nil {
+		msg := fmt.Sprintf("Failed to translate unstructed to VirtualMachineInstance, with error: %v", err)
+		log.Printf("[Error] %s", msg)
+		return nil, fmt.Errorf(msg)
+	}
+	return &vmi, nil
+}
+
+func (c *client) UpdateVirtualMachineInstance(namespace string, name string, vmi *kubevirtapiv1.VirtualMachineInstance, data []byte) error {
+	vmiUpdateTypeMeta(vmi)
+	return c.updateResource(namespace, name, vmiRes(), vmi, data)
+}
+
+func (c *client) DeleteVirtualMachineInstance(namespace string, name string) error {
+	return c.deleteResource(namespace, name, vmiRes())
+}
+
+func (c *client) ApplyVirtualMachineInstance(vmi *kubevirtapiv1.VirtualMachineInstance) error {
+	vmiUpdateTypeMeta(vmi)
+	return c.applyResource(vmi, vmi.Namespace, vmi.Name, vmiRes())
+}
+
+// VirtualMachineInstance guest agent operations
+
+func (c *client) GetVirtualMachineInstanceGuestOSInfo(namespace string, name string) (*kubevirtapiv1.VirtualMachineInstanceGuestAgentInfo, error) {
+	var guestInfo kubevirtapiv1.VirtualMachineInstanceGuestAgentInfo
+	if err := c.getSubresource(namespace, name, "virtualmachineinstances", "guestosinfo", &guestInfo); err != nil {
+		return nil, err
+	}
+	return &guestInfo, nil
+}
+
+func (c *client) GetVirtualMachineInstanceUserList(namespace string, name string) (*kubevirtapiv1.VirtualMachineInstanceGuestOSUserList, error) {
+	var userList kubevirtapiv1.VirtualMachineInstanceGuestOSUserList
+	if err := c.getSubresource(namespace, name, "virtualmachineinstances", "userlist", &userList); err != nil {
+		return nil, err
+	}
+	return &userList, nil
+}
+
+func (c *client) GetVirtualMachineInstanceFileSystemList(namespace string, name string) (*kubevirtapiv1.VirtualMachineInstanceFileSystemList, error) {
+	var fileSystemList kubevirtapiv1.VirtualMachineInstanceFileSystemList
+	if err := c.getSubresource(namespace, name, "virtualmachineinstances", "filesystemlist", &fileSystemList); err != nil {
+		return nil, err
+	}
+	return &fileSystemList, nil
+}
+
+func vmiUpdateTypeMeta(vmi *kubevirtapiv1.VirtualMachineInstance) {
+	vmi.TypeMeta = metav1.TypeMeta{
+		Kind:       "VirtualMachineInstance",
+		APIVersion: kubevirtapiv1.GroupVersion.String(),
+	}
+}
+
+func vmiRes() schema.GroupVersionResource {
+	return schema.GroupVersionResource{
+		Group:    kubevirtapiv1.GroupVersion.Group,
+		Version:  kubevirtapiv1.GroupVersion.Version,
+		Resource: "virtualmachineinstances",
+	}
+}
+
 // DataVolume CRUD operations
 
 func (c *client) CreateDataVolume(dv *cdiv1.DataVolume) error {
@@ -162,6 +351,65 @@ func (c *client) DeleteDataVolume(namespace string, name string) error {
 	return c.deleteResource(namespace, name, dvRes())
 }
 
+func (c *client) ApplyDataVolume(dv *cdiv1.DataVolume) error {
+	dvUpdateTypeMeta(dv)
+	return c.applyResource(dv, dv.Namespace, dv.Name, dvRes())
+}
+
+// Secret operations
+
+func (c *client) GetSecret(namespace string, name string) (*k8sv1.Secret, error) {
+	var secret k8sv1.Secret
+	resp, err := c.getResource(namespace, name, secretRes())
+	if err != nil {
+		if errors.IsNotFound(err) {
+			log.Printf("[Warning] Secret %s not found (namespace=%s)", name, namespace)
+			return nil, err
+		}
+		msg := fmt.Sprintf("Failed to get Secret, with error: %v", err)
+		log.Printf("[Error] %s", msg)
+		return nil, fmt.Errorf(msg)
+	}
+	unstructured := resp.UnstructuredContent()
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(unstructured, &secret); err != nil {
+		msg := fmt.Sprintf("Failed to translate unstructed to Secret, with error: %v", err)
+		log.Printf("[Error] %s", msg)
+		return nil, fmt.Errorf(msg)
+	}
+	return &secret, nil
+}
+
+// ApplySecret creates or updates the Secret with server-side apply, whatever the
+// client options, as the provider owns the whole Secret.
+func (c *client) ApplySecret(secret *k8sv1.Secret) error {
+	secretUpdateTypeMeta(secret)
+	return c.applyResource(secret, secret.Namespace, secret.Name, secretRes())
+}
+
+func (c *client) DeleteSecret(namespace string, name string) error {
+	return c.deleteResource(namespace, name, secretRes())
+}
+
+func secretUpdateTypeMeta(secret *k8sv1.Secret) {
+	secret.TypeMeta = metav1.TypeMeta{
+		Kind:       "Secret",
+		APIVersion: k8sv1.SchemeGroupVersion.String(),
+	}
+}
+
+func secretRes() schema.GroupVersionResource {
+	return schema.GroupVersionResource{
+		Group:    k8sv1.SchemeGroupVersion.Group,
+		Version:  k8sv1.SchemeGroupVersion.Version,
+		Resource: "secrets",
+	}
+}
+
+// ServerSideApply reports whether resources should be written using server-side apply
+func (c *client) ServerSideApply() bool {
+	return c.options.ServerSideApply
+}
+
 // GetDynamicClient returns the underlying dynamic client for custom resource operations
 func (c *client) GetDynamicClient() dynamic.Interface {
 	return c.dynamicClient
@@ -218,6 +466,71 @@ func (c *client) updateResource(namespace string, name string, resource schema.G
 	return runtime.DefaultUnstructuredConverter.FromUnstructured(unstructured, obj)
 }
 
+func (c *client) applyResource(obj interface{}, namespace string, name string, resource schema.GroupVersionResource) error {
+	resultMap, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if err != nil {
+		msg := fmt.Sprintf("Failed to translate %s to Unstructed (for apply operation), with error: %v", resource.Resource, err)
+		log.Printf("[Error] %s", msg)
+		return fmt.Errorf(msg)
+	}
+	// Status is owned by the controllers and the resource version would turn
+	// the apply into a conditional update, so neither belongs in the configuration.
+	delete(resultMap, "status")
+	unstructured.RemoveNestedField(resultMap, "metadata", "resourceVersion")
+	data, err := json.Marshal(resultMap)
+	if err != nil {
+		msg := fmt.Sprintf("Failed to marshal %s (for apply operation), with error: %v", resource.Resource, err)
+		log.Printf("[Error] %s", msg)
+		return fmt.Errorf(msg)
+	}
+	options := metav1.PatchOptions{
+		FieldManager: FieldManager,
+		Force:        &c.options.ForceConflicts,
+	}
+	resp, err := c.dynamicClient.Resource(resource).Namespace(namespace).Patch(context.Background(), name, pkgApi.ApplyPatchType, data, options)
+	if err != nil {
+		msg := fmt.Sprintf("Failed to apply %s, with error: %v", resource.Resource, err)
+		log.Printf("[Error] %s", msg)
+		return fmt.Errorf(msg)
+	}
+	unstructured := resp.UnstructuredContent()
+	return runtime.DefaultUnstructuredConverter.FromUnstructured(unstructured, obj)
+}
+
+func (c *client) putSubresource(namespace string, name string, resource string, subresource string, options interface{}) error {
+	req := c.subresourceClient.Put().Namespace(namespace).Resource(resource).Name(name).SubResource(subresource)
+	if options != nil {
+		body, err := json.Marshal(options)
+		if err != nil {
+			msg := fmt.Sprintf("Failed to marshal %s options, with error: %v", subresource, err)
+			log.Printf("[Error] %s", msg)
+			return fmt.Errorf(msg)
+		}
+		req = req.Body(body)
+	}
+	if err := req.Do(context.Background()).Error(); err != nil {
+		msg := fmt.Sprintf("Failed to %s %s %s/%s, with error: %v", subresource, resource, namespace, name, err)
+		log.Printf("[Error] %s", msg)
+		return fmt.Errorf(msg)
+	}
+	return nil
+}
+
+func (c *client) getSubresource(namespace string, name string, resource string, subresource string, out interface{}) error {
+	body, err := c.subresourceClient.Get().Namespace(namespace).Resource(resource).Name(name).SubResource(subresource).Do(context.Background()).Raw()
+	if err != nil {
+		msg := fmt.Sprintf("Failed to get %s of %s %s/%s, with error: %v", subresource, resource, namespace, name, err)
+		log.Printf("[Error] %s", msg)
+		return fmt.Errorf(msg)
+	}
+	if err := json.Unmarshal(body, out); err != nil {
+		msg := fmt.Sprintf("Failed to unmarshal %s of %s %s/%s, with error: %v", subresource, resource, namespace, name, err)
+		log.Printf("[Error] %s", msg)
+		return fmt.Errorf(msg)
+	}
+	return nil
+}
+
 func (c *client) deleteResource(namespace string, name string, resource schema.GroupVersionResource) error {
 	return c.dynamicClient.Resource(resource).Namespace(namespace).Delete(context.Background(), name, metav1.DeleteOptions{})
 }
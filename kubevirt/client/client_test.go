@@ -0,0 +1,179 @@
+package client
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	k8sv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	pkgApi "k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/dynamic"
+	restclient "k8s.io/client-go/rest"
+	kubevirtapiv1 "kubevirt.io/api/core/v1"
+	cdiv1 "kubevirt.io/containerized-data-importer-api/pkg/apis/core/v1beta1"
+
+	"gotest.tools/assert"
+)
+
+// recordedRequest is what the API server under test received.
+type recordedRequest struct {
+	method      string
+	path        string
+	query       url.Values
+	contentType string
+	body        map[string]interface{}
+	rawBody     []byte
+}
+
+// newRecordingClient returns a client talking to an API server that records the request
+// it receives and answers with response.
+func newRecordingClient(t *testing.T, options Options, response interface{}) (Client, *recordedRequest) {
+	recorded := &recordedRequest{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		assert.NilError(t, err)
+
+		recorded.method = r.Method
+		recorded.path = r.URL.Path
+		recorded.query = r.URL.Query()
+		recorded.contentType = r.Header.Get("Content-Type")
+		recorded.rawBody = body
+		recorded.body = nil
+		_ = json.Unmarshal(body, &recorded.body)
+
+		w.Header().Set("Content-Type", "application/json")
+		assert.NilError(t, json.NewEncoder(w).Encode(response))
+	}))
+	t.Cleanup(server.Close)
+
+	dynamicClient, err := dynamic.NewForConfig(&restclient.Config{Host: server.URL})
+	assert.NilError(t, err)
+
+	return &client{dynamicClient: dynamicClient, options: options}, recorded
+}
+
+func TestApplyVirtualMachine(t *testing.T) {
+	cases := []struct {
+		name          string
+		options       Options
+		expectedForce string
+	}{
+		{
+			name:          "without forcing conflicts",
+			options:       Options{ServerSideApply: true},
+			expectedForce: "false",
+		},
+		{
+			name:          "forcing conflicts",
+			options:       Options{ServerSideApply: true, ForceConflicts: true},
+			expectedForce: "true",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			response := map[string]interface{}{
+				"apiVersion": kubevirtapiv1.GroupVersion.String(),
+				"kind":       "VirtualMachine",
+				"metadata": map[string]interface{}{
+					"name":            "test-vm",
+					"namespace":       "default",
+					"resourceVersion": "43",
+				},
+			}
+			c, recorded := newRecordingClient(t, tc.options, response)
+
+			vm := &kubevirtapiv1.VirtualMachine{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:            "test-vm",
+					Namespace:       "default",
+					ResourceVersion: "42",
+				},
+				Status: kubevirtapiv1.VirtualMachineStatus{
+					PrintableStatus: kubevirtapiv1.VirtualMachineStatusRunning,
+				},
+			}
+			assert.NilError(t, c.ApplyVirtualMachine(vm))
+
+			assert.Equal(t, recorded.method, http.MethodPatch)
+			assert.Equal(t, recorded.path, "/apis/kubevirt.io/v1/namespaces/default/virtualmachines/test-vm")
+			assert.Equal(t, recorded.contentType, string(pkgApi.ApplyPatchType))
+			assert.Equal(t, recorded.query.Get("fieldManager"), FieldManager)
+			assert.Equal(t, recorded.query.Get("force"), tc.expectedForce)
+
+			assert.Equal(t, recorded.body["kind"], "VirtualMachine")
+			assert.Equal(t, recorded.body["apiVersion"], kubevirtapiv1.GroupVersion.String())
+			_, hasStatus := recorded.body["status"]
+			assert.Assert(t, !hasStatus, "status must not be applied")
+			_, hasResourceVersion := recorded.body["metadata"].(map[string]interface{})["resourceVersion"]
+			assert.Assert(t, !hasResourceVersion, "resourceVersion must not be applied")
+
+			assert.Equal(t, vm.ResourceVersion, "43")
+		})
+	}
+}
+
+func TestApplyDataVolume(t *testing.T) {
+	response := map[string]interface{}{
+		"apiVersion": cdiv1.SchemeGroupVersion.String(),
+		"kind":       "DataVolume",
+		"metadata":   map[string]interface{}{"name": "test-dv", "namespace": "default"},
+	}
+	c, recorded := newRecordingClient(t, Options{ServerSideApply: true}, response)
+
+	dv := &cdiv1.DataVolume{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-dv", Namespace: "default"},
+		Status:     cdiv1.DataVolumeStatus{Phase: cdiv1.Succeeded},
+	}
+	assert.NilError(t, c.ApplyDataVolume(dv))
+
+	assert.Equal(t, recorded.path, "/apis/cdi.kubevirt.io/v1beta1/namespaces/default/datavolumes/test-dv")
+	assert.Equal(t, recorded.contentType, string(pkgApi.ApplyPatchType))
+	assert.Equal(t, recorded.query.Get("fieldManager"), FieldManager)
+	assert.Equal(t, recorded.body["kind"], "DataVolume")
+	_, hasStatus := recorded.body["status"]
+	assert.Assert(t, !hasStatus, "status must not be applied")
+}
+
+func TestApplySecret(t *testing.T) {
+	response := map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Secret",
+		"metadata":   map[string]interface{}{"name": "test-vm-cloudinitdisk-cloud-init", "namespace": "default"},
+	}
+	// Secrets are always applied, whatever the client options.
+	c, recorded := newRecordingClient(t, Options{}, response)
+
+	secret := &k8sv1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-vm-cloudinitdisk-cloud-init", Namespace: "default"},
+		Data:       map[string][]byte{"userdata": []byte("#cloud-config")},
+	}
+	assert.NilError(t, c.ApplySecret(secret))
+
+	assert.Equal(t, recorded.path, "/api/v1/namespaces/default/secrets/test-vm-cloudinitdisk-cloud-init")
+	assert.Equal(t, recorded.contentType, string(pkgApi.ApplyPatchType))
+	assert.Equal(t, recorded.query.Get("fieldManager"), FieldManager)
+	assert.Equal(t, recorded.body["kind"], "Secret")
+	assert.DeepEqual(t, recorded.body["data"], map[string]interface{}{"userdata": "I2Nsb3VkLWNvbmZpZw=="})
+}
+
+func TestUpdateVirtualMachine(t *testing.T) {
+	response := map[string]interface{}{
+		"apiVersion": kubevirtapiv1.GroupVersion.String(),
+		"kind":       "VirtualMachine",
+		"metadata":   map[string]interface{}{"name": "test-vm", "namespace": "default"},
+	}
+	c, recorded := newRecordingClient(t, Options{}, response)
+
+	data := []byte(`[{"op":"replace","path":"/spec/runStrategy","value":"Halted"}]`)
+	assert.NilError(t, c.UpdateVirtualMachine("default", "test-vm", &kubevirtapiv1.VirtualMachine{}, data))
+
+	assert.Equal(t, recorded.method, http.MethodPatch)
+	assert.Equal(t, recorded.contentType, string(pkgApi.JSONPatchType))
+	assert.Equal(t, recorded.query.Get("fieldManager"), "")
+	assert.Equal(t, string(recorded.rawBody), string(data))
+}
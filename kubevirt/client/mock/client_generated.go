@@ -6,8 +6,13 @@ package mock
 
 import (
 	reflect "reflect"
+	time "time"
 
 	gomock "github.com/golang/mock/gomock"
+	client "github.com/nrp-nautilus/terraform-provider-kubevirt/kubevirt/client"
+	v10 "k8s.io/api/core/v1"
+	schema "k8s.io/apimachinery/pkg/runtime/schema"
+	dynamic "k8s.io/client-go/dynamic"
 	v1 "kubevirt.io/api/core/v1"
 	v1beta1 "kubevirt.io/containerized-data-importer-api/pkg/apis/core/v1beta1"
 )
@@ -35,6 +40,62 @@ func (m *MockClient) EXPECT() *MockClientMockRecorder {
 	return m.recorder
 }
 
+// ApplyDataVolume mocks base method.
+func (m *MockClient) ApplyDataVolume(dv *v1beta1.DataVolume) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ApplyDataVolume", dv)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ApplyDataVolume indicates an expected call of ApplyDataVolume.
+func (mr *MockClientMockRecorder) ApplyDataVolume(dv interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ApplyDataVolume", reflect.TypeOf((*MockClient)(nil).ApplyDataVolume), dv)
+}
+
+// ApplySecret mocks base method.
+func (m *MockClient) ApplySecret(secret *v10.Secret) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ApplySecret", secret)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ApplySecret indicates an expected call of ApplySecret.
+func (mr *MockClientMockRecorder) ApplySecret(secret interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ApplySecret", reflect.TypeOf((*MockClient)(nil).ApplySecret), secret)
+}
+
+// ApplyVirtualMachine mocks base method.
+func (m *MockClient) ApplyVirtualMachine(vm *v1.VirtualMachine) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ApplyVirtualMachine", vm)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ApplyVirtualMachine indicates an expected call of ApplyVirtualMachine.
+func (mr *MockClientMockRecorder) ApplyVirtualMachine(vm interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ApplyVirtualMachine", reflect.TypeOf((*MockClient)(nil).ApplyVirtualMachine), vm)
+}
+
+// ApplyVirtualMachineInstance mocks base method.
+func (m *MockClient) ApplyVirtualMachineInstance(vmi *v1.VirtualMachineInstance) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ApplyVirtualMachineInstance", vmi)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ApplyVirtualMachineInstance indicates an expected call of ApplyVirtualMachineInstance.
+func (mr *MockClientMockRecorder) ApplyVirtualMachineInstance(vmi interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ApplyVirtualMachineInstance", reflect.TypeOf((*MockClient)(nil).ApplyVirtualMachineInstance), vmi)
+}
+
 // CreateDataVolume mocks base method.
 func (m *MockClient) CreateDataVolume(vm *v1beta1.DataVolume) error {
 	m.ctrl.T.Helper()
@@ -63,6 +124,20 @@ func (mr *MockClientMockRecorder) CreateVirtualMachine(vm interface{}) *gomock.C
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateVirtualMachine", reflect.TypeOf((*MockClient)(nil).CreateVirtualMachine), vm)
 }
 
+// CreateVirtualMachineInstance mocks base method.
+func (m *MockClient) CreateVirtualMachineInstance(vmi *v1.VirtualMachineInstance) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateVirtualMachineInstance", vmi)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateVirtualMachineInstance indicates an expected call of CreateVirtualMachineInstance.
+func (mr *MockClientMockRecorder) CreateVirtualMachineInstance(vmi interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateVirtualMachineInstance", reflect.TypeOf((*MockClient)(nil).CreateVirtualMachineInstance), vmi)
+}
+
 // DeleteDataVolume mocks base method.
 func (m *MockClient) DeleteDataVolume(namespace, name string) error {
 	m.ctrl.T.Helper()
@@ -77,6 +152,20 @@ func (mr *MockClientMockRecorder) DeleteDataVolume(namespace, name interface{})
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteDataVolume", reflect.TypeOf((*MockClient)(nil).DeleteDataVolume), namespace, name)
 }
 
+// DeleteSecret mocks base method.
+func (m *MockClient) DeleteSecret(namespace, name string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteSecret", namespace, name)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteSecret indicates an expected call of DeleteSecret.
+func (mr *MockClientMockRecorder) DeleteSecret(namespace, name interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteSecret", reflect.TypeOf((*MockClient)(nil).DeleteSecret), namespace, name)
+}
+
 // DeleteVirtualMachine mocks base method.
 func (m *MockClient) DeleteVirtualMachine(namespace, name string) error {
 	m.ctrl.T.Helper()
@@ -91,6 +180,20 @@ func (mr *MockClientMockRecorder) DeleteVirtualMachine(namespace, name interface
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteVirtualMachine", reflect.TypeOf((*MockClient)(nil).DeleteVirtualMachine), namespace, name)
 }
 
+// DeleteVirtualMachineInstance mocks base method.
+func (m *MockClient) DeleteVirtualMachineInstance(namespace, name string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteVirtualMachineInstance", namespace, name)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteVirtualMachineInstance indicates an expected call of DeleteVirtualMachineInstance.
+func (mr *MockClientMockRecorder) DeleteVirtualMachineInstance(namespace, name interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteVirtualMachineInstance", reflect.TypeOf((*MockClient)(nil).DeleteVirtualMachineInstance), namespace, name)
+}
+
 // GetDataVolume mocks base method.
 func (m *MockClient) GetDataVolume(namespace, name string) (*v1beta1.DataVolume, error) {
 	m.ctrl.T.Helper()
@@ -106,6 +209,35 @@ func (mr *MockClientMockRecorder) GetDataVolume(namespace, name interface{}) *go
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDataVolume", reflect.TypeOf((*MockClient)(nil).GetDataVolume), namespace, name)
 }
 
+// GetDynamicClient mocks base method.
+func (m *MockClient) GetDynamicClient() dynamic.Interface {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDynamicClient")
+	ret0, _ := ret[0].(dynamic.Interface)
+	return ret0
+}
+
+// GetDynamicClient indicates an expected call of GetDynamicClient.
+func (mr *MockClientMockRecorder) GetDynamicClient() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDynamicClient", reflect.TypeOf((*MockClient)(nil).GetDynamicClient))
+}
+
+// GetSecret mocks base method.
+func (m *MockClient) GetSecret(namespace, name string) (*v10.Secret, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSecret", namespace, name)
+	ret0, _ := ret[0].(*v10.Secret)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSecret indicates an expected call of GetSecret.
+func (mr *MockClientMockRecorder) GetSecret(namespace, name interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSecret", reflect.TypeOf((*MockClient)(nil).GetSecret), namespace, name)
+}
+
 // GetVirtualMachine mocks base method.
 func (m *MockClient) GetVirtualMachine(namespace, name string) (*v1.VirtualMachine, error) {
 	m.ctrl.T.Helper()
@@ -121,6 +253,164 @@ func (mr *MockClientMockRecorder) GetVirtualMachine(namespace, name interface{})
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetVirtualMachine", reflect.TypeOf((*MockClient)(nil).GetVirtualMachine), namespace, name)
 }
 
+// GetVirtualMachineInstance mocks base method.
+func (m *MockClient) GetVirtualMachineInstance(namespace, name string) (*v1.VirtualMachineInstance, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetVirtualMachineInstance", namespace, name)
+	ret0, _ := ret[0].(*v1.VirtualMachineInstance)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetVirtualMachineInstance indicates an expected call of GetVirtualMachineInstance.
+func (mr *MockClientMockRecorder) GetVirtualMachineInstance(namespace, name interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetVirtualMachineInstance", reflect.TypeOf((*MockClient)(nil).GetVirtualMachineInstance), namespace, name)
+}
+
+// GetVirtualMachineInstanceFileSystemList mocks base method.
+func (m *MockClient) GetVirtualMachineInstanceFileSystemList(namespace, name string) (*v1.VirtualMachineInstanceFileSystemList, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetVirtualMachineInstanceFileSystemList", namespace, name)
+	ret0, _ := ret[0].(*v1.VirtualMachineInstanceFileSystemList)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetVirtualMachineInstanceFileSystemList indicates an expected call of GetVirtualMachineInstanceFileSystemList.
+func (mr *MockClientMockRecorder) GetVirtualMachineInstanceFileSystemList(namespace, name interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetVirtualMachineInstanceFileSystemList", reflect.TypeOf((*MockClient)(nil).GetVirtualMachineInstanceFileSystemList), namespace, name)
+}
+
+// GetVirtualMachineInstanceGuestOSInfo mocks base method.
+func (m *MockClient) GetVirtualMachineInstanceGuestOSInfo(namespace, name string) (*v1.VirtualMachineInstanceGuestAgentInfo, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetVirtualMachineInstanceGuestOSInfo", namespace, name)
+	ret0, _ := ret[0].(*v1.VirtualMachineInstanceGuestAgentInfo)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetVirtualMachineInstanceGuestOSInfo indicates an expected call of GetVirtualMachineInstanceGuestOSInfo.
+func (mr *MockClientMockRecorder) GetVirtualMachineInstanceGuestOSInfo(namespace, name interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetVirtualMachineInstanceGuestOSInfo", reflect.TypeOf((*MockClient)(nil).GetVirtualMachineInstanceGuestOSInfo), namespace, name)
+}
+
+// GetVirtualMachineInstanceUserList mocks base method.
+func (m *MockClient) GetVirtualMachineInstanceUserList(namespace, name string) (*v1.VirtualMachineInstanceGuestOSUserList, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetVirtualMachineInstanceUserList", namespace, name)
+	ret0, _ := ret[0].(*v1.VirtualMachineInstanceGuestOSUserList)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetVirtualMachineInstanceUserList indicates an expected call of GetVirtualMachineInstanceUserList.
+func (mr *MockClientMockRecorder) GetVirtualMachineInstanceUserList(namespace, name interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetVirtualMachineInstanceUserList", reflect.TypeOf((*MockClient)(nil).GetVirtualMachineInstanceUserList), namespace, name)
+}
+
+// PauseVirtualMachineInstance mocks base method.
+func (m *MockClient) PauseVirtualMachineInstance(namespace, name string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PauseVirtualMachineInstance", namespace, name)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// PauseVirtualMachineInstance indicates an expected call of PauseVirtualMachineInstance.
+func (mr *MockClientMockRecorder) PauseVirtualMachineInstance(namespace, name interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PauseVirtualMachineInstance", reflect.TypeOf((*MockClient)(nil).PauseVirtualMachineInstance), namespace, name)
+}
+
+// RestartVirtualMachine mocks base method.
+func (m *MockClient) RestartVirtualMachine(namespace, name string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RestartVirtualMachine", namespace, name)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RestartVirtualMachine indicates an expected call of RestartVirtualMachine.
+func (mr *MockClientMockRecorder) RestartVirtualMachine(namespace, name interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RestartVirtualMachine", reflect.TypeOf((*MockClient)(nil).RestartVirtualMachine), namespace, name)
+}
+
+// ServerSideApply mocks base method.
+func (m *MockClient) ServerSideApply() bool {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ServerSideApply")
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// ServerSideApply indicates an expected call of ServerSideApply.
+func (mr *MockClientMockRecorder) ServerSideApply() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ServerSideApply", reflect.TypeOf((*MockClient)(nil).ServerSideApply))
+}
+
+// SoftRebootVirtualMachineInstance mocks base method.
+func (m *MockClient) SoftRebootVirtualMachineInstance(namespace, name string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SoftRebootVirtualMachineInstance", namespace, name)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SoftRebootVirtualMachineInstance indicates an expected call of SoftRebootVirtualMachineInstance.
+func (mr *MockClientMockRecorder) SoftRebootVirtualMachineInstance(namespace, name interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SoftRebootVirtualMachineInstance", reflect.TypeOf((*MockClient)(nil).SoftRebootVirtualMachineInstance), namespace, name)
+}
+
+// StartVirtualMachine mocks base method.
+func (m *MockClient) StartVirtualMachine(namespace, name string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StartVirtualMachine", namespace, name)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// StartVirtualMachine indicates an expected call of StartVirtualMachine.
+func (mr *MockClientMockRecorder) StartVirtualMachine(namespace, name interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StartVirtualMachine", reflect.TypeOf((*MockClient)(nil).StartVirtualMachine), namespace, name)
+}
+
+// StopVirtualMachine mocks base method.
+func (m *MockClient) StopVirtualMachine(namespace, name string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StopVirtualMachine", namespace, name)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// StopVirtualMachine indicates an expected call of StopVirtualMachine.
+func (mr *MockClientMockRecorder) StopVirtualMachine(namespace, name interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StopVirtualMachine", reflect.TypeOf((*MockClient)(nil).StopVirtualMachine), namespace, name)
+}
+
+// UnpauseVirtualMachineInstance mocks base method.
+func (m *MockClient) UnpauseVirtualMachineInstance(namespace, name string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UnpauseVirtualMachineInstance", namespace, name)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UnpauseVirtualMachineInstance indicates an expected call of UnpauseVirtualMachineInstance.
+func (mr *MockClientMockRecorder) UnpauseVirtualMachineInstance(namespace, name interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UnpauseVirtualMachineInstance", reflect.TypeOf((*MockClient)(nil).UnpauseVirtualMachineInstance), namespace, name)
+}
+
 // UpdateDataVolume mocks base method.
 func (m *MockClient) UpdateDataVolume(namespace, name string, dv *v1beta1.DataVolume, data []byte) error {
 	m.ctrl.T.Helper()
@@ -148,3 +438,76 @@ func (mr *MockClientMockRecorder) UpdateVirtualMachine(namespace, name, vm, data
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateVirtualMachine", reflect.TypeOf((*MockClient)(nil).UpdateVirtualMachine), namespace, name, vm, data)
 }
+
+// UpdateVirtualMachineInstance mocks base method.
+func (m *MockClient) UpdateVirtualMachineInstance(namespace, name string, vmi *v1.VirtualMachineInstance, data []byte) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateVirtualMachineInstance", namespace, name, vmi, data)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateVirtualMachineInstance indicates an expected call of UpdateVirtualMachineInstance.
+func (mr *MockClientMockRecorder) UpdateVirtualMachineInstance(namespace, name, vmi, data interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateVirtualMachineInstance", reflect.TypeOf((*MockClient)(nil).UpdateVirtualMachineInstance), namespace, name, vmi, data)
+}
+
+// WaitForDataVolumePhase mocks base method.
+func (m *MockClient) WaitForDataVolumePhase(namespace, name string, phase v1beta1.DataVolumePhase, timeout time.Duration) (*v1beta1.DataVolume, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WaitForDataVolumePhase", namespace, name, phase, timeout)
+	ret0, _ := ret[0].(*v1beta1.DataVolume)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// WaitForDataVolumePhase indicates an expected call of WaitForDataVolumePhase.
+func (mr *MockClientMockRecorder) WaitForDataVolumePhase(namespace, name, phase, timeout interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WaitForDataVolumePhase", reflect.TypeOf((*MockClient)(nil).WaitForDataVolumePhase), namespace, name, phase, timeout)
+}
+
+// WaitForDeletion mocks base method.
+func (m *MockClient) WaitForDeletion(resource schema.GroupVersionResource, namespace, name string, timeout time.Duration) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WaitForDeletion", resource, namespace, name, timeout)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// WaitForDeletion indicates an expected call of WaitForDeletion.
+func (mr *MockClientMockRecorder) WaitForDeletion(resource, namespace, name, timeout interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WaitForDeletion", reflect.TypeOf((*MockClient)(nil).WaitForDeletion), resource, namespace, name, timeout)
+}
+
+// WaitForVMCondition mocks base method.
+func (m *MockClient) WaitForVMCondition(namespace, name string, condition client.VirtualMachineConditionFunc, timeout time.Duration) (*v1.VirtualMachine, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WaitForVMCondition", namespace, name, condition, timeout)
+	ret0, _ := ret[0].(*v1.VirtualMachine)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// WaitForVMCondition indicates an expected call of WaitForVMCondition.
+func (mr *MockClientMockRecorder) WaitForVMCondition(namespace, name, condition, timeout interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WaitForVMCondition", reflect.TypeOf((*MockClient)(nil).WaitForVMCondition), namespace, name, condition, timeout)
+}
+
+// WaitForVMICondition mocks base method.
+func (m *MockClient) WaitForVMICondition(namespace, name string, condition client.VirtualMachineInstanceConditionFunc, timeout time.Duration) (*v1.VirtualMachineInstance, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WaitForVMICondition", namespace, name, condition, timeout)
+	ret0, _ := ret[0].(*v1.VirtualMachineInstance)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// WaitForVMICondition indicates an expected call of WaitForVMICondition.
+func (mr *MockClientMockRecorder) WaitForVMICondition(namespace, name, condition, timeout interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WaitForVMICondition", reflect.TypeOf((*MockClient)(nil).WaitForVMICondition), namespace, name, condition, timeout)
+}
@@ -0,0 +1,243 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	unstructured "k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/apimachinery/pkg/watch"
+	kubevirtapiv1 "kubevirt.io/api/core/v1"
+	cdiv1 "kubevirt.io/containerized-data-importer-api/pkg/apis/core/v1beta1"
+)
+
+// pollInterval is how often an object is fetched when watching it is forbidden.
+const pollInterval = 10 * time.Second
+
+// Resources that can be passed to WaitForDeletion.
+var (
+	VirtualMachineResource         = vmRes()
+	VirtualMachineInstanceResource = vmiRes()
+	DataVolumeResource             = dvRes()
+)
+
+// VirtualMachineConditionFunc reports whether the virtual machine reached the awaited state.
+// Returning an error stops the wait.
+type VirtualMachineConditionFunc func(vm *kubevirtapiv1.VirtualMachine) (bool, error)
+
+// VirtualMachineInstanceConditionFunc reports whether the virtual machine instance reached the awaited state.
+// Returning an error stops the wait.
+type VirtualMachineInstanceConditionFunc func(vmi *kubevirtapiv1.VirtualMachineInstance) (bool, error)
+
+// objectConditionFunc reports whether the watched object reached the awaited state.
+// obj is nil while the object doesn't exist.
+type objectConditionFunc func(obj *unstructured.Unstructured) (bool, error)
+
+func (c *client) WaitForDataVolumePhase(namespace string, name string, phase cdiv1.DataVolumePhase, timeout time.Duration) (*cdiv1.DataVolume, error) {
+	var dv *cdiv1.DataVolume
+	err := c.waitForObject(namespace, name, dvRes(), timeout, func(obj *unstructured.Unstructured) (bool, error) {
+		if obj == nil {
+			log.Printf("[DEBUG] data volume %s is not created yet", name)
+			return false, nil
+		}
+		dv = &cdiv1.DataVolume{}
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.UnstructuredContent(), dv); err != nil {
+			return false, err
+		}
+
+		switch dv.Status.Phase {
+		case phase:
+			return true, nil
+		case cdiv1.Failed:
+			return false, fmt.Errorf("data volume failed to be created, finished with phase=\"failed\"")
+		}
+
+		log.Printf("[DEBUG] data volume %s is in phase %q, waiting for %q", name, dv.Status.Phase, phase)
+		return false, nil
+	})
+	return dv, err
+}
+
+func (c *client) WaitForVMCondition(namespace string, name string, condition VirtualMachineConditionFunc, timeout time.Duration) (*kubevirtapiv1.VirtualMachine, error) {
+	var vm *kubevirtapiv1.VirtualMachine
+	err := c.waitForObject(namespace, name, vmRes(), timeout, func(obj *unstructured.Unstructured) (bool, error) {
+		if obj == nil {
+			log.Printf("[DEBUG] virtual machine %s is not created yet", name)
+			return false, nil
+		}
+		vm = &kubevirtapiv1.VirtualMachine{}
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.UnstructuredContent(), vm); err != nil {
+			return false, err
+		}
+		return condition(vm)
+	})
+	return vm, err
+}
+
+func (c *client) WaitForVMICondition(namespace string, name string, condition VirtualMachineInstanceConditionFunc, timeout time.Duration) (*kubevirtapiv1.VirtualMachineInstance, error) {
+	var vmi *kubevirtapiv1.VirtualMachineInstance
+	err := c.waitForObject(namespace, name, vmiRes(), timeout, func(obj *unstructured.Unstructured) (bool, error) {
+		if obj == nil {
+			log.Printf("[DEBUG] virtual machine instance %s is not created yet", name)
+			return false, nil
+		}
+		vmi = &kubevirtapiv1.VirtualMachineInstance{}
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.UnstructuredContent(), vmi); err != nil {
+			return false, err
+		}
+		return condition(vmi)
+	})
+	return vmi, err
+}
+
+func (c *client) WaitForDeletion(resource schema.GroupVersionResource, namespace string, name string, timeout time.Duration) error {
+	return c.waitForObject(namespace, name, resource, timeout, func(obj *unstructured.Unstructured) (bool, error) {
+		if obj != nil {
+			log.Printf("[DEBUG] %s %s is being deleted", resource.Resource, name)
+		}
+		return obj == nil, nil
+	})
+}
+
+// waitForObject waits until condition holds for the named object. The object is watched
+// from the resource version it was listed at; when that version expires the object is
+// listed again, and when RBAC forbids listing or watching it's polled instead.
+func (c *client) waitForObject(namespace string, name string, resource schema.GroupVersionResource, timeout time.Duration, condition objectConditionFunc) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	ri := c.dynamicClient.Resource(resource).Namespace(namespace)
+	fieldSelector := fields.OneTermEqualSelector("metadata.name", name).String()
+
+	for {
+		list, err := ri.List(ctx, metav1.ListOptions{FieldSelector: fieldSelector})
+		if err != nil {
+			if errors.IsForbidden(err) {
+				log.Printf("[DEBUG] listing %s is forbidden, polling %s/%s instead", resource.Resource, namespace, name)
+				return c.pollForObject(ctx, namespace, name, resource, condition)
+			}
+			return waitError(ctx, namespace, name, resource, err)
+		}
+
+		var obj *unstructured.Unstructured
+		if len(list.Items) > 0 {
+			obj = &list.Items[0]
+		}
+		if done, err := condition(obj); done || err != nil {
+			return err
+		}
+
+		done, err := c.watchObject(ctx, namespace, resource, fieldSelector, list.GetResourceVersion(), condition)
+		if err != nil {
+			if errors.IsForbidden(err) {
+				log.Printf("[DEBUG] watching %s is forbidden, polling %s/%s instead", resource.Resource, namespace, name)
+				return c.pollForObject(ctx, namespace, name, resource, condition)
+			}
+			return waitError(ctx, namespace, name, resource, err)
+		}
+		if done {
+			return nil
+		}
+		log.Printf("[DEBUG] watch on %s %s/%s expired, listing it again", resource.Resource, namespace, name)
+	}
+}
+
+// watchObject consumes watch events until condition holds or the resource version it
+// watches from expires, which is reported as not done without an error. Watches closed
+// by the server are resumed from the last seen resource version.
+func (c *client) watchObject(ctx context.Context, namespace string, resource schema.GroupVersionResource, fieldSelector string, resourceVersion string, condition objectConditionFunc) (bool, error) {
+	ri := c.dynamicClient.Resource(resource).Namespace(namespace)
+
+	for {
+		w, err := ri.Watch(ctx, metav1.ListOptions{
+			FieldSelector:       fieldSelector,
+			ResourceVersion:     resourceVersion,
+			AllowWatchBookmarks: true,
+		})
+		if err != nil {
+			if errors.IsResourceExpired(err) || errors.IsGone(err) {
+				return false, nil
+			}
+			return false, err
+		}
+
+		done, expired, err := consumeWatch(ctx, w, &resourceVersion, condition)
+		if done || expired || err != nil {
+			return done, err
+		}
+	}
+}
+
+// consumeWatch handles the events of a single watch, keeping resourceVersion up to date
+// so the watch can be resumed once the server closes it.
+func consumeWatch(ctx context.Context, w watch.Interface, resourceVersion *string, condition objectConditionFunc) (done bool, expired bool, err error) {
+	defer w.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return false, false, ctx.Err()
+		case event, ok := <-w.ResultChan():
+			if !ok {
+				return false, false, nil
+			}
+
+			if event.Type == watch.Error {
+				err := errors.FromObject(event.Object)
+				if errors.IsResourceExpired(err) || errors.IsGone(err) {
+					return false, true, nil
+				}
+				return false, false, err
+			}
+
+			obj, ok := event.Object.(*unstructured.Unstructured)
+			if !ok {
+				continue
+			}
+			*resourceVersion = obj.GetResourceVersion()
+
+			switch event.Type {
+			case watch.Added, watch.Modified:
+				done, err = condition(obj)
+			case watch.Deleted:
+				done, err = condition(nil)
+			}
+			if done || err != nil {
+				return done, false, err
+			}
+		}
+	}
+}
+
+func (c *client) pollForObject(ctx context.Context, namespace string, name string, resource schema.GroupVersionResource, condition objectConditionFunc) error {
+	err := wait.PollImmediateUntilWithContext(ctx, pollInterval, func(ctx context.Context) (bool, error) {
+		obj, err := c.dynamicClient.Resource(resource).Namespace(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			if !errors.IsNotFound(err) {
+				return false, err
+			}
+			obj = nil
+		}
+		return condition(obj)
+	})
+	return waitError(ctx, namespace, name, resource, err)
+}
+
+func waitError(ctx context.Context, namespace string, name string, resource schema.GroupVersionResource, err error) error {
+	if err == nil {
+		return nil
+	}
+	if ctx.Err() != nil {
+		msg := fmt.Sprintf("Timed out waiting for %s %s/%s", resource.Resource, namespace, name)
+		log.Printf("[Error] %s", msg)
+		return fmt.Errorf(msg)
+	}
+	return err
+}
@@ -0,0 +1,313 @@
+package client
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	unstructured "k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/watch"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	k8stesting "k8s.io/client-go/testing"
+	kubevirtapiv1 "kubevirt.io/api/core/v1"
+	cdiv1 "kubevirt.io/containerized-data-importer-api/pkg/apis/core/v1beta1"
+
+	"gotest.tools/assert"
+)
+
+// fakeWatches serves the watches of the fake dynamic client, one per watch request, recording
+// the resource versions they're started from.
+type fakeWatches struct {
+	watches          []*watch.FakeWatcher
+	errors           []error
+	resourceVersions []string
+}
+
+func (f *fakeWatches) react(action k8stesting.Action) (bool, watch.Interface, error) {
+	i := len(f.resourceVersions)
+	f.resourceVersions = append(f.resourceVersions, action.(k8stesting.WatchActionImpl).WatchRestrictions.ResourceVersion)
+	if i < len(f.errors) && f.errors[i] != nil {
+		return true, nil, f.errors[i]
+	}
+	if i >= len(f.watches) {
+		return true, nil, fmt.Errorf("unexpected watch from resource version %q", f.resourceVersions[i])
+	}
+	return true, f.watches[i], nil
+}
+
+// newFakeWatch returns a watch delivering the given events, closed once they're consumed
+// unless it's kept open.
+func newFakeWatch(keepOpen bool, events ...watch.Event) *watch.FakeWatcher {
+	w := watch.NewFakeWithChanSize(len(events), false)
+	for _, event := range events {
+		w.Action(event.Type, event.Object)
+	}
+	if !keepOpen {
+		w.Stop()
+	}
+	return w
+}
+
+func newWaitClient(t *testing.T, lists []runtime.Object, listErr error, watches *fakeWatches) (*client, *dynamicfake.FakeDynamicClient, *int) {
+	dynamicClient := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{
+		vmRes(): "VirtualMachineList",
+		dvRes(): "DataVolumeList",
+	})
+
+	listed := 0
+	dynamicClient.PrependReactor("list", "*", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		listed++
+		if listErr != nil {
+			return true, nil, listErr
+		}
+		if listed > len(lists) {
+			return true, nil, fmt.Errorf("unexpected list")
+		}
+		return true, lists[listed-1], nil
+	})
+	if watches != nil {
+		dynamicClient.PrependWatchReactor("*", watches.react)
+	}
+
+	return &client{dynamicClient: dynamicClient}, dynamicClient, &listed
+}
+
+func vmList(resourceVersion string, vms ...*unstructured.Unstructured) *unstructured.UnstructuredList {
+	list := &unstructured.UnstructuredList{Object: map[string]interface{}{
+		"apiVersion": kubevirtapiv1.GroupVersion.String(),
+		"kind":       "VirtualMachineList",
+	}}
+	list.SetResourceVersion(resourceVersion)
+	for _, vm := range vms {
+		list.Items = append(list.Items, *vm)
+	}
+	return list
+}
+
+func unstructuredVM(resourceVersion string, printableStatus kubevirtapiv1.VirtualMachinePrintableStatus) *unstructured.Unstructured {
+	vm := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": kubevirtapiv1.GroupVersion.String(),
+		"kind":       "VirtualMachine",
+		"metadata": map[string]interface{}{
+			"name":            "test-vm",
+			"namespace":       "default",
+			"resourceVersion": resourceVersion,
+		},
+		"status": map[string]interface{}{
+			"printableStatus": string(printableStatus),
+		},
+	}}
+	return vm
+}
+
+func expiredEvent() watch.Event {
+	status := errors.NewResourceExpired("too old resource version").ErrStatus
+	return watch.Event{Type: watch.Error, Object: &status}
+}
+
+func isRunning(vm *kubevirtapiv1.VirtualMachine) (bool, error) {
+	return vm.Status.PrintableStatus == kubevirtapiv1.VirtualMachineStatusRunning, nil
+}
+
+func TestWaitForVMCondition(t *testing.T) {
+	forbidden := errors.NewForbidden(schema.GroupResource{Group: "kubevirt.io", Resource: "virtualmachines"}, "", fmt.Errorf("RBAC"))
+
+	cases := []struct {
+		name                     string
+		lists                    []runtime.Object
+		listErr                  error
+		watches                  *fakeWatches
+		get                      *unstructured.Unstructured
+		expectedLists            int
+		expectedResourceVersions []string
+		expectedGets             int
+		expectedError            string
+	}{
+		{
+			name:          "condition holding when listed",
+			lists:         []runtime.Object{vmList("10", unstructuredVM("10", kubevirtapiv1.VirtualMachineStatusRunning))},
+			watches:       &fakeWatches{},
+			expectedLists: 1,
+		},
+		{
+			name:  "condition holding after watch events",
+			lists: []runtime.Object{vmList("10", unstructuredVM("10", kubevirtapiv1.VirtualMachineStatusStopped))},
+			watches: &fakeWatches{watches: []*watch.FakeWatcher{
+				newFakeWatch(true,
+					watch.Event{Type: watch.Modified, Object: unstructuredVM("11", kubevirtapiv1.VirtualMachineStatusStarting)},
+					watch.Event{Type: watch.Modified, Object: unstructuredVM("12", kubevirtapiv1.VirtualMachineStatusRunning)},
+				),
+			}},
+			expectedLists:            1,
+			expectedResourceVersions: []string{"10"},
+		},
+		{
+			name:  "object created while watching",
+			lists: []runtime.Object{vmList("10")},
+			watches: &fakeWatches{watches: []*watch.FakeWatcher{
+				newFakeWatch(true, watch.Event{Type: watch.Added, Object: unstructuredVM("11", kubevirtapiv1.VirtualMachineStatusRunning)}),
+			}},
+			expectedLists:            1,
+			expectedResourceVersions: []string{"10"},
+		},
+		{
+			name:  "watch closed by the server",
+			lists: []runtime.Object{vmList("10", unstructuredVM("10", kubevirtapiv1.VirtualMachineStatusStopped))},
+			watches: &fakeWatches{watches: []*watch.FakeWatcher{
+				newFakeWatch(false, watch.Event{Type: watch.Modified, Object: unstructuredVM("11", kubevirtapiv1.VirtualMachineStatusStarting)}),
+				newFakeWatch(true, watch.Event{Type: watch.Modified, Object: unstructuredVM("12", kubevirtapiv1.VirtualMachineStatusRunning)}),
+			}},
+			expectedLists:            1,
+			expectedResourceVersions: []string{"10", "11"},
+		},
+		{
+			name: "resource version expired during the watch",
+			lists: []runtime.Object{
+				vmList("10", unstructuredVM("10", kubevirtapiv1.VirtualMachineStatusStopped)),
+				vmList("20", unstructuredVM("20", kubevirtapiv1.VirtualMachineStatusStarting)),
+			},
+			watches: &fakeWatches{watches: []*watch.FakeWatcher{
+				newFakeWatch(true, expiredEvent()),
+				newFakeWatch(true, watch.Event{Type: watch.Modified, Object: unstructuredVM("21", kubevirtapiv1.VirtualMachineStatusRunning)}),
+			}},
+			expectedLists:            2,
+			expectedResourceVersions: []string{"10", "20"},
+		},
+		{
+			name: "resource version expired when watching",
+			lists: []runtime.Object{
+				vmList("10", unstructuredVM("10", kubevirtapiv1.VirtualMachineStatusStopped)),
+				vmList("20", unstructuredVM("20", kubevirtapiv1.VirtualMachineStatusRunning)),
+			},
+			watches: &fakeWatches{
+				errors:  []error{errors.NewResourceExpired("too old resource version")},
+				watches: []*watch.FakeWatcher{nil},
+			},
+			expectedLists:            2,
+			expectedResourceVersions: []string{"10"},
+		},
+		{
+			name:          "listing forbidden",
+			listErr:       forbidden,
+			watches:       &fakeWatches{},
+			get:           unstructuredVM("10", kubevirtapiv1.VirtualMachineStatusRunning),
+			expectedLists: 1,
+			expectedGets:  1,
+		},
+		{
+			name:  "watching forbidden",
+			lists: []runtime.Object{vmList("10", unstructuredVM("10", kubevirtapiv1.VirtualMachineStatusStopped))},
+			watches: &fakeWatches{
+				errors: []error{forbidden},
+			},
+			get:                      unstructuredVM("11", kubevirtapiv1.VirtualMachineStatusRunning),
+			expectedLists:            1,
+			expectedResourceVersions: []string{"10"},
+			expectedGets:             1,
+		},
+		{
+			name:          "listing failed",
+			listErr:       fmt.Errorf("connection refused"),
+			watches:       &fakeWatches{},
+			expectedLists: 1,
+			expectedError: "connection refused",
+		},
+		{
+			name:  "timeout",
+			lists: []runtime.Object{vmList("10", unstructuredVM("10", kubevirtapiv1.VirtualMachineStatusStopped))},
+			watches: &fakeWatches{watches: []*watch.FakeWatcher{
+				newFakeWatch(true),
+			}},
+			expectedLists:            1,
+			expectedResourceVersions: []string{"10"},
+			expectedError:            "Timed out waiting for virtualmachines default/test-vm",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			c, dynamicClient, listed := newWaitClient(t, tc.lists, tc.listErr, tc.watches)
+			gets := 0
+			dynamicClient.PrependReactor("get", "virtualmachines", func(action k8stesting.Action) (bool, runtime.Object, error) {
+				gets++
+				if tc.get == nil {
+					return true, nil, fmt.Errorf("unexpected get")
+				}
+				return true, tc.get, nil
+			})
+
+			vm, err := c.WaitForVMCondition("default", "test-vm", isRunning, 200*time.Millisecond)
+
+			if tc.expectedError == "" {
+				assert.NilError(t, err)
+				assert.Equal(t, vm.Status.PrintableStatus, kubevirtapiv1.VirtualMachineStatusRunning)
+			} else {
+				assert.Error(t, err, tc.expectedError)
+			}
+			assert.Equal(t, *listed, tc.expectedLists)
+			assert.DeepEqual(t, tc.watches.resourceVersions, tc.expectedResourceVersions)
+			assert.Equal(t, gets, tc.expectedGets)
+		})
+	}
+}
+
+func TestWaitForDeletion(t *testing.T) {
+	watches := &fakeWatches{watches: []*watch.FakeWatcher{
+		newFakeWatch(true, watch.Event{Type: watch.Deleted, Object: unstructuredVM("11", kubevirtapiv1.VirtualMachineStatusTerminating)}),
+	}}
+	c, _, _ := newWaitClient(t, []runtime.Object{vmList("10", unstructuredVM("10", kubevirtapiv1.VirtualMachineStatusTerminating))}, nil, watches)
+
+	assert.NilError(t, c.WaitForDeletion(VirtualMachineResource, "default", "test-vm", time.Second))
+}
+
+func TestWaitForDataVolumePhase(t *testing.T) {
+	dv := func(phase cdiv1.DataVolumePhase) *unstructured.Unstructured {
+		return &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": cdiv1.SchemeGroupVersion.String(),
+			"kind":       "DataVolume",
+			"metadata":   map[string]interface{}{"name": "test-dv", "namespace": "default", "resourceVersion": "10"},
+			"status":     map[string]interface{}{"phase": string(phase)},
+		}}
+	}
+	dvList := &unstructured.UnstructuredList{Object: map[string]interface{}{
+		"apiVersion": cdiv1.SchemeGroupVersion.String(),
+		"kind":       "DataVolumeList",
+	}}
+	dvList.Items = []unstructured.Unstructured{*dv(cdiv1.ImportInProgress)}
+
+	cases := []struct {
+		name          string
+		event         *unstructured.Unstructured
+		expectedError string
+	}{
+		{
+			name:  "succeeded",
+			event: dv(cdiv1.Succeeded),
+		},
+		{
+			name:          "failed",
+			event:         dv(cdiv1.Failed),
+			expectedError: "data volume failed to be created, finished with phase=\"failed\"",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			watches := &fakeWatches{watches: []*watch.FakeWatcher{
+				newFakeWatch(true, watch.Event{Type: watch.Modified, Object: tc.event}),
+			}}
+			c, _, _ := newWaitClient(t, []runtime.Object{dvList.DeepCopy()}, nil, watches)
+
+			_, err := c.WaitForDataVolumePhase("default", "test-dv", cdiv1.Succeeded, time.Second)
+
+			if tc.expectedError == "" {
+				assert.NilError(t, err)
+			} else {
+				assert.Error(t, err, tc.expectedError)
+			}
+		})
+	}
+}
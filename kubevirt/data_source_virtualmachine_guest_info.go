@@ -0,0 +1,74 @@
+package kubevirt
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/nrp-nautilus/terraform-provider-kubevirt/kubevirt/client"
+	"github.com/nrp-nautilus/terraform-provider-kubevirt/kubevirt/schema/virtualmachineinstance"
+	"github.com/nrp-nautilus/terraform-provider-kubevirt/kubevirt/utils"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubevirtapiv1 "kubevirt.io/api/core/v1"
+)
+
+func dataSourceKubevirtVirtualMachineGuestInfo() *schema.Resource {
+	return &schema.Resource{
+		Read: dataSourceKubevirtVirtualMachineGuestInfoRead,
+		Timeouts: &schema.ResourceTimeout{
+			Read: schema.DefaultTimeout(10 * time.Minute),
+		},
+		Schema: virtualmachineinstance.GuestInfoFields(),
+	}
+}
+
+func dataSourceKubevirtVirtualMachineGuestInfoRead(resourceData *schema.ResourceData, meta interface{}) error {
+	cli := (meta).(client.Client)
+
+	objectMeta := metav1.ObjectMeta{
+		Name:      resourceData.Get("name").(string),
+		Namespace: resourceData.Get("namespace").(string),
+	}
+
+	var vmi *kubevirtapiv1.VirtualMachineInstance
+	var err error
+	if interfaceName := resourceData.Get("wait_for_interface").(string); interfaceName != "" {
+		log.Printf("[INFO] Waiting for the guest agent of virtual machine instance %s to report an IP address on %s", objectMeta.Name, interfaceName)
+		vmi, err = cli.WaitForVMICondition(objectMeta.Namespace, objectMeta.Name, func(vmi *kubevirtapiv1.VirtualMachineInstance) (bool, error) {
+			return virtualmachineinstance.GuestAgentIPAddress(vmi, interfaceName) != "", nil
+		}, resourceData.Timeout(schema.TimeoutRead))
+	} else {
+		log.Printf("[INFO] Reading virtual machine instance %s", objectMeta.Name)
+		vmi, err = cli.GetVirtualMachineInstance(objectMeta.Namespace, objectMeta.Name)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read virtual machine instance: %v", err)
+	}
+	log.Printf("[INFO] Received virtual machine instance: %#v", vmi)
+
+	// The guest agent subresources fail unless the agent is connected.
+	var guestInfo *kubevirtapiv1.VirtualMachineInstanceGuestAgentInfo
+	var users []kubevirtapiv1.VirtualMachineInstanceGuestOSUser
+	var fileSystems []kubevirtapiv1.VirtualMachineInstanceFileSystem
+	if virtualmachineinstance.GuestAgentConnected(vmi) {
+		if guestInfo, err = cli.GetVirtualMachineInstanceGuestOSInfo(objectMeta.Namespace, objectMeta.Name); err != nil {
+			return err
+		}
+		userList, err := cli.GetVirtualMachineInstanceUserList(objectMeta.Namespace, objectMeta.Name)
+		if err != nil {
+			return err
+		}
+		users = userList.Items
+		fileSystemList, err := cli.GetVirtualMachineInstanceFileSystemList(objectMeta.Namespace, objectMeta.Name)
+		if err != nil {
+			return err
+		}
+		fileSystems = fileSystemList.Items
+	} else {
+		log.Printf("[INFO] Guest agent of virtual machine instance %s is not connected", objectMeta.Name)
+	}
+
+	resourceData.SetId(utils.BuildId(objectMeta))
+	return virtualmachineinstance.GuestInfoToResourceData(*vmi, guestInfo, users, fileSystems, resourceData)
+}
@@ -0,0 +1,53 @@
+package kubevirt
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/nrp-nautilus/terraform-provider-kubevirt/kubevirt/client"
+	"github.com/nrp-nautilus/terraform-provider-kubevirt/kubevirt/schema/virtualmachineinstance"
+	"github.com/nrp-nautilus/terraform-provider-kubevirt/kubevirt/utils"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func dataSourceKubevirtVirtualMachineInstance() *schema.Resource {
+	fields := utils.DataSourceSchemaFromResourceSchema(virtualmachineinstance.VirtualMachineInstanceFields())
+
+	// The virtual machine instance is looked up by name and namespace.
+	metadata := fields["metadata"]
+	metadata.Computed = false
+	metadata.Required = true
+	metadata.MaxItems = 1
+	metadataFields := metadata.Elem.(*schema.Resource).Schema
+	metadataFields["name"].Computed = false
+	metadataFields["name"].Required = true
+	metadataFields["namespace"].Computed = false
+	metadataFields["namespace"].Optional = true
+	metadataFields["namespace"].Default = "default"
+
+	return &schema.Resource{
+		Read:   dataSourceKubevirtVirtualMachineInstanceRead,
+		Schema: fields,
+	}
+}
+
+func dataSourceKubevirtVirtualMachineInstanceRead(resourceData *schema.ResourceData, meta interface{}) error {
+	cli := (meta).(client.Client)
+
+	objectMeta := metav1.ObjectMeta{
+		Name:      resourceData.Get("metadata.0.name").(string),
+		Namespace: resourceData.Get("metadata.0.namespace").(string),
+	}
+
+	log.Printf("[INFO] Reading virtual machine instance %s", objectMeta.Name)
+
+	vmi, err := cli.GetVirtualMachineInstance(objectMeta.Namespace, objectMeta.Name)
+	if err != nil {
+		return fmt.Errorf("failed to read virtual machine instance: %v", err)
+	}
+	log.Printf("[INFO] Received virtual machine instance: %#v", vmi)
+
+	resourceData.SetId(utils.BuildId(objectMeta))
+	return virtualmachineinstance.ToResourceData(*vmi, resourceData)
+}
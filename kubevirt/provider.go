@@ -100,11 +100,28 @@ func Provider() *schema.Provider {
 				DefaultFunc: schema.EnvDefaultFunc("KUBE_LOAD_CONFIG_FILE", true),
 				Description: "Load local kubeconfig.",
 			},
+			"server_side_apply": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("KUBE_SERVER_SIDE_APPLY", false),
+				Description: "Create and update virtual machines and data volumes using server-side apply with the \"terraform-provider-kubevirt\" field manager, so that fields owned by other controllers are left untouched.",
+			},
+			"force_conflicts": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("KUBE_FORCE_CONFLICTS", false),
+				Description: "Take ownership of fields managed by other field managers when server-side apply reports a conflict, instead of failing.",
+			},
 		},
 		ResourcesMap: map[string]*schema.Resource{
-			"kubevirt_virtual_machine": resourceKubevirtVirtualMachine(),
-			"kubevirt_data_volume":     resourceKubevirtDataVolume(),
-			"kubevirt_kubevirt_vm":     resourceKubevirtKubevirtVM(),
+			"kubevirt_virtual_machine":          resourceKubevirtVirtualMachine(),
+			"kubevirt_virtual_machine_instance": resourceKubevirtVirtualMachineInstance(),
+			"kubevirt_data_volume":              resourceKubevirtDataVolume(),
+			"kubevirt_kubevirt_vm":              resourceKubevirtKubevirtVM(),
+		},
+		DataSourcesMap: map[string]*schema.Resource{
+			"kubevirt_virtual_machine_instance":   dataSourceKubevirtVirtualMachineInstance(),
+			"kubevirt_virtual_machine_guest_info": dataSourceKubevirtVirtualMachineGuestInfo(),
 		},
 	}
 	p.ConfigureFunc = func(resourceData *schema.ResourceData) (interface{}, error) {
@@ -170,7 +187,12 @@ func providerConfigure(resourceData *schema.ResourceData, terraformVersion strin
 		cfg.BearerToken = v.(string)
 	}
 
-	return client.NewClient(cfg)
+	options := client.Options{
+		ServerSideApply: resourceData.Get("server_side_apply").(bool),
+		ForceConflicts:  resourceData.Get("force_conflicts").(bool),
+	}
+
+	return client.NewClient(cfg, options)
 }
 
 func tryLoadingConfigFile(resourceData *schema.ResourceData) (*restclient.Config, error) {
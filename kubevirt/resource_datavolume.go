@@ -5,7 +5,6 @@ import (
 	"log"
 	"time"
 
-	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/nrp-nautilus/terraform-provider-kubevirt/kubevirt/client"
 	"github.com/nrp-nautilus/terraform-provider-kubevirt/kubevirt/schema/datavolume"
@@ -42,7 +41,12 @@ func resourceKubevirtDataVolumeCreate(resourceData *schema.ResourceData, meta in
 	}
 
 	log.Printf("[INFO] Creating new data volume: %#v", dv)
-	if err := cli.CreateDataVolume(dv); err != nil {
+	if cli.ServerSideApply() {
+		err = cli.ApplyDataVolume(dv)
+	} else {
+		err = cli.CreateDataVolume(dv)
+	}
+	if err != nil {
 		return err
 	}
 	log.Printf("[INFO] Submitted new data volume: %#v", dv)
@@ -55,35 +59,9 @@ func resourceKubevirtDataVolumeCreate(resourceData *schema.ResourceData, meta in
 	name := dv.ObjectMeta.Name
 	namespace := dv.ObjectMeta.Namespace
 
-	stateConf := &resource.StateChangeConf{
-		Pending: []string{"Creating"},
-		Target:  []string{"Succeeded"},
-		Timeout: resourceData.Timeout(schema.TimeoutCreate),
-		Refresh: func() (interface{}, string, error) {
-			var err error
-			dv, err = cli.GetDataVolume(namespace, name)
-			if err != nil {
-				if errors.IsNotFound(err) {
-					log.Printf("[DEBUG] data volume %s is not created yet", name)
-					return dv, "Creating", nil
-				}
-				return dv, "", err
-			}
-
-			switch dv.Status.Phase {
-			case cdiv1.Succeeded:
-				return dv, "Succeeded", nil
-			case cdiv1.Failed:
-				return dv, "", fmt.Errorf("data volume failed to be created, finished with phase=\"failed\"")
-			}
-
-			log.Printf("[DEBUG] data volume %s is being created", name)
-			return dv, "Creating", nil
-		},
-	}
-
-	if _, err := stateConf.WaitForState(); err != nil {
-		return fmt.Errorf("%s", err)
+	dv, err = cli.WaitForDataVolumePhase(namespace, name, cdiv1.Succeeded, resourceData.Timeout(schema.TimeoutCreate))
+	if err != nil {
+		return err
 	}
 	return datavolume.ToResourceData(*dv, resourceData)
 }
@@ -116,6 +94,21 @@ func resourceKubevirtDataVolumeUpdate(resourceData *schema.ResourceData, meta in
 		return err
 	}
 
+	if cli.ServerSideApply() {
+		dv, err := datavolume.FromResourceData(resourceData)
+		if err != nil {
+			return err
+		}
+
+		log.Printf("[INFO] Applying data volume: %#v", dv)
+		if err := cli.ApplyDataVolume(dv); err != nil {
+			return err
+		}
+
+		log.Printf("[INFO] Submitted applied data volume: %#v", dv)
+		return resourceKubevirtDataVolumeRead(resourceData, meta)
+	}
+
 	ops := datavolume.AppendPatchOps("", "", resourceData, make([]patch.PatchOperation, 0, 0))
 	data, err := ops.MarshalJSON()
 	if err != nil {
@@ -147,25 +140,8 @@ func resourceKubevirtDataVolumeDelete(resourceData *schema.ResourceData, meta in
 	}
 
 	// Wait for data volume instance to be removed:
-	stateConf := &resource.StateChangeConf{
-		Pending: []string{"Deleting"},
-		Timeout: resourceData.Timeout(schema.TimeoutDelete),
-		Refresh: func() (interface{}, string, error) {
-			dv, err := cli.GetDataVolume(namespace, name)
-			if err != nil {
-				if errors.IsNotFound(err) {
-					return nil, "", nil
-				}
-				return dv, "", err
-			}
-
-			log.Printf("[DEBUG] data volume %s is being deleted", dv.GetName())
-			return dv, "Deleting", nil
-		},
-	}
-
-	if _, err := stateConf.WaitForState(); err != nil {
-		return fmt.Errorf("%s", err)
+	if err := cli.WaitForDeletion(client.DataVolumeResource, namespace, name, resourceData.Timeout(schema.TimeoutDelete)); err != nil {
+		return err
 	}
 
 	log.Printf("[INFO] data volume %s deleted", name)
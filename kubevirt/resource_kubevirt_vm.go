@@ -5,17 +5,39 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
-	"strconv"
+	"reflect"
+	"sort"
 	"strings"
 	"time"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
 	"github.com/nrp-nautilus/terraform-provider-kubevirt/kubevirt/client"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	k8sschema "k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/util/retry"
+	kubevirtapiv1 "kubevirt.io/api/core/v1"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	workspaceTransitionStart  = "start"
+	workspaceTransitionStop   = "stop"
+	workspaceTransitionDelete = "delete"
+
+	// coderAgentTokenPath is where cloud-init writes the Coder agent token, as an environment file.
+	coderAgentTokenPath = "/etc/coder/agent.env"
+
+	// rootDiskName is the name of the disk and volume of the persistent root disk.
+	rootDiskName = "rootdisk"
+
+	// usbDeviceResourcePrefix prefixes the resource names USB devices are permitted under.
+	usbDeviceResourcePrefix = "kubevirt.io/usb-"
 )
 
 func resourceKubevirtKubevirtVM() *schema.Resource {
@@ -33,6 +55,7 @@ func resourceKubevirtKubevirtVM() *schema.Resource {
 			Update: schema.DefaultTimeout(5 * time.Minute),
 			Delete: schema.DefaultTimeout(5 * time.Minute),
 		},
+		CustomizeDiff: validateVMDiskChanges,
 		Schema: map[string]*schema.Schema{
 			"id": {
 				Type:        schema.TypeString,
@@ -52,9 +75,79 @@ func resourceKubevirtKubevirtVM() *schema.Resource {
 				Description: "Kubernetes namespace for the VM",
 			},
 			"image": {
-				Type:        schema.TypeString,
-				Required:    true,
-				Description: "Container image for the VM",
+				Type:         schema.TypeString,
+				Optional:     true,
+				ExactlyOneOf: []string{"image", "root_disk"},
+				Description:  "Container image for the VM, booted as an ephemeral disk. Conflicts with root_disk",
+			},
+			"root_disk": {
+				Type:         schema.TypeList,
+				Optional:     true,
+				MaxItems:     1,
+				ExactlyOneOf: []string{"image", "root_disk"},
+				Description:  "Persistent root disk for the VM, imported into a DataVolume that is kept across stop and start. It can grow in place, but its source, storage class and access mode can't be changed without replacing the VM explicitly. Conflicts with image",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"size": {
+							Type:        schema.TypeString,
+							Required:    true,
+							Description: "Size of the root disk (e.g., '20Gi')",
+						},
+						"storage_class": {
+							Type:        schema.TypeString,
+							Optional:    true,
+							Description: "Storage class of the root disk. Defaults to the cluster default",
+						},
+						"access_mode": {
+							Type:         schema.TypeString,
+							Optional:     true,
+							ValidateFunc: validation.StringInSlice([]string{"ReadWriteOnce", "ReadWriteMany", "ReadOnlyMany"}, false),
+							Description:  "Access mode of the root disk (ReadWriteOnce, ReadWriteMany, ReadOnlyMany). Defaults to the storage profile of the storage class",
+						},
+						"source_url": {
+							Type:         schema.TypeString,
+							Optional:     true,
+							ExactlyOneOf: []string{"root_disk.0.source_url", "root_disk.0.source_registry"},
+							Description:  "HTTP(S) URL of the disk image to import into the root disk. Conflicts with source_registry",
+						},
+						"source_registry": {
+							Type:         schema.TypeString,
+							Optional:     true,
+							ExactlyOneOf: []string{"root_disk.0.source_url", "root_disk.0.source_registry"},
+							Description:  "Registry URL of the container disk to import into the root disk (e.g., 'docker://quay.io/containerdisks/fedora:38'). Conflicts with source_url",
+						},
+					},
+				},
+			},
+			"data_disks": {
+				Type:        schema.TypeList,
+				Optional:    true,
+				Description: "Additional blank persistent disks for the VM, kept across stop and start. Disks can be added, removed and grown in place, but their storage class and access mode can't be changed",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"name": {
+							Type:        schema.TypeString,
+							Required:    true,
+							Description: "Name of the disk",
+						},
+						"size": {
+							Type:        schema.TypeString,
+							Required:    true,
+							Description: "Size of the disk (e.g., '10Gi')",
+						},
+						"storage_class": {
+							Type:        schema.TypeString,
+							Optional:    true,
+							Description: "Storage class of the disk. Defaults to the cluster default",
+						},
+						"access_mode": {
+							Type:         schema.TypeString,
+							Optional:     true,
+							ValidateFunc: validation.StringInSlice([]string{"ReadWriteOnce", "ReadWriteMany", "ReadOnlyMany"}, false),
+							Description:  "Access mode of the disk (ReadWriteOnce, ReadWriteMany, ReadOnlyMany). Defaults to the storage profile of the storage class",
+						},
+					},
+				},
 			},
 			"memory": {
 				Type:        schema.TypeString,
@@ -125,9 +218,11 @@ func resourceKubevirtKubevirtVM() *schema.Resource {
 				},
 			},
 			"affinity": {
-				Type:        schema.TypeString,
-				Optional:    true,
-				Description: "Affinity configuration as JSON string (advanced users)",
+				Type:             schema.TypeString,
+				Optional:         true,
+				ValidateFunc:     validation.StringIsJSON,
+				DiffSuppressFunc: suppressEquivalentJSON,
+				Description:      "Affinity configuration as JSON string (advanced users)",
 			},
 			"host_devices": {
 				Type:        schema.TypeList,
@@ -151,18 +246,18 @@ func resourceKubevirtKubevirtVM() *schema.Resource {
 			"usb_devices": {
 				Type:        schema.TypeList,
 				Optional:    true,
-				Description: "USB devices to attach to the VM",
+				Description: "USB devices to attach to the VM. Each device must be permitted in the KubeVirt CR under permittedHostDevices.usb, with the resource name kubevirt.io/usb-<vendor_id>-<product_id>",
 				Elem: &schema.Resource{
 					Schema: map[string]*schema.Schema{
 						"vendor_id": {
 							Type:        schema.TypeString,
 							Required:    true,
-							Description: "USB vendor ID",
+							Description: "USB vendor ID, in hexadecimal (e.g., '046d')",
 						},
 						"product_id": {
 							Type:        schema.TypeString,
 							Required:    true,
-							Description: "USB product ID",
+							Description: "USB product ID, in hexadecimal (e.g., 'c52b')",
 						},
 					},
 				},
@@ -208,7 +303,7 @@ func resourceKubevirtKubevirtVM() *schema.Resource {
 			"network_interfaces": {
 				Type:        schema.TypeList,
 				Optional:    true,
-				Description: "Network interfaces for the VM",
+				Description: "Secondary network interfaces for the VM, bridged to Multus networks. The VM keeps its pod network interface",
 				Elem: &schema.Resource{
 					Schema: map[string]*schema.Schema{
 						"name": {
@@ -219,7 +314,7 @@ func resourceKubevirtKubevirtVM() *schema.Resource {
 						"network_name": {
 							Type:        schema.TypeString,
 							Required:    true,
-							Description: "Network name to attach to",
+							Description: "Multus NetworkAttachmentDefinition to attach to, as name or namespace/name",
 						},
 					},
 				},
@@ -227,12 +322,13 @@ func resourceKubevirtKubevirtVM() *schema.Resource {
 			"cloud_init": {
 				Type:        schema.TypeString,
 				Optional:    true,
-				Description: "Cloud-init configuration for the VM",
+				Description: "Cloud-init user data for the VM, attached as a NoCloud disk",
 			},
 			"coder_agent_token": {
 				Type:        schema.TypeString,
 				Optional:    true,
-				Description: "Coder agent token for workspace integration",
+				Sensitive:   true,
+				Description: fmt.Sprintf("Coder agent token for workspace integration, written by cloud-init to %s as CODER_AGENT_TOKEN", coderAgentTokenPath),
 			},
 			"vm_status": {
 				Type:        schema.TypeString,
@@ -245,9 +341,11 @@ func resourceKubevirtKubevirtVM() *schema.Resource {
 				Description: "Timestamp when the VM was created",
 			},
 			"workspace_transition": {
-				Type:        schema.TypeString,
-				Computed:    true,
-				Description: "Current workspace transition state",
+				Type:         schema.TypeString,
+				Optional:     true,
+				Computed:     true,
+				ValidateFunc: validation.StringInSlice([]string{workspaceTransitionStart, workspaceTransitionStop, workspaceTransitionDelete}, false),
+				Description:  "Coder workspace transition (start, stop or delete), as passed from data.coder_workspace.transition. Starts or stops the VM, keeping its disks, and reports the transition matching the live VM status. Delete stops the VM like stop",
 			},
 		},
 	}
@@ -286,6 +384,10 @@ func resourceKubevirtKubevirtVMCreate(d *schema.ResourceData, meta interface{})
 	
 	// Set the ID
 	d.SetId(fmt.Sprintf("%s/%s", namespace, name))
+
+	if err := waitForWorkspaceTransition(cli, namespace, name, d.Get("workspace_transition").(string), d.Timeout(schema.TimeoutCreate)); err != nil {
+		return err
+	}
 	
 	log.Printf("[INFO] Successfully created KubeVirt VM: %s", name)
 	
@@ -353,21 +455,57 @@ func resourceKubevirtKubevirtVMUpdate(d *schema.ResourceData, meta interface{})
 		Resource: "virtualmachines",
 	})
 	
-	_, err = vmResource.Namespace(namespace).Get(context.Background(), name, metav1.GetOptions{})
-	if err != nil {
-		return fmt.Errorf("failed to get current VM: %v", err)
+	// A transition only changes the run strategy, ordered so that the VM never runs with an
+	// outdated spec: stopping comes before the rest of the update, starting after it.
+	transition := d.Get("workspace_transition").(string)
+	transitionChanged := d.HasChange("workspace_transition")
+	timeout := d.Timeout(schema.TimeoutUpdate)
+	if transitionChanged && transition != workspaceTransitionStart {
+		if err := applyWorkspaceTransition(cli, vmResource, namespace, name, transition, timeout); err != nil {
+			return err
+		}
 	}
-	
-	// Create updated VM object
-	updatedVM, err := createVMObject(d)
-	if err != nil {
-		return fmt.Errorf("failed to create updated VM object: %v", err)
+
+	if d.HasChangesExcept("workspace_transition") {
+		// Create updated VM object
+		desiredVM, err := createVMObject(d)
+		if err != nil {
+			return fmt.Errorf("failed to create updated VM object: %v", err)
+		}
+
+		// Update the VM, merging the desired fields into the live object so that the ones
+		// KubeVirt and other clients set are kept, and retrying when it changed meanwhile
+		err = retry.RetryOnConflict(retry.DefaultRetry, func() error {
+			liveVM, err := vmResource.Namespace(namespace).Get(context.Background(), name, metav1.GetOptions{})
+			if err != nil {
+				return err
+			}
+			updatedVM, err := mergeVMObject(liveVM, desiredVM, vmRenderedDiskNames(d))
+			if err != nil {
+				return err
+			}
+			if transitionChanged {
+				keepVMRunStrategy(liveVM, updatedVM)
+			}
+			_, err = vmResource.Namespace(namespace).Update(context.Background(), updatedVM, metav1.UpdateOptions{})
+			if errors.IsConflict(err) {
+				log.Printf("[DEBUG] KubeVirt VM %s changed during update, retrying", name)
+			}
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("failed to update VM: %v", err)
+		}
+
+		if err := expandVMDiskVolumes(dynamicClient, namespace, name, d); err != nil {
+			return err
+		}
 	}
-	
-	// Update the VM
-	_, err = vmResource.Namespace(namespace).Update(context.Background(), updatedVM, metav1.UpdateOptions{})
-	if err != nil {
-		return fmt.Errorf("failed to update VM: %v", err)
+
+	if transitionChanged && transition == workspaceTransitionStart {
+		if err := applyWorkspaceTransition(cli, vmResource, namespace, name, transition, timeout); err != nil {
+			return err
+		}
 	}
 	
 	log.Printf("[INFO] Successfully updated KubeVirt VM: %s", name)
@@ -404,6 +542,17 @@ func resourceKubevirtKubevirtVMDelete(d *schema.ResourceData, meta interface{})
 		}
 		return fmt.Errorf("failed to delete VM: %v", err)
 	}
+
+	// A replacement reuses the names of the VM and its DataVolumes, so they have to be gone first.
+	timeout := d.Timeout(schema.TimeoutDelete)
+	if err := cli.WaitForDeletion(client.VirtualMachineResource, namespace, name, timeout); err != nil {
+		return err
+	}
+	for _, diskName := range vmPersistentDiskNames(d) {
+		if err := cli.WaitForDeletion(client.DataVolumeResource, namespace, vmDataVolumeName(name, diskName), timeout); err != nil {
+			return err
+		}
+	}
 	
 	log.Printf("[INFO] Successfully deleted KubeVirt VM: %s", name)
 	
@@ -454,9 +603,15 @@ func createVMObject(d *schema.ResourceData) (*unstructured.Unstructured, error)
 		"managed-by": "terraform",
 	})
 	
+	// Boot from the persistent root disk if specified, otherwise from the ephemeral container disk
+	disks, volumes, dataVolumeTemplates, err := vmDisks(d)
+	if err != nil {
+		return nil, err
+	}
+
 	// Create spec
 	spec := map[string]interface{}{
-		"running": false,
+		"runStrategy": workspaceRunStrategy(d.Get("workspace_transition").(string)),
 		"template": map[string]interface{}{
 			"metadata": map[string]interface{}{
 				"labels": map[string]interface{}{
@@ -466,12 +621,7 @@ func createVMObject(d *schema.ResourceData) (*unstructured.Unstructured, error)
 			"spec": map[string]interface{}{
 				"domain": map[string]interface{}{
 					"devices": map[string]interface{}{
-						"disks": []map[string]interface{}{
-							{
-								"name": "containerdisk",
-								"disk": map[string]interface{}{},
-							},
-						},
+						"disks": disks,
 					},
 					"resources": map[string]interface{}{
 						"requests": map[string]interface{}{
@@ -480,18 +630,23 @@ func createVMObject(d *schema.ResourceData) (*unstructured.Unstructured, error)
 						},
 					},
 				},
-				"volumes": []map[string]interface{}{
-					{
-						"name": "containerdisk",
-						"containerDisk": map[string]interface{}{
-							"image": d.Get("image").(string),
-						},
-					},
-				},
+				"volumes": volumes,
 			},
 		},
 	}
+	if len(dataVolumeTemplates) > 0 {
+		spec["dataVolumeTemplates"] = dataVolumeTemplates
+	}
 	
+	// Back the guest memory with hugepages if specified
+	if hugepages, ok := d.GetOk("hugepages"); ok && hugepages.(string) != "" {
+		spec["template"].(map[string]interface{})["spec"].(map[string]interface{})["domain"].(map[string]interface{})["memory"] = map[string]interface{}{
+			"hugepages": map[string]interface{}{
+				"pageSize": hugepages.(string),
+			},
+		}
+	}
+
 	// Add sidecar hook if specified
 	if sidecarHook, ok := d.GetOk("sidecar_hook"); ok && sidecarHook.(string) != "" {
 		annotations := map[string]string{
@@ -545,6 +700,19 @@ func createVMObject(d *schema.ResourceData) (*unstructured.Unstructured, error)
 		}
 	}
 	
+	// Add host devices if specified
+	if hostDevices, ok := d.GetOk("host_devices"); ok && len(hostDevices.([]interface{})) > 0 {
+		var hostObjects []map[string]interface{}
+		for _, host := range hostDevices.([]interface{}) {
+			hostMap := host.(map[string]interface{})
+			hostObjects = append(hostObjects, map[string]interface{}{
+				"name":       hostMap["name"].(string),
+				"deviceName": hostMap["device_name"].(string),
+			})
+		}
+		spec["template"].(map[string]interface{})["spec"].(map[string]interface{})["domain"].(map[string]interface{})["devices"].(map[string]interface{})["hostDevices"] = hostObjects
+	}
+
 	// Add PCI devices if specified
 	if pciDevices, ok := d.GetOk("pci_devices"); ok && len(pciDevices.([]interface{})) > 0 {
 		var pciObjects []map[string]interface{}
@@ -558,7 +726,9 @@ func createVMObject(d *schema.ResourceData) (*unstructured.Unstructured, error)
 		}
 		
 		if len(pciObjects) > 0 {
-			spec["template"].(map[string]interface{})["spec"].(map[string]interface{})["domain"].(map[string]interface{})["devices"].(map[string]interface{})["hostDevices"] = pciObjects
+			// Add to host devices if any
+			existingList, _ := spec["template"].(map[string]interface{})["spec"].(map[string]interface{})["domain"].(map[string]interface{})["devices"].(map[string]interface{})["hostDevices"].([]map[string]interface{})
+			spec["template"].(map[string]interface{})["spec"].(map[string]interface{})["domain"].(map[string]interface{})["devices"].(map[string]interface{})["hostDevices"] = append(existingList, pciObjects...)
 		}
 	}
 	
@@ -587,11 +757,589 @@ func createVMObject(d *schema.ResourceData) (*unstructured.Unstructured, error)
 		}
 	}
 	
+	templateSpec := spec["template"].(map[string]interface{})["spec"].(map[string]interface{})
+	domain := templateSpec["domain"].(map[string]interface{})
+	devices := domain["devices"].(map[string]interface{})
+
+	// Add USB devices if specified, as host devices the KubeVirt CR permits by vendor and product
+	if usbDevices, ok := d.GetOk("usb_devices"); ok && len(usbDevices.([]interface{})) > 0 {
+		hostDevices, _ := devices["hostDevices"].([]map[string]interface{})
+		for _, usb := range usbDevices.([]interface{}) {
+			usbMap := usb.(map[string]interface{})
+			vendorID := strings.ToLower(usbMap["vendor_id"].(string))
+			productID := strings.ToLower(usbMap["product_id"].(string))
+			hostDevices = append(hostDevices, map[string]interface{}{
+				"name":       fmt.Sprintf("usb-%s-%s", vendorID, productID),
+				"deviceName": usbDeviceResourceName(vendorID, productID),
+			})
+		}
+		devices["hostDevices"] = hostDevices
+	}
+
+	// Add network interfaces if specified, as Multus networks next to the pod network
+	if networkInterfaces, ok := d.GetOk("network_interfaces"); ok && len(networkInterfaces.([]interface{})) > 0 {
+		// The pod network is only attached implicitly when no interface is listed.
+		interfaces := []map[string]interface{}{
+			{
+				"name":       "default",
+				"masquerade": map[string]interface{}{},
+			},
+		}
+		networks := []map[string]interface{}{
+			{
+				"name": "default",
+				"pod":  map[string]interface{}{},
+			},
+		}
+		for _, iface := range networkInterfaces.([]interface{}) {
+			ifaceMap := iface.(map[string]interface{})
+			interfaces = append(interfaces, map[string]interface{}{
+				"name":   ifaceMap["name"].(string),
+				"bridge": map[string]interface{}{},
+			})
+			networks = append(networks, map[string]interface{}{
+				"name": ifaceMap["name"].(string),
+				"multus": map[string]interface{}{
+					"networkName": ifaceMap["network_name"].(string),
+				},
+			})
+		}
+		devices["interfaces"] = interfaces
+		templateSpec["networks"] = networks
+	}
+
+	// Add cloud-init if specified, as a NoCloud volume
+	userData, err := cloudInitUserData(d.Get("cloud_init").(string), d.Get("coder_agent_token").(string))
+	if err != nil {
+		return nil, err
+	}
+	if userData != "" {
+		devices["disks"] = append(devices["disks"].([]map[string]interface{}), map[string]interface{}{
+			"name": "cloudinitdisk",
+			"disk": map[string]interface{}{
+				"bus": "virtio",
+			},
+		})
+		templateSpec["volumes"] = append(templateSpec["volumes"].([]map[string]interface{}), map[string]interface{}{
+			"name": "cloudinitdisk",
+			"cloudInitNoCloud": map[string]interface{}{
+				"userData": userData,
+			},
+		})
+	}
+
+	// Set machine type and architecture
+	if machineType, ok := d.GetOk("machine_type"); ok && machineType.(string) != "" {
+		domain["machine"] = map[string]interface{}{
+			"type": machineType.(string),
+		}
+	}
+	if architecture, ok := d.GetOk("architecture"); ok && architecture.(string) != "" {
+		templateSpec["architecture"] = architecture.(string)
+	}
+
 	vm.Object["spec"] = spec
 	
 	return vm, nil
 }
 
+// vmDisks returns the disks and volumes of the VM, with the DataVolume templates of the persistent ones.
+// The root disk and data disks are imported into DataVolumes named after the VM, which KubeVirt
+// only creates once and keeps while the VM is stopped.
+func vmDisks(d *schema.ResourceData) ([]map[string]interface{}, []map[string]interface{}, []map[string]interface{}, error) {
+	var disks, volumes, dataVolumeTemplates []map[string]interface{}
+	name := d.Get("name").(string)
+
+	if rootDisks := d.Get("root_disk").([]interface{}); len(rootDisks) > 0 && rootDisks[0] != nil {
+		rootDisk := rootDisks[0].(map[string]interface{})
+		sourceURL := rootDisk["source_url"].(string)
+		sourceRegistry := rootDisk["source_registry"].(string)
+
+		// The schema ensures exactly one of the sources is set.
+		source := map[string]interface{}{"http": map[string]interface{}{"url": sourceURL}}
+		if sourceRegistry != "" {
+			source = map[string]interface{}{"registry": map[string]interface{}{"url": sourceRegistry}}
+		}
+
+		disks = append(disks, map[string]interface{}{
+			"name": rootDiskName,
+			"disk": map[string]interface{}{},
+		})
+		volumes = append(volumes, map[string]interface{}{
+			"name": rootDiskName,
+			"dataVolume": map[string]interface{}{
+				"name": vmDataVolumeName(name, rootDiskName),
+			},
+		})
+		dataVolumeTemplates = append(dataVolumeTemplates, vmDataVolumeTemplate(vmDataVolumeName(name, rootDiskName), source, rootDisk))
+	} else {
+		disks = append(disks, map[string]interface{}{
+			"name": "containerdisk",
+			"disk": map[string]interface{}{},
+		})
+		volumes = append(volumes, map[string]interface{}{
+			"name": "containerdisk",
+			"containerDisk": map[string]interface{}{
+				"image": d.Get("image").(string),
+			},
+		})
+	}
+
+	for _, dataDisk := range d.Get("data_disks").([]interface{}) {
+		dataDiskMap := dataDisk.(map[string]interface{})
+		diskName := dataDiskMap["name"].(string)
+		if diskName == rootDiskName || diskName == "containerdisk" || diskName == "cloudinitdisk" {
+			return nil, nil, nil, fmt.Errorf("data disk name %q is reserved", diskName)
+		}
+
+		disks = append(disks, map[string]interface{}{
+			"name": diskName,
+			"disk": map[string]interface{}{
+				"bus": "virtio",
+			},
+		})
+		volumes = append(volumes, map[string]interface{}{
+			"name": diskName,
+			"dataVolume": map[string]interface{}{
+				"name": vmDataVolumeName(name, diskName),
+			},
+		})
+		dataVolumeTemplates = append(dataVolumeTemplates, vmDataVolumeTemplate(vmDataVolumeName(name, diskName), map[string]interface{}{"blank": map[string]interface{}{}}, dataDiskMap))
+	}
+
+	return disks, volumes, dataVolumeTemplates, nil
+}
+
+// vmDataVolumeName returns the name of the DataVolume backing a persistent disk of the VM.
+func vmDataVolumeName(vmName, diskName string) string {
+	return fmt.Sprintf("%s-%s", vmName, diskName)
+}
+
+// vmDataVolumeTemplate returns the template of a DataVolume imported from source, sized
+// and placed as the root_disk or data_disks block describes.
+func vmDataVolumeTemplate(name string, source map[string]interface{}, disk map[string]interface{}) map[string]interface{} {
+	storage := map[string]interface{}{
+		"resources": map[string]interface{}{
+			"requests": map[string]interface{}{
+				"storage": disk["size"].(string),
+			},
+		},
+	}
+	if storageClass := disk["storage_class"].(string); storageClass != "" {
+		storage["storageClassName"] = storageClass
+	}
+	if accessMode := disk["access_mode"].(string); accessMode != "" {
+		storage["accessModes"] = []string{accessMode}
+	}
+
+	return map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"name": name,
+		},
+		"spec": map[string]interface{}{
+			"source":  source,
+			"storage": storage,
+		},
+	}
+}
+
+// vmPersistentDisks returns the root disk and data disks blocks by disk name.
+func vmPersistentDisks(rootDisks, dataDisks []interface{}) map[string]map[string]interface{} {
+	disks := make(map[string]map[string]interface{})
+	if len(rootDisks) > 0 && rootDisks[0] != nil {
+		disks[rootDiskName] = rootDisks[0].(map[string]interface{})
+	}
+	for _, dataDisk := range dataDisks {
+		if dataDiskMap, ok := dataDisk.(map[string]interface{}); ok {
+			disks[dataDiskMap["name"].(string)] = dataDiskMap
+		}
+	}
+	return disks
+}
+
+// vmPersistentDiskNames returns the names of the disks of the VM backed by DataVolumes.
+func vmPersistentDiskNames(d *schema.ResourceData) []string {
+	var names []string
+	for diskName := range vmPersistentDisks(d.Get("root_disk").([]interface{}), d.Get("data_disks").([]interface{})) {
+		names = append(names, diskName)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// validateVMDiskChanges rejects the changes to persistent disks that can't be made in place.
+// The DataVolumes are owned by the VM, so replacing the VM to make them would delete every disk.
+func validateVMDiskChanges(ctx context.Context, diff *schema.ResourceDiff, meta interface{}) error {
+	if diff.Id() == "" || !diff.HasChanges("root_disk", "data_disks") {
+		return nil
+	}
+
+	oldRootDisks, newRootDisks := diff.GetChange("root_disk")
+	oldDataDisks, newDataDisks := diff.GetChange("data_disks")
+	oldDisks := vmPersistentDisks(oldRootDisks.([]interface{}), oldDataDisks.([]interface{}))
+	newDisks := vmPersistentDisks(newRootDisks.([]interface{}), newDataDisks.([]interface{}))
+
+	for diskName, newDisk := range newDisks {
+		oldDisk, ok := oldDisks[diskName]
+		if !ok {
+			continue
+		}
+		for _, key := range []string{"source_url", "source_registry", "storage_class", "access_mode"} {
+			if oldDisk[key] != newDisk[key] {
+				return fmt.Errorf("%s of disk %q can't be changed in place, as the VM owns its DataVolume: replace the VM explicitly to recreate its disks", key, diskName)
+			}
+		}
+
+		oldSize, newSize := oldDisk["size"].(string), newDisk["size"].(string)
+		// Sizes aren't known yet when they're computed from other resources.
+		if oldSize == "" || newSize == "" {
+			continue
+		}
+		oldQuantity, err := resource.ParseQuantity(oldSize)
+		if err != nil {
+			return fmt.Errorf("invalid size %q of disk %q: %v", oldSize, diskName, err)
+		}
+		newQuantity, err := resource.ParseQuantity(newSize)
+		if err != nil {
+			return fmt.Errorf("invalid size %q of disk %q: %v", newSize, diskName, err)
+		}
+		if newQuantity.Cmp(oldQuantity) < 0 {
+			return fmt.Errorf("disk %q can't shrink from %s to %s", diskName, oldSize, newSize)
+		}
+	}
+	return nil
+}
+
+// expandVMDiskVolumes grows the PVCs of the persistent disks whose size increased. KubeVirt only
+// creates the DataVolumes from their templates, so resizing a template leaves an existing disk as is.
+func expandVMDiskVolumes(dynamicClient dynamic.Interface, namespace, name string, d *schema.ResourceData) error {
+	oldRootDisks, newRootDisks := d.GetChange("root_disk")
+	oldDataDisks, newDataDisks := d.GetChange("data_disks")
+	oldDisks := vmPersistentDisks(oldRootDisks.([]interface{}), oldDataDisks.([]interface{}))
+	newDisks := vmPersistentDisks(newRootDisks.([]interface{}), newDataDisks.([]interface{}))
+
+	pvcResource := dynamicClient.Resource(k8sschema.GroupVersionResource{
+		Version:  "v1",
+		Resource: "persistentvolumeclaims",
+	})
+	for diskName, newDisk := range newDisks {
+		oldDisk, ok := oldDisks[diskName]
+		if !ok || oldDisk["size"] == newDisk["size"] {
+			continue
+		}
+		oldQuantity, err := resource.ParseQuantity(oldDisk["size"].(string))
+		if err != nil {
+			return fmt.Errorf("invalid size of disk %q: %v", diskName, err)
+		}
+		newQuantity, err := resource.ParseQuantity(newDisk["size"].(string))
+		if err != nil {
+			return fmt.Errorf("invalid size of disk %q: %v", diskName, err)
+		}
+		if newQuantity.Cmp(oldQuantity) <= 0 {
+			continue
+		}
+
+		patch, err := json.Marshal(map[string]interface{}{
+			"spec": map[string]interface{}{
+				"resources": map[string]interface{}{
+					"requests": map[string]interface{}{
+						"storage": newDisk["size"],
+					},
+				},
+			},
+		})
+		if err != nil {
+			return fmt.Errorf("failed to marshal PVC expansion: %v", err)
+		}
+
+		pvcName := vmDataVolumeName(name, diskName)
+		log.Printf("[INFO] Expanding PVC %s of KubeVirt VM %s to %s", pvcName, name, newDisk["size"])
+		if _, err := pvcResource.Namespace(namespace).Patch(context.Background(), pvcName, types.MergePatchType, patch, metav1.PatchOptions{}); err != nil {
+			// The DataVolume isn't created yet, and will be from the updated template.
+			if errors.IsNotFound(err) {
+				log.Printf("[DEBUG] PVC %s not found, leaving its size to the DataVolume template", pvcName)
+				continue
+			}
+			return fmt.Errorf("failed to expand disk %q: %v", diskName, err)
+		}
+	}
+	return nil
+}
+
+// applyWorkspaceTransition starts or stops the VM on its own, so that a transition never
+// rewrites the rest of the VM, and waits for the transition to complete.
+func applyWorkspaceTransition(cli client.Client, vmResource dynamic.NamespaceableResourceInterface, namespace, name, transition string, timeout time.Duration) error {
+	patch, err := json.Marshal(map[string]interface{}{
+		"spec": map[string]interface{}{
+			// VMs created before run strategies were used set running, which excludes runStrategy.
+			"running":     nil,
+			"runStrategy": workspaceRunStrategy(transition),
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal workspace transition: %v", err)
+	}
+
+	log.Printf("[INFO] Applying workspace transition %s to KubeVirt VM: %s", transition, name)
+	if _, err := vmResource.Namespace(namespace).Patch(context.Background(), name, types.MergePatchType, patch, metav1.PatchOptions{}); err != nil {
+		return fmt.Errorf("failed to apply workspace transition: %v", err)
+	}
+	return waitForWorkspaceTransition(cli, namespace, name, transition, timeout)
+}
+
+// keepVMRunStrategy sets the run strategy of the updated VM back to the one of the live VM,
+// leaving it to applyWorkspaceTransition.
+func keepVMRunStrategy(live, updated *unstructured.Unstructured) {
+	for _, field := range [][]string{{"spec", "running"}, {"spec", "runStrategy"}} {
+		value, found, _ := unstructured.NestedFieldCopy(live.Object, field...)
+		if !found {
+			unstructured.RemoveNestedField(updated.Object, field...)
+			continue
+		}
+		_ = unstructured.SetNestedField(updated.Object, value, field...)
+	}
+}
+
+// workspaceRunStrategy returns the run strategy of the VM for a Coder workspace transition.
+// Stopping and deleting halt the VM rather than deleting it, so that its disks are kept.
+func workspaceRunStrategy(transition string) string {
+	if transition == workspaceTransitionStart {
+		return string(kubevirtapiv1.RunStrategyAlways)
+	}
+	return string(kubevirtapiv1.RunStrategyHalted)
+}
+
+// workspaceTransitionFromVM returns the Coder workspace transition the live VM is in, from its
+// printable status, or from its run strategy until KubeVirt reported a status.
+func workspaceTransitionFromVM(vm *unstructured.Unstructured) string {
+	printableStatus, _, _ := unstructured.NestedString(vm.Object, "status", "printableStatus")
+	switch kubevirtapiv1.VirtualMachinePrintableStatus(printableStatus) {
+	case "":
+		runStrategy, _, _ := unstructured.NestedString(vm.Object, "spec", "runStrategy")
+		if runStrategy == string(kubevirtapiv1.RunStrategyHalted) {
+			return workspaceTransitionStop
+		}
+		if running, ok, _ := unstructured.NestedBool(vm.Object, "spec", "running"); ok && !running {
+			return workspaceTransitionStop
+		}
+		return workspaceTransitionStart
+	case kubevirtapiv1.VirtualMachineStatusStopped, kubevirtapiv1.VirtualMachineStatusStopping:
+		return workspaceTransitionStop
+	default:
+		return workspaceTransitionStart
+	}
+}
+
+// waitForWorkspaceTransition waits for the VM to be running after a start transition,
+// or stopped after a stop or delete transition.
+func waitForWorkspaceTransition(cli client.Client, namespace, name, transition string, timeout time.Duration) error {
+	var target kubevirtapiv1.VirtualMachinePrintableStatus
+	switch transition {
+	case workspaceTransitionStart:
+		target = kubevirtapiv1.VirtualMachineStatusRunning
+	case workspaceTransitionStop, workspaceTransitionDelete:
+		target = kubevirtapiv1.VirtualMachineStatusStopped
+	default:
+		return nil
+	}
+
+	_, err := cli.WaitForVMCondition(namespace, name, func(vm *kubevirtapiv1.VirtualMachine) (bool, error) {
+		log.Printf("[DEBUG] KubeVirt VM %s is %s, waiting for %s", name, vm.Status.PrintableStatus, target)
+		return vm.Status.PrintableStatus == target, nil
+	}, timeout)
+	if err != nil {
+		return fmt.Errorf("failed to wait for workspace transition %s: %v", transition, err)
+	}
+	return nil
+}
+
+// cloudInitUserData returns the cloud-init user data of the VM, with the Coder agent token
+// written to an environment file when there is one. The token can only be added to a
+// #cloud-config document, as other kinds of user data can't be merged with.
+func cloudInitUserData(cloudInit, coderAgentToken string) (string, error) {
+	if coderAgentToken == "" {
+		return cloudInit, nil
+	}
+
+	config := make(map[string]interface{})
+	if cloudInit != "" {
+		if !strings.HasPrefix(cloudInit, "#cloud-config") {
+			return "", fmt.Errorf("coder_agent_token requires cloud_init to be a #cloud-config document")
+		}
+		if err := yaml.Unmarshal([]byte(cloudInit), &config); err != nil {
+			return "", fmt.Errorf("failed to parse cloud_init: %v", err)
+		}
+	}
+
+	writeFiles, _ := config["write_files"].([]interface{})
+	config["write_files"] = append(writeFiles, map[string]interface{}{
+		"path":        coderAgentTokenPath,
+		"permissions": "0600",
+		"content":     fmt.Sprintf("CODER_AGENT_TOKEN=%s\n", coderAgentToken),
+	})
+
+	data, err := yaml.Marshal(config)
+	if err != nil {
+		return "", fmt.Errorf("failed to render cloud_init: %v", err)
+	}
+	return "#cloud-config\n" + string(data), nil
+}
+
+// usbDeviceResourceName returns the resource name a USB device is expected to be permitted
+// under in the KubeVirt CR, in permittedHostDevices.usb.
+func usbDeviceResourceName(vendorID, productID string) string {
+	return fmt.Sprintf("%s%s-%s", usbDeviceResourcePrefix, vendorID, productID)
+}
+
+// parseUSBDeviceResourceName returns the vendor and product of a USB device resource name.
+func parseUSBDeviceResourceName(deviceName string) (string, string, bool) {
+	if !strings.HasPrefix(deviceName, usbDeviceResourcePrefix) {
+		return "", "", false
+	}
+	parts := strings.Split(strings.TrimPrefix(deviceName, usbDeviceResourcePrefix), "-")
+	if len(parts) != 2 {
+		return "", "", false
+	}
+	return parts[0], parts[1], true
+}
+
+// managedVMFields lists the fields of the VM that createVMObject renders. Updates only
+// touch these, leaving the others as KubeVirt and other clients set them.
+var managedVMFields = [][]string{
+	{"metadata", "labels", "app"},
+	{"metadata", "labels", "managed-by"},
+	{"spec", "running"},
+	{"spec", "runStrategy"},
+	{"spec", "dataVolumeTemplates"},
+	{"spec", "template", "metadata", "labels", "kubevirt.io/vm"},
+	{"spec", "template", "metadata", "annotations", "hooks.kubevirt.io/hookSidecars"},
+	{"spec", "template", "spec", "architecture"},
+	{"spec", "template", "spec", "domain", "devices", "hostDevices"},
+	{"spec", "template", "spec", "domain", "devices", "interfaces"},
+	{"spec", "template", "spec", "domain", "machine", "type"},
+	{"spec", "template", "spec", "domain", "memory", "hugepages"},
+	{"spec", "template", "spec", "domain", "resources", "requests", "cpu"},
+	{"spec", "template", "spec", "domain", "resources", "requests", "memory"},
+	{"spec", "template", "spec", "affinity"},
+	{"spec", "template", "spec", "networks"},
+	{"spec", "template", "spec", "nodeSelector"},
+	{"spec", "template", "spec", "tolerations"},
+}
+
+// managedVMLists lists the lists of the VM that createVMObject renders entries of. Updates
+// merge them by name, leaving the entries added by others, such as hotplugged volumes.
+var managedVMLists = [][]string{
+	{"spec", "template", "spec", "domain", "devices", "disks"},
+	{"spec", "template", "spec", "volumes"},
+}
+
+// vmRenderedDiskNames returns the names of the disks and volumes createVMObject renders for
+// the previous or the current configuration.
+func vmRenderedDiskNames(d *schema.ResourceData) map[string]bool {
+	names := map[string]bool{
+		rootDiskName:    true,
+		"containerdisk": true,
+		"cloudinitdisk": true,
+	}
+	oldDataDisks, newDataDisks := d.GetChange("data_disks")
+	for _, dataDisks := range [][]interface{}{oldDataDisks.([]interface{}), newDataDisks.([]interface{})} {
+		for _, dataDisk := range dataDisks {
+			if dataDiskMap, ok := dataDisk.(map[string]interface{}); ok {
+				names[dataDiskMap["name"].(string)] = true
+			}
+		}
+	}
+	return names
+}
+
+// mergeVMObject returns a copy of the live VM with the managed fields of the desired VM,
+// removing the managed fields the desired VM leaves out. The entries of the managed lists
+// are merged by name: the rendered ones follow the desired VM, and the others are kept. The
+// copy keeps the resource version of the live VM, so that the update fails if the VM
+// changed since it was read.
+func mergeVMObject(live, desired *unstructured.Unstructured, rendered map[string]bool) (*unstructured.Unstructured, error) {
+	// The desired VM is made of typed Go values, which the unstructured helpers can't copy.
+	data, err := json.Marshal(desired.Object)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal VM object: %v", err)
+	}
+	desiredObj := make(map[string]interface{})
+	if err := json.Unmarshal(data, &desiredObj); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal VM object: %v", err)
+	}
+
+	merged := live.DeepCopy()
+	for _, field := range managedVMFields {
+		value, found, err := unstructured.NestedFieldCopy(desiredObj, field...)
+		if err != nil {
+			return nil, err
+		}
+		if !found {
+			unstructured.RemoveNestedField(merged.Object, field...)
+			continue
+		}
+		if err := unstructured.SetNestedField(merged.Object, value, field...); err != nil {
+			return nil, err
+		}
+	}
+	for _, field := range managedVMLists {
+		desiredItems, _, err := unstructured.NestedSlice(desiredObj, field...)
+		if err != nil {
+			return nil, err
+		}
+		liveItems, _, err := unstructured.NestedSlice(merged.Object, field...)
+		if err != nil {
+			return nil, err
+		}
+		items := mergeNamedItems(liveItems, desiredItems, rendered)
+		if len(items) == 0 {
+			unstructured.RemoveNestedField(merged.Object, field...)
+			continue
+		}
+		if err := unstructured.SetNestedSlice(merged.Object, items, field...); err != nil {
+			return nil, err
+		}
+	}
+	return merged, nil
+}
+
+// mergeNamedItems merges the desired items into the live ones by name. Live items that are
+// neither desired nor rendered are kept in place, the rendered ones are replaced by the
+// desired item of the same name or dropped, and the new desired items are appended.
+func mergeNamedItems(liveItems, desiredItems []interface{}, rendered map[string]bool) []interface{} {
+	desiredByName := make(map[string]interface{}, len(desiredItems))
+	for _, item := range desiredItems {
+		desiredByName[itemName(item)] = item
+	}
+
+	items := make([]interface{}, 0, len(liveItems)+len(desiredItems))
+	merged := make(map[string]bool, len(desiredItems))
+	for _, item := range liveItems {
+		name := itemName(item)
+		if desiredItem, ok := desiredByName[name]; ok {
+			items = append(items, desiredItem)
+			merged[name] = true
+			continue
+		}
+		if !rendered[name] {
+			items = append(items, item)
+		}
+	}
+	for _, item := range desiredItems {
+		if !merged[itemName(item)] {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// itemName returns the name of an item of an unstructured list.
+func itemName(item interface{}) string {
+	itemMap, _ := item.(map[string]interface{})
+	name, _ := itemMap["name"].(string)
+	return name
+}
+
 func updateResourceDataFromVM(vm *unstructured.Unstructured, d *schema.ResourceData) error {
 	// Extract basic fields
 	if err := d.Set("name", vm.GetName()); err != nil {
@@ -603,62 +1351,311 @@ func updateResourceDataFromVM(vm *unstructured.Unstructured, d *schema.ResourceD
 	if err := d.Set("creation_timestamp", vm.GetCreationTimestamp().String()); err != nil {
 		return err
 	}
-	
-	// Extract spec fields
-	if spec, ok := vm.Object["spec"].(map[string]interface{}); ok {
-		if template, ok := spec["template"].(map[string]interface{}); ok {
-			if templateSpec, ok := template["spec"].(map[string]interface{}); ok {
-				if domain, ok := templateSpec["domain"].(map[string]interface{}); ok {
-					if resources, ok := domain["resources"].(map[string]interface{}); ok {
-						if requests, ok := resources["requests"].(map[string]interface{}); ok {
-							if memory, ok := requests["memory"].(string); ok {
-								if err := d.Set("memory", memory); err != nil {
-									return err
-								}
-							}
-							if cpu, ok := requests["cpu"].(string); ok && cpu != "" {
-								if cpuInt, err := strconv.Atoi(cpu); err == nil {
-									if err := d.Set("cpu", cpuInt); err != nil {
-										return err
-									}
-								} else {
-									log.Printf("[WARN] Failed to convert CPU value '%s' to int: %v", cpu, err)
-								}
-							} else {
-								log.Printf("[DEBUG] CPU value not found or empty in requests")
-							}
-						}
-					}
-				}
-			}
+	transition := workspaceTransitionFromVM(vm)
+	// A stopped VM matches a delete transition as well.
+	if transition == workspaceTransitionStop && d.Get("workspace_transition").(string) == workspaceTransitionDelete {
+		transition = workspaceTransitionDelete
+	}
+	if err := d.Set("workspace_transition", transition); err != nil {
+		return err
+	}
+	printableStatus, _, _ := unstructured.NestedString(vm.Object, "status", "printableStatus")
+	if err := d.Set("vm_status", printableStatus); err != nil {
+		return err
+	}
+
+	// Extract template fields
+	templateSpec, _, _ := unstructured.NestedMap(vm.Object, "spec", "template", "spec")
+	domain, _, _ := unstructured.NestedMap(templateSpec, "domain")
+
+	memory, _, _ := unstructured.NestedString(domain, "resources", "requests", "memory")
+	if err := d.Set("memory", memory); err != nil {
+		return err
+	}
+	if cpu, ok, _ := unstructured.NestedFieldNoCopy(domain, "resources", "requests", "cpu"); ok {
+		cores, err := cpuCores(cpu)
+		if err != nil {
+			return err
+		}
+		if err := d.Set("cpu", cores); err != nil {
+			return err
 		}
 	}
-	
-	// Extract volumes for image
-	if spec, ok := vm.Object["spec"].(map[string]interface{}); ok {
-		if template, ok := spec["template"].(map[string]interface{}); ok {
-			if templateSpec, ok := template["spec"].(map[string]interface{}); ok {
-				if volumes, ok := templateSpec["volumes"].([]interface{}); ok {
-					for _, volume := range volumes {
-						if volMap, ok := volume.(map[string]interface{}); ok {
-							if containerDisk, ok := volMap["containerDisk"].(map[string]interface{}); ok {
-								if image, ok := containerDisk["image"].(string); ok {
-									if err := d.Set("image", image); err != nil {
-										return err
-									}
-									break
-								}
-							}
-						}
-					}
-				}
-			}
+	hugepages, _, _ := unstructured.NestedString(domain, "memory", "hugepages", "pageSize")
+	if err := d.Set("hugepages", hugepages); err != nil {
+		return err
+	}
+	machineType, _, _ := unstructured.NestedString(domain, "machine", "type")
+	if err := d.Set("machine_type", machineType); err != nil {
+		return err
+	}
+	architecture, _, _ := unstructured.NestedString(templateSpec, "architecture")
+	if err := d.Set("architecture", architecture); err != nil {
+		return err
+	}
+
+	sidecarHook, err := sidecarHookFromAnnotations(vm)
+	if err != nil {
+		return err
+	}
+	if err := d.Set("sidecar_hook", sidecarHook); err != nil {
+		return err
+	}
+
+	nodeSelector, _, _ := unstructured.NestedStringMap(templateSpec, "nodeSelector")
+	if err := d.Set("node_selector", nodeSelector); err != nil {
+		return err
+	}
+	if err := d.Set("tolerations", flattenVMTolerations(templateSpec)); err != nil {
+		return err
+	}
+	affinity := ""
+	if affinityObj, ok, _ := unstructured.NestedMap(templateSpec, "affinity"); ok {
+		data, err := json.Marshal(affinityObj)
+		if err != nil {
+			return fmt.Errorf("failed to marshal affinity: %v", err)
 		}
+		affinity = string(data)
 	}
-	
+	if err := d.Set("affinity", affinity); err != nil {
+		return err
+	}
+
+	hostDevices, pciDevices, gpuDevices, usbDevices := flattenVMHostDevices(domain, d)
+	if err := d.Set("host_devices", hostDevices); err != nil {
+		return err
+	}
+	if err := d.Set("pci_devices", pciDevices); err != nil {
+		return err
+	}
+	if err := d.Set("gpu_devices", gpuDevices); err != nil {
+		return err
+	}
+	if err := d.Set("usb_devices", usbDevices); err != nil {
+		return err
+	}
+	if err := d.Set("network_interfaces", flattenVMNetworkInterfaces(templateSpec)); err != nil {
+		return err
+	}
+
+	// Extract volumes for image and cloud-init
+	image := ""
+	userData := ""
+	volumes, _, _ := unstructured.NestedSlice(templateSpec, "volumes")
+	for _, volume := range volumes {
+		volMap, ok := volume.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if containerImage, ok, _ := unstructured.NestedString(volMap, "containerDisk", "image"); ok && image == "" {
+			image = containerImage
+		}
+		if volMap["name"] == "cloudinitdisk" {
+			userData, _, _ = unstructured.NestedString(volMap, "cloudInitNoCloud", "userData")
+		}
+	}
+	if err := d.Set("image", image); err != nil {
+		return err
+	}
+	rootDisk, dataDisks := flattenVMDataVolumeTemplates(vm)
+	if err := d.Set("root_disk", rootDisk); err != nil {
+		return err
+	}
+	if err := d.Set("data_disks", dataDisks); err != nil {
+		return err
+	}
+	// The agent token is merged into the user data, so the configured cloud-init is kept as long
+	// as rendering it again yields what the VM holds. Otherwise the user data is reported as is.
+	if rendered, err := cloudInitUserData(d.Get("cloud_init").(string), d.Get("coder_agent_token").(string)); err != nil || rendered != userData {
+		if err := d.Set("cloud_init", userData); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
+// suppressEquivalentJSON ignores differences in formatting between JSON documents,
+// as the VM read back from the cluster renders them its own way.
+func suppressEquivalentJSON(k, old, new string, d *schema.ResourceData) bool {
+	var oldObj, newObj interface{}
+	if err := json.Unmarshal([]byte(old), &oldObj); err != nil {
+		return false
+	}
+	if err := json.Unmarshal([]byte(new), &newObj); err != nil {
+		return false
+	}
+	return reflect.DeepEqual(oldObj, newObj)
+}
+
+// cpuCores returns the number of cores of a CPU request, which is a number
+// when written by the provider but may be any quantity when edited out of band.
+func cpuCores(cpu interface{}) (int, error) {
+	switch v := cpu.(type) {
+	case int64:
+		return int(v), nil
+	case float64:
+		return int(v), nil
+	case string:
+		quantity, err := resource.ParseQuantity(v)
+		if err != nil {
+			return 0, fmt.Errorf("failed to parse CPU request %q: %v", v, err)
+		}
+		return int(quantity.Value()), nil
+	default:
+		return 0, fmt.Errorf("unexpected CPU request %v", cpu)
+	}
+}
+
+// sidecarHookFromAnnotations returns the ConfigMap of the hook sidecar the template annotations declare.
+func sidecarHookFromAnnotations(vm *unstructured.Unstructured) (string, error) {
+	hookSidecars, ok, _ := unstructured.NestedString(vm.Object, "spec", "template", "metadata", "annotations", "hooks.kubevirt.io/hookSidecars")
+	if !ok || hookSidecars == "" {
+		return "", nil
+	}
+
+	var sidecars []struct {
+		ConfigMap struct {
+			Name string `json:"name"`
+		} `json:"configMap"`
+	}
+	if err := json.Unmarshal([]byte(hookSidecars), &sidecars); err != nil {
+		return "", fmt.Errorf("failed to parse hook sidecars annotation: %v", err)
+	}
+	if len(sidecars) == 0 {
+		return "", nil
+	}
+	return sidecars[0].ConfigMap.Name, nil
+}
+
+func flattenVMTolerations(templateSpec map[string]interface{}) []interface{} {
+	tolerations, _, _ := unstructured.NestedSlice(templateSpec, "tolerations")
+	result := make([]interface{}, 0, len(tolerations))
+	for _, tol := range tolerations {
+		tolMap, ok := tol.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		result = append(result, map[string]interface{}{
+			"key":      tolMap["key"],
+			"operator": tolMap["operator"],
+			"value":    tolMap["value"],
+			"effect":   tolMap["effect"],
+		})
+	}
+	return result
+}
+
+// flattenVMHostDevices attributes the host devices of the VM back to the arguments they were
+// rendered from. USB devices are recognized by their resource name, host devices and GPUs by the
+// names configured for them, and the remaining ones are reported as PCI devices.
+func flattenVMHostDevices(domain map[string]interface{}, d *schema.ResourceData) (hostDevices, pciDevices, gpuDevices, usbDevices []interface{}) {
+	configured := func(key string) map[string]bool {
+		names := make(map[string]bool)
+		for _, device := range d.Get(key).([]interface{}) {
+			if deviceMap, ok := device.(map[string]interface{}); ok {
+				names[deviceMap["name"].(string)] = true
+			}
+		}
+		return names
+	}
+	hostDeviceNames := configured("host_devices")
+	gpuDeviceNames := configured("gpu_devices")
+
+	hostDevices, pciDevices, gpuDevices, usbDevices = []interface{}{}, []interface{}{}, []interface{}{}, []interface{}{}
+	devices, _, _ := unstructured.NestedSlice(domain, "devices", "hostDevices")
+	for _, device := range devices {
+		deviceMap, ok := device.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		name, _ := deviceMap["name"].(string)
+		deviceName, _ := deviceMap["deviceName"].(string)
+
+		if vendorID, productID, ok := parseUSBDeviceResourceName(deviceName); ok {
+			usbDevices = append(usbDevices, map[string]interface{}{
+				"vendor_id":  vendorID,
+				"product_id": productID,
+			})
+			continue
+		}
+
+		flattened := map[string]interface{}{
+			"name":        name,
+			"device_name": deviceName,
+		}
+		switch {
+		case hostDeviceNames[name]:
+			hostDevices = append(hostDevices, flattened)
+		case gpuDeviceNames[name]:
+			gpuDevices = append(gpuDevices, flattened)
+		default:
+			pciDevices = append(pciDevices, flattened)
+		}
+	}
+	return hostDevices, pciDevices, gpuDevices, usbDevices
+}
+
+// flattenVMDataVolumeTemplates returns the root disk and data disks of the VM from the
+// DataVolume templates named after it.
+func flattenVMDataVolumeTemplates(vm *unstructured.Unstructured) ([]interface{}, []interface{}) {
+	rootDisk, dataDisks := []interface{}{}, []interface{}{}
+
+	dataVolumeTemplates, _, _ := unstructured.NestedSlice(vm.Object, "spec", "dataVolumeTemplates")
+	for _, template := range dataVolumeTemplates {
+		templateMap, ok := template.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		name, _, _ := unstructured.NestedString(templateMap, "metadata", "name")
+		diskName := strings.TrimPrefix(name, vm.GetName()+"-")
+		if diskName == name {
+			continue
+		}
+
+		disk := map[string]interface{}{}
+		disk["size"], _, _ = unstructured.NestedString(templateMap, "spec", "storage", "resources", "requests", "storage")
+		disk["storage_class"], _, _ = unstructured.NestedString(templateMap, "spec", "storage", "storageClassName")
+		disk["access_mode"] = ""
+		if accessModes, _, _ := unstructured.NestedStringSlice(templateMap, "spec", "storage", "accessModes"); len(accessModes) > 0 {
+			disk["access_mode"] = accessModes[0]
+		}
+
+		if diskName == rootDiskName {
+			disk["source_url"], _, _ = unstructured.NestedString(templateMap, "spec", "source", "http", "url")
+			disk["source_registry"], _, _ = unstructured.NestedString(templateMap, "spec", "source", "registry", "url")
+			rootDisk = append(rootDisk, disk)
+			continue
+		}
+		disk["name"] = diskName
+		dataDisks = append(dataDisks, disk)
+	}
+
+	return rootDisk, dataDisks
+}
+
+// flattenVMNetworkInterfaces returns the interfaces of the VM attached to Multus networks,
+// leaving out the pod network rendered next to them.
+func flattenVMNetworkInterfaces(templateSpec map[string]interface{}) []interface{} {
+	networks, _, _ := unstructured.NestedSlice(templateSpec, "networks")
+	result := make([]interface{}, 0, len(networks))
+	for _, network := range networks {
+		networkMap, ok := network.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		networkName, ok, _ := unstructured.NestedString(networkMap, "multus", "networkName")
+		if !ok {
+			continue
+		}
+		result = append(result, map[string]interface{}{
+			"name":         networkMap["name"],
+			"network_name": networkName,
+		})
+	}
+	return result
+}
+
 func getDynamicClient(cli client.Client) (dynamic.Interface, error) {
 	return cli.GetDynamicClient(), nil
 }
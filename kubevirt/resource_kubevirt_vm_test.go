@@ -0,0 +1,848 @@
+package kubevirt
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+	"github.com/nrp-nautilus/terraform-provider-kubevirt/kubevirt/client"
+	"github.com/nrp-nautilus/terraform-provider-kubevirt/kubevirt/client/mock"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	k8sschema "k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	k8stesting "k8s.io/client-go/testing"
+	kubevirtapiv1 "kubevirt.io/api/core/v1"
+
+	"gotest.tools/assert"
+)
+
+func TestCreateVMObject(t *testing.T) {
+	cases := []struct {
+		name     string
+		raw      map[string]interface{}
+		path     []string
+		expected interface{}
+	}{
+		{
+			name:     "machine type",
+			raw:      map[string]interface{}{"machine_type": "pc-q35-rhel8.0"},
+			path:     []string{"spec", "template", "spec", "domain", "machine"},
+			expected: map[string]interface{}{"type": "pc-q35-rhel8.0"},
+		},
+		{
+			name:     "hugepages",
+			raw:      map[string]interface{}{"hugepages": "1Gi"},
+			path:     []string{"spec", "template", "spec", "domain", "memory"},
+			expected: map[string]interface{}{"hugepages": map[string]interface{}{"pageSize": "1Gi"}},
+		},
+		{
+			name: "no hugepages",
+			path: []string{"spec", "template", "spec", "domain", "memory"},
+		},
+		{
+			name:     "default architecture",
+			path:     []string{"spec", "template", "spec", "architecture"},
+			expected: "amd64",
+		},
+		{
+			name:     "architecture",
+			raw:      map[string]interface{}{"architecture": "arm64"},
+			path:     []string{"spec", "template", "spec", "architecture"},
+			expected: "arm64",
+		},
+		{
+			name:     "stopped without a workspace transition",
+			path:     []string{"spec", "runStrategy"},
+			expected: "Halted",
+		},
+		{
+			name:     "start transition",
+			raw:      map[string]interface{}{"workspace_transition": "start"},
+			path:     []string{"spec", "runStrategy"},
+			expected: "Always",
+		},
+		{
+			name:     "stop transition",
+			raw:      map[string]interface{}{"workspace_transition": "stop"},
+			path:     []string{"spec", "runStrategy"},
+			expected: "Halted",
+		},
+		{
+			name: "coder agent token without cloud-init",
+			raw:  map[string]interface{}{"coder_agent_token": "token"},
+			path: []string{"spec", "template", "spec", "volumes"},
+			expected: []interface{}{
+				map[string]interface{}{"name": "containerdisk", "containerDisk": map[string]interface{}{"image": "quay.io/containerdisks/fedora:38"}},
+				map[string]interface{}{"name": "cloudinitdisk", "cloudInitNoCloud": map[string]interface{}{
+					"userData": "#cloud-config\nwrite_files:\n- content: |\n    CODER_AGENT_TOKEN=token\n  path: /etc/coder/agent.env\n  permissions: \"0600\"\n",
+				}},
+			},
+		},
+		{
+			name: "root disk and data disks",
+			raw: map[string]interface{}{
+				"image": "",
+				"root_disk": []interface{}{
+					map[string]interface{}{"size": "20Gi", "storage_class": "ceph-block", "source_registry": "docker://quay.io/containerdisks/fedora:38"},
+				},
+				"data_disks": []interface{}{
+					map[string]interface{}{"name": "home", "size": "10Gi", "access_mode": "ReadWriteMany"},
+				},
+			},
+			path: []string{"spec", "dataVolumeTemplates"},
+			expected: []interface{}{
+				map[string]interface{}{
+					"metadata": map[string]interface{}{"name": "test-vm-rootdisk"},
+					"spec": map[string]interface{}{
+						"source": map[string]interface{}{"registry": map[string]interface{}{"url": "docker://quay.io/containerdisks/fedora:38"}},
+						"storage": map[string]interface{}{
+							"resources":        map[string]interface{}{"requests": map[string]interface{}{"storage": "20Gi"}},
+							"storageClassName": "ceph-block",
+						},
+					},
+				},
+				map[string]interface{}{
+					"metadata": map[string]interface{}{"name": "test-vm-home"},
+					"spec": map[string]interface{}{
+						"source": map[string]interface{}{"blank": map[string]interface{}{}},
+						"storage": map[string]interface{}{
+							"resources":   map[string]interface{}{"requests": map[string]interface{}{"storage": "10Gi"}},
+							"accessModes": []interface{}{"ReadWriteMany"},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "root disk volumes",
+			raw: map[string]interface{}{
+				"image": "",
+				"root_disk": []interface{}{
+					map[string]interface{}{"size": "20Gi", "source_url": "https://example.com/fedora.qcow2"},
+				},
+				"data_disks": []interface{}{
+					map[string]interface{}{"name": "home", "size": "10Gi"},
+				},
+			},
+			path: []string{"spec", "template", "spec", "volumes"},
+			expected: []interface{}{
+				map[string]interface{}{"name": "rootdisk", "dataVolume": map[string]interface{}{"name": "test-vm-rootdisk"}},
+				map[string]interface{}{"name": "home", "dataVolume": map[string]interface{}{"name": "test-vm-home"}},
+			},
+		},
+		{
+			name: "no data volume templates with a container disk",
+			path: []string{"spec", "dataVolumeTemplates"},
+		},
+		{
+			name: "cloud-init disk",
+			raw:  map[string]interface{}{"cloud_init": "#cloud-config"},
+			path: []string{"spec", "template", "spec", "domain", "devices", "disks"},
+			expected: []interface{}{
+				map[string]interface{}{"name": "containerdisk", "disk": map[string]interface{}{}},
+				map[string]interface{}{"name": "cloudinitdisk", "disk": map[string]interface{}{"bus": "virtio"}},
+			},
+		},
+		{
+			name: "cloud-init volume",
+			raw:  map[string]interface{}{"cloud_init": "#cloud-config"},
+			path: []string{"spec", "template", "spec", "volumes"},
+			expected: []interface{}{
+				map[string]interface{}{"name": "containerdisk", "containerDisk": map[string]interface{}{"image": "quay.io/containerdisks/fedora:38"}},
+				map[string]interface{}{"name": "cloudinitdisk", "cloudInitNoCloud": map[string]interface{}{"userData": "#cloud-config"}},
+			},
+		},
+		{
+			name: "network interfaces",
+			raw: map[string]interface{}{
+				"network_interfaces": []interface{}{
+					map[string]interface{}{"name": "storage", "network_name": "infra/storage"},
+				},
+			},
+			path: []string{"spec", "template", "spec", "domain", "devices", "interfaces"},
+			expected: []interface{}{
+				map[string]interface{}{"name": "default", "masquerade": map[string]interface{}{}},
+				map[string]interface{}{"name": "storage", "bridge": map[string]interface{}{}},
+			},
+		},
+		{
+			name: "networks",
+			raw: map[string]interface{}{
+				"network_interfaces": []interface{}{
+					map[string]interface{}{"name": "storage", "network_name": "infra/storage"},
+				},
+			},
+			path: []string{"spec", "template", "spec", "networks"},
+			expected: []interface{}{
+				map[string]interface{}{"name": "default", "pod": map[string]interface{}{}},
+				map[string]interface{}{"name": "storage", "multus": map[string]interface{}{"networkName": "infra/storage"}},
+			},
+		},
+		{
+			name: "no network interfaces",
+			path: []string{"spec", "template", "spec", "networks"},
+		},
+		{
+			name: "usb and gpu devices",
+			raw: map[string]interface{}{
+				"gpu_devices": []interface{}{
+					map[string]interface{}{"name": "gpu1", "device_name": "nvidia.com/GA102GL_A10"},
+				},
+				"usb_devices": []interface{}{
+					map[string]interface{}{"vendor_id": "046D", "product_id": "c52b"},
+				},
+			},
+			path: []string{"spec", "template", "spec", "domain", "devices", "hostDevices"},
+			expected: []interface{}{
+				map[string]interface{}{"name": "gpu1", "deviceName": "nvidia.com/GA102GL_A10"},
+				map[string]interface{}{"name": "usb-046d-c52b", "deviceName": "kubevirt.io/usb-046d-c52b"},
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			obj := kubevirtVMObject(t, tc.raw)
+
+			actual, _, err := unstructured.NestedFieldNoCopy(obj, tc.path...)
+			assert.NilError(t, err)
+			assert.DeepEqual(t, tc.expected, actual)
+		})
+	}
+}
+
+// kubevirtVMObject renders the VM object of a kubevirt_kubevirt_vm configuration,
+// round-tripped through JSON the way it's sent to the cluster.
+func kubevirtVMObject(t *testing.T, raw map[string]interface{}) map[string]interface{} {
+	config := map[string]interface{}{
+		"name":      "test-vm",
+		"namespace": "default",
+		"image":     "quay.io/containerdisks/fedora:38",
+		"memory":    "2Gi",
+		"cpu":       2,
+	}
+	for k, v := range raw {
+		config[k] = v
+	}
+	d := schema.TestResourceDataRaw(t, resourceKubevirtKubevirtVM().Schema, config)
+
+	vm, err := createVMObject(d)
+	assert.NilError(t, err)
+
+	data, err := json.Marshal(vm.Object)
+	assert.NilError(t, err)
+	obj := make(map[string]interface{})
+	assert.NilError(t, json.Unmarshal(data, &obj))
+
+	return obj
+}
+
+func TestCloudInitUserData(t *testing.T) {
+	cases := []struct {
+		name            string
+		cloudInit       string
+		coderAgentToken string
+		expected        string
+		expectedError   string
+	}{
+		{
+			name:      "without coder agent token",
+			cloudInit: "#!/bin/sh\necho hello",
+			expected:  "#!/bin/sh\necho hello",
+		},
+		{
+			name:            "coder agent token appended to write_files",
+			cloudInit:       "#cloud-config\nwrite_files:\n- path: /etc/motd\n  content: hello\n",
+			coderAgentToken: "token",
+			expected:        "#cloud-config\nwrite_files:\n- content: hello\n  path: /etc/motd\n- content: |\n    CODER_AGENT_TOKEN=token\n  path: /etc/coder/agent.env\n  permissions: \"0600\"\n",
+		},
+		{
+			name:            "coder agent token with a script",
+			cloudInit:       "#!/bin/sh\necho hello",
+			coderAgentToken: "token",
+			expectedError:   "coder_agent_token requires cloud_init to be a #cloud-config document",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			userData, err := cloudInitUserData(tc.cloudInit, tc.coderAgentToken)
+
+			if tc.expectedError == "" {
+				assert.NilError(t, err)
+				assert.Equal(t, tc.expected, userData)
+			} else {
+				assert.Error(t, err, tc.expectedError)
+			}
+		})
+	}
+}
+
+func TestWorkspaceTransitionFromVM(t *testing.T) {
+	cases := []struct {
+		name     string
+		vm       map[string]interface{}
+		expected string
+	}{
+		{
+			name:     "running",
+			vm:       map[string]interface{}{"status": map[string]interface{}{"printableStatus": "Running"}},
+			expected: "start",
+		},
+		{
+			name:     "starting",
+			vm:       map[string]interface{}{"status": map[string]interface{}{"printableStatus": "Starting"}},
+			expected: "start",
+		},
+		{
+			name:     "stopping",
+			vm:       map[string]interface{}{"status": map[string]interface{}{"printableStatus": "Stopping"}},
+			expected: "stop",
+		},
+		{
+			name:     "halted without status",
+			vm:       map[string]interface{}{"spec": map[string]interface{}{"runStrategy": "Halted"}},
+			expected: "stop",
+		},
+		{
+			name:     "not running without status",
+			vm:       map[string]interface{}{"spec": map[string]interface{}{"running": false}},
+			expected: "stop",
+		},
+		{
+			name:     "always without status",
+			vm:       map[string]interface{}{"spec": map[string]interface{}{"runStrategy": "Always"}},
+			expected: "start",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, workspaceTransitionFromVM(&unstructured.Unstructured{Object: tc.vm}))
+		})
+	}
+}
+
+func TestWorkspaceTransitionDelete(t *testing.T) {
+	resourceSchema := resourceKubevirtKubevirtVM().Schema
+	_, errs := resourceSchema["workspace_transition"].ValidateFunc("delete", "workspace_transition")
+	assert.Equal(t, 0, len(errs))
+	assert.Equal(t, "Halted", workspaceRunStrategy("delete"))
+
+	// A stopped VM keeps reporting the delete transition it was given, so that plans stay clean.
+	vm := &unstructured.Unstructured{Object: kubevirtVMObject(t, map[string]interface{}{"workspace_transition": "delete"})}
+	assert.NilError(t, unstructured.SetNestedField(vm.Object, "Stopped", "status", "printableStatus"))
+	d := schema.TestResourceDataRaw(t, resourceSchema, map[string]interface{}{"workspace_transition": "delete"})
+	assert.NilError(t, updateResourceDataFromVM(vm, d))
+	assert.Equal(t, "delete", d.Get("workspace_transition"))
+
+	assert.NilError(t, unstructured.SetNestedField(vm.Object, "Running", "status", "printableStatus"))
+	assert.NilError(t, updateResourceDataFromVM(vm, d))
+	assert.Equal(t, "start", d.Get("workspace_transition"))
+}
+
+func TestUpdateResourceDataFromVM(t *testing.T) {
+	raw := map[string]interface{}{
+		"name":                 "test-vm",
+		"namespace":            "default",
+		"image":                "quay.io/containerdisks/fedora:38",
+		"memory":               "2Gi",
+		"cpu":                  2,
+		"machine_type":         "q35",
+		"architecture":         "amd64",
+		"hugepages":            "2Mi",
+		"sidecar_hook":         "hook",
+		"node_selector":        map[string]interface{}{"gpu": "true"},
+		"affinity":             `{"nodeAffinity": {"requiredDuringSchedulingIgnoredDuringExecution": {"nodeSelectorTerms": [{"matchExpressions": [{"key": "zone", "operator": "In", "values": ["a"]}]}]}}}`,
+		"cloud_init":           "#cloud-config\n",
+		"coder_agent_token":    "token",
+		"workspace_transition": "start",
+		"tolerations": []interface{}{
+			map[string]interface{}{"key": "gpu", "operator": "Exists", "value": "", "effect": "NoSchedule"},
+		},
+		"host_devices": []interface{}{
+			map[string]interface{}{"name": "nic", "device_name": "intel.com/e810"},
+		},
+		"pci_devices": []interface{}{
+			map[string]interface{}{"name": "fpga", "device_name": "xilinx.com/u250"},
+		},
+		"gpu_devices": []interface{}{
+			map[string]interface{}{"name": "gpu1", "device_name": "nvidia.com/GA102GL_A10"},
+		},
+		"usb_devices": []interface{}{
+			map[string]interface{}{"vendor_id": "046d", "product_id": "c52b"},
+		},
+		"network_interfaces": []interface{}{
+			map[string]interface{}{"name": "storage", "network_name": "infra/storage"},
+		},
+	}
+	resourceSchema := resourceKubevirtKubevirtVM().Schema
+
+	// Reading back the VM rendered from a configuration has to yield that configuration, so that plans stay clean.
+	vm := &unstructured.Unstructured{Object: kubevirtVMObject(t, raw)}
+	assert.NilError(t, unstructured.SetNestedField(vm.Object, "Running", "status", "printableStatus"))
+	d := schema.TestResourceDataRaw(t, resourceSchema, raw)
+	assert.NilError(t, updateResourceDataFromVM(vm, d))
+	for key, expected := range raw {
+		if key == "affinity" {
+			assert.Assert(t, suppressEquivalentJSON(key, expected.(string), d.Get(key).(string), d))
+			continue
+		}
+		assert.DeepEqual(t, expected, d.Get(key))
+	}
+	assert.Equal(t, "Running", d.Get("vm_status"))
+
+	// Out of band edits show up as drift.
+	assert.NilError(t, unstructured.SetNestedField(vm.Object, "4", "spec", "template", "spec", "domain", "resources", "requests", "cpu"))
+	assert.NilError(t, unstructured.SetNestedSlice(vm.Object, []interface{}{}, "spec", "template", "spec", "tolerations"))
+	volumes, _, _ := unstructured.NestedSlice(vm.Object, "spec", "template", "spec", "volumes")
+	assert.NilError(t, unstructured.SetNestedField(volumes[1].(map[string]interface{}), "#cloud-config\n", "cloudInitNoCloud", "userData"))
+	assert.NilError(t, unstructured.SetNestedSlice(vm.Object, volumes, "spec", "template", "spec", "volumes"))
+	d = schema.TestResourceDataRaw(t, resourceSchema, raw)
+	assert.NilError(t, updateResourceDataFromVM(vm, d))
+	assert.Equal(t, 4, d.Get("cpu"))
+	assert.DeepEqual(t, []interface{}{}, d.Get("tolerations"))
+	assert.Equal(t, "#cloud-config\n", d.Get("cloud_init"))
+}
+
+func TestMergeVMObject(t *testing.T) {
+	live := &unstructured.Unstructured{Object: kubevirtVMObject(t, map[string]interface{}{
+		"tolerations": []interface{}{
+			map[string]interface{}{"key": "gpu", "operator": "Exists"},
+		},
+		"data_disks": []interface{}{
+			map[string]interface{}{"name": "scratch", "size": "1Gi"},
+		},
+	})}
+	live.SetResourceVersion("42")
+	// A volume hotplugged by another client.
+	disks, _, _ := unstructured.NestedSlice(live.Object, "spec", "template", "spec", "domain", "devices", "disks")
+	disks = append(disks, map[string]interface{}{"name": "hotplug", "disk": map[string]interface{}{"bus": "scsi"}})
+	assert.NilError(t, unstructured.SetNestedSlice(live.Object, disks, "spec", "template", "spec", "domain", "devices", "disks"))
+	volumes, _, _ := unstructured.NestedSlice(live.Object, "spec", "template", "spec", "volumes")
+	volumes = append(volumes, map[string]interface{}{"name": "hotplug", "persistentVolumeClaim": map[string]interface{}{"claimName": "hotplug", "hotpluggable": true}})
+	assert.NilError(t, unstructured.SetNestedSlice(live.Object, volumes, "spec", "template", "spec", "volumes"))
+	live.SetAnnotations(map[string]string{"kubevirt.io/latest-observed-api-version": "v1"})
+	assert.NilError(t, unstructured.SetNestedField(live.Object, true, "spec", "running"))
+	assert.NilError(t, unstructured.SetNestedField(live.Object, "6a1a24a1-4061-4607-8bf4-a3963d0c5895", "spec", "template", "spec", "domain", "firmware", "uuid"))
+	assert.NilError(t, unstructured.SetNestedField(live.Object, "Running", "status", "printableStatus"))
+
+	desiredVM, err := createVMObject(schema.TestResourceDataRaw(t, resourceKubevirtKubevirtVM().Schema, map[string]interface{}{
+		"name":                 "test-vm",
+		"namespace":            "default",
+		"image":                "quay.io/containerdisks/fedora:38",
+		"memory":               "4Gi",
+		"cpu":                  2,
+		"workspace_transition": "start",
+	}))
+	assert.NilError(t, err)
+
+	// The scratch data disk was removed from the configuration.
+	merged, err := mergeVMObject(live, desiredVM, map[string]bool{rootDiskName: true, "containerdisk": true, "cloudinitdisk": true, "scratch": true})
+	assert.NilError(t, err)
+
+	// The fields set by the server are kept.
+	assert.Equal(t, "42", merged.GetResourceVersion())
+	assert.DeepEqual(t, map[string]string{"kubevirt.io/latest-observed-api-version": "v1"}, merged.GetAnnotations())
+	uuid, _, _ := unstructured.NestedString(merged.Object, "spec", "template", "spec", "domain", "firmware", "uuid")
+	assert.Equal(t, "6a1a24a1-4061-4607-8bf4-a3963d0c5895", uuid)
+	printableStatus, _, _ := unstructured.NestedString(merged.Object, "status", "printableStatus")
+	assert.Equal(t, "Running", printableStatus)
+
+	// The managed fields follow the configuration, including the ones it leaves out.
+	memory, _, _ := unstructured.NestedString(merged.Object, "spec", "template", "spec", "domain", "resources", "requests", "memory")
+	assert.Equal(t, "4Gi", memory)
+	runStrategy, _, _ := unstructured.NestedString(merged.Object, "spec", "runStrategy")
+	assert.Equal(t, "Always", runStrategy)
+	_, found, _ := unstructured.NestedFieldNoCopy(merged.Object, "spec", "running")
+	assert.Assert(t, !found)
+	_, found, _ = unstructured.NestedFieldNoCopy(merged.Object, "spec", "template", "spec", "tolerations")
+	assert.Assert(t, !found)
+
+	// The disks and volumes are merged by name, keeping the hotplugged ones.
+	mergedDisks, _, _ := unstructured.NestedSlice(merged.Object, "spec", "template", "spec", "domain", "devices", "disks")
+	assert.DeepEqual(t, []interface{}{
+		map[string]interface{}{"name": "containerdisk", "disk": map[string]interface{}{}},
+		map[string]interface{}{"name": "hotplug", "disk": map[string]interface{}{"bus": "scsi"}},
+	}, mergedDisks)
+	mergedVolumes, _, _ := unstructured.NestedSlice(merged.Object, "spec", "template", "spec", "volumes")
+	assert.DeepEqual(t, []interface{}{
+		map[string]interface{}{"name": "containerdisk", "containerDisk": map[string]interface{}{"image": "quay.io/containerdisks/fedora:38"}},
+		map[string]interface{}{"name": "hotplug", "persistentVolumeClaim": map[string]interface{}{"claimName": "hotplug", "hotpluggable": true}},
+	}, mergedVolumes)
+
+	// The live object is left as read.
+	liveMemory, _, _ := unstructured.NestedString(live.Object, "spec", "template", "spec", "domain", "resources", "requests", "memory")
+	assert.Equal(t, "2Gi", liveMemory)
+}
+
+func TestCreateVMObjectDiskValidation(t *testing.T) {
+	cases := []struct {
+		name          string
+		raw           map[string]interface{}
+		expectedError string
+	}{
+		{
+			name: "reserved data disk name",
+			raw: map[string]interface{}{
+				"image":      "quay.io/containerdisks/fedora:38",
+				"data_disks": []interface{}{map[string]interface{}{"name": "cloudinitdisk", "size": "1Gi"}},
+			},
+			expectedError: "data disk name \"cloudinitdisk\" is reserved",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			raw := map[string]interface{}{
+				"name":      "test-vm",
+				"namespace": "default",
+				"memory":    "2Gi",
+				"cpu":       2,
+			}
+			for k, v := range tc.raw {
+				raw[k] = v
+			}
+
+			_, err := createVMObject(schema.TestResourceDataRaw(t, resourceKubevirtKubevirtVM().Schema, raw))
+			assert.Error(t, err, tc.expectedError)
+		})
+	}
+}
+
+func TestValidateVMRootDiskSource(t *testing.T) {
+	cases := []struct {
+		name          string
+		rootDisk      map[string]interface{}
+		expectedError bool
+	}{
+		{
+			name:     "source url",
+			rootDisk: map[string]interface{}{"size": "20Gi", "source_url": "https://example.com/fedora.qcow2"},
+		},
+		{
+			name:     "source registry",
+			rootDisk: map[string]interface{}{"size": "20Gi", "source_registry": "docker://quay.io/containerdisks/fedora:38"},
+		},
+		{
+			name:          "no source",
+			rootDisk:      map[string]interface{}{"size": "20Gi"},
+			expectedError: true,
+		},
+		{
+			name:          "both sources",
+			rootDisk:      map[string]interface{}{"size": "20Gi", "source_url": "https://example.com/fedora.qcow2", "source_registry": "docker://quay.io/containerdisks/fedora:38"},
+			expectedError: true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			raw := map[string]interface{}{
+				"name":      "test-vm",
+				"namespace": "default",
+				"memory":    "2Gi",
+				"cpu":       2,
+				"root_disk": []interface{}{tc.rootDisk},
+			}
+
+			diags := resourceKubevirtKubevirtVM().Validate(terraform.NewResourceConfigRaw(raw))
+			assert.Equal(t, tc.expectedError, diags.HasError(), "unexpected diagnostics %v", diags)
+		})
+	}
+}
+
+func TestValidateVMDiskChanges(t *testing.T) {
+	rootDisk := map[string]interface{}{"size": "20Gi", "storage_class": "ceph-block", "access_mode": "ReadWriteOnce", "source_url": "https://example.com/fedora.qcow2"}
+	dataDisk := map[string]interface{}{"name": "data", "size": "10Gi", "storage_class": "ceph-block", "access_mode": "ReadWriteOnce"}
+	otherDataDisk := map[string]interface{}{"name": "scratch", "size": "5Gi"}
+
+	cases := []struct {
+		name          string
+		rootDisk      map[string]interface{}
+		dataDisk      map[string]interface{}
+		dataDisks     []interface{}
+		expectedError string
+	}{
+		{name: "unchanged"},
+		{name: "root disk grown", rootDisk: map[string]interface{}{"size": "40Gi"}},
+		{name: "data disk grown", dataDisk: map[string]interface{}{"size": "10240Mi"}},
+		{name: "data disk added", dataDisks: []interface{}{dataDisk, otherDataDisk}},
+		{name: "data disk removed", dataDisks: []interface{}{}},
+		{
+			name:          "root disk shrunk",
+			rootDisk:      map[string]interface{}{"size": "10Gi"},
+			expectedError: `disk "rootdisk" can't shrink from 20Gi to 10Gi`,
+		},
+		{
+			name:          "data disk shrunk",
+			dataDisk:      map[string]interface{}{"size": "9Gi"},
+			expectedError: `disk "data" can't shrink from 10Gi to 9Gi`,
+		},
+		{
+			name:          "root disk source url",
+			rootDisk:      map[string]interface{}{"source_url": "https://example.com/fedora-39.qcow2"},
+			expectedError: `source_url of disk "rootdisk" can't be changed in place, as the VM owns its DataVolume: replace the VM explicitly to recreate its disks`,
+		},
+		{
+			name:          "root disk source",
+			rootDisk:      map[string]interface{}{"source_url": "", "source_registry": "docker://quay.io/containerdisks/fedora:38"},
+			expectedError: `source_url of disk "rootdisk" can't be changed in place, as the VM owns its DataVolume: replace the VM explicitly to recreate its disks`,
+		},
+		{
+			name:          "root disk storage class",
+			rootDisk:      map[string]interface{}{"storage_class": "ceph-fs"},
+			expectedError: `storage_class of disk "rootdisk" can't be changed in place, as the VM owns its DataVolume: replace the VM explicitly to recreate its disks`,
+		},
+		{
+			name:          "data disk access mode",
+			dataDisk:      map[string]interface{}{"access_mode": "ReadWriteMany"},
+			expectedError: `access_mode of disk "data" can't be changed in place, as the VM owns its DataVolume: replace the VM explicitly to recreate its disks`,
+		},
+	}
+
+	resource := resourceKubevirtKubevirtVM()
+	config := func(rootDiskChanges, dataDiskChanges map[string]interface{}, dataDisks []interface{}) map[string]interface{} {
+		root := map[string]interface{}{}
+		for k, v := range rootDisk {
+			root[k] = v
+		}
+		for k, v := range rootDiskChanges {
+			root[k] = v
+		}
+		data := map[string]interface{}{}
+		for k, v := range dataDisk {
+			data[k] = v
+		}
+		for k, v := range dataDiskChanges {
+			data[k] = v
+		}
+		if dataDisks == nil {
+			dataDisks = []interface{}{data}
+		}
+		return map[string]interface{}{
+			"name":       "test-vm",
+			"namespace":  "default",
+			"memory":     "2Gi",
+			"cpu":        2,
+			"root_disk":  []interface{}{root},
+			"data_disks": dataDisks,
+		}
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			d := schema.TestResourceDataRaw(t, resource.Schema, config(nil, nil, nil))
+			d.SetId("default/test-vm")
+
+			diff, err := resource.Diff(context.Background(), d.State(), terraform.NewResourceConfigRaw(config(tc.rootDisk, tc.dataDisk, tc.dataDisks)), nil)
+
+			if tc.expectedError != "" {
+				assert.Error(t, err, tc.expectedError)
+				return
+			}
+			assert.NilError(t, err)
+			// The VM owns the DataVolumes, so replacing it would delete the disks.
+			assert.Assert(t, diff == nil || !diff.RequiresNew())
+		})
+	}
+}
+
+func TestExpandVMDiskVolumes(t *testing.T) {
+	pvc := func(name, size string) *unstructured.Unstructured {
+		return &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "PersistentVolumeClaim",
+			"metadata":   map[string]interface{}{"name": name, "namespace": "default"},
+			"spec": map[string]interface{}{
+				"resources": map[string]interface{}{
+					"requests": map[string]interface{}{"storage": size},
+				},
+			},
+		}}
+	}
+	dynamicClient := dynamicfake.NewSimpleDynamicClient(runtime.NewScheme(), pvc("test-vm-rootdisk", "20Gi"), pvc("test-vm-data", "10Gi"))
+
+	raw := func(rootDiskSize, dataDiskSize string) map[string]interface{} {
+		return map[string]interface{}{
+			"name":      "test-vm",
+			"namespace": "default",
+			"memory":    "2Gi",
+			"cpu":       2,
+			"root_disk": []interface{}{
+				map[string]interface{}{"size": rootDiskSize, "source_url": "https://example.com/fedora.qcow2"},
+			},
+			"data_disks": []interface{}{
+				map[string]interface{}{"name": "data", "size": dataDiskSize},
+				// Not created yet, so there's no PVC to expand.
+				map[string]interface{}{"name": "scratch", "size": "5Gi"},
+			},
+		}
+	}
+	resource := resourceKubevirtKubevirtVM()
+	stateRaw := raw("20Gi", "10Gi")
+	stateRaw["data_disks"] = stateRaw["data_disks"].([]interface{})[:1]
+	state := schema.TestResourceDataRaw(t, resource.Schema, stateRaw)
+	state.SetId("default/test-vm")
+	diff, err := resource.Diff(context.Background(), state.State(), terraform.NewResourceConfigRaw(raw("20Gi", "15Gi")), nil)
+	assert.NilError(t, err)
+	d, err := schema.InternalMap(resource.Schema).Data(state.State(), diff)
+	assert.NilError(t, err)
+
+	assert.NilError(t, expandVMDiskVolumes(dynamicClient, "default", "test-vm", d))
+
+	pvcResource := dynamicClient.Resource(k8sschema.GroupVersionResource{Version: "v1", Resource: "persistentvolumeclaims"}).Namespace("default")
+	for name, expected := range map[string]string{"test-vm-rootdisk": "20Gi", "test-vm-data": "15Gi"} {
+		obj, err := pvcResource.Get(context.Background(), name, metav1.GetOptions{})
+		assert.NilError(t, err)
+		size, _, _ := unstructured.NestedString(obj.Object, "spec", "resources", "requests", "storage")
+		assert.Equal(t, expected, size, name)
+	}
+}
+
+func TestResourceKubevirtKubevirtVMDelete(t *testing.T) {
+	vm := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "kubevirt.io/v1",
+		"kind":       "VirtualMachine",
+		"metadata":   map[string]interface{}{"name": "test-vm", "namespace": "default"},
+	}}
+	dynamicClient := dynamicfake.NewSimpleDynamicClient(runtime.NewScheme(), vm)
+
+	d := schema.TestResourceDataRaw(t, resourceKubevirtKubevirtVM().Schema, map[string]interface{}{
+		"name":      "test-vm",
+		"namespace": "default",
+		"memory":    "2Gi",
+		"cpu":       2,
+		"root_disk": []interface{}{
+			map[string]interface{}{"size": "20Gi", "source_url": "https://example.com/fedora.qcow2"},
+		},
+		"data_disks": []interface{}{
+			map[string]interface{}{"name": "data", "size": "10Gi"},
+		},
+	})
+	d.SetId("default/test-vm")
+
+	// The VM and its DataVolumes are only deleted once they're gone, so that a replacement can reuse their names.
+	timeout := d.Timeout(schema.TimeoutDelete)
+	cli := mock.NewMockClient(gomock.NewController(t))
+	cli.EXPECT().GetDynamicClient().Return(dynamicClient)
+	gomock.InOrder(
+		cli.EXPECT().WaitForDeletion(client.VirtualMachineResource, "default", "test-vm", timeout).Return(nil),
+		cli.EXPECT().WaitForDeletion(client.DataVolumeResource, "default", "test-vm-data", timeout).Return(nil),
+		cli.EXPECT().WaitForDeletion(client.DataVolumeResource, "default", "test-vm-rootdisk", timeout).Return(nil),
+	)
+
+	assert.NilError(t, resourceKubevirtKubevirtVMDelete(d, cli))
+
+	_, err := dynamicClient.Resource(k8sschema.GroupVersionResource{Group: "kubevirt.io", Version: "v1", Resource: "virtualmachines"}).Namespace("default").Get(context.Background(), "test-vm", metav1.GetOptions{})
+	assert.Assert(t, errors.IsNotFound(err))
+}
+
+func TestResourceKubevirtKubevirtVMUpdateWorkspaceTransition(t *testing.T) {
+	cases := []struct {
+		name     string
+		from     string
+		to       string
+		expected []string
+	}{
+		{
+			// The VM boots with the updated spec.
+			name:     "start",
+			from:     "stop",
+			to:       "start",
+			expected: []string{"get", "update", "patch", "wait", "get"},
+		},
+		{
+			// The VM stops before its spec is updated.
+			name:     "stop",
+			from:     "start",
+			to:       "stop",
+			expected: []string{"patch", "wait", "get", "update", "get"},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			stateRaw := map[string]interface{}{"workspace_transition": tc.from}
+			configRaw := map[string]interface{}{"workspace_transition": tc.to, "memory": "4Gi"}
+			vm := &unstructured.Unstructured{Object: kubevirtVMObject(t, stateRaw)}
+			dynamicClient := dynamicfake.NewSimpleDynamicClient(runtime.NewScheme(), vm)
+
+			steps := []string{}
+			dynamicClient.PrependReactor("*", "*", func(action k8stesting.Action) (bool, runtime.Object, error) {
+				steps = append(steps, action.GetVerb())
+				return false, nil, nil
+			})
+			cli := mock.NewMockClient(gomock.NewController(t))
+			cli.EXPECT().GetDynamicClient().Return(dynamicClient).AnyTimes()
+			cli.EXPECT().WaitForVMCondition("default", "test-vm", gomock.Any(), gomock.Any()).DoAndReturn(
+				func(namespace, name string, condition client.VirtualMachineConditionFunc, timeout time.Duration) (*kubevirtapiv1.VirtualMachine, error) {
+					steps = append(steps, "wait")
+					return nil, nil
+				})
+
+			resource := resourceKubevirtKubevirtVM()
+			config := map[string]interface{}{
+				"name":      "test-vm",
+				"namespace": "default",
+				"image":     "quay.io/containerdisks/fedora:38",
+				"memory":    "2Gi",
+				"cpu":       2,
+			}
+			for k, v := range stateRaw {
+				config[k] = v
+			}
+			state := schema.TestResourceDataRaw(t, resource.Schema, config)
+			state.SetId("default/test-vm")
+			for k, v := range configRaw {
+				config[k] = v
+			}
+			diff, err := resource.Diff(context.Background(), state.State(), terraform.NewResourceConfigRaw(config), nil)
+			assert.NilError(t, err)
+			d, err := schema.InternalMap(resource.Schema).Data(state.State(), diff)
+			assert.NilError(t, err)
+
+			assert.NilError(t, resourceKubevirtKubevirtVMUpdate(d, cli))
+
+			assert.DeepEqual(t, tc.expected, steps)
+			for _, action := range dynamicClient.Actions() {
+				if update, ok := action.(k8stesting.UpdateAction); ok {
+					// The run strategy only changes through the transition.
+					runStrategy, _, _ := unstructured.NestedString(update.GetObject().(*unstructured.Unstructured).Object, "spec", "runStrategy")
+					assert.Equal(t, "Halted", runStrategy)
+				}
+			}
+		})
+	}
+}
+
+func TestUpdateResourceDataFromVMDisks(t *testing.T) {
+	raw := map[string]interface{}{
+		"name":      "test-vm",
+		"namespace": "default",
+		"memory":    "2Gi",
+		"cpu":       2,
+		"root_disk": []interface{}{
+			map[string]interface{}{"size": "20Gi", "storage_class": "ceph-block", "access_mode": "ReadWriteOnce", "source_url": "https://example.com/fedora.qcow2", "source_registry": ""},
+		},
+		"data_disks": []interface{}{
+			map[string]interface{}{"name": "home", "size": "10Gi", "storage_class": "", "access_mode": ""},
+		},
+	}
+	resourceSchema := resourceKubevirtKubevirtVM().Schema
+
+	// Reading back the persistent disks has to yield their configuration, so that plans stay clean.
+	vm := &unstructured.Unstructured{Object: kubevirtVMObject(t, raw)}
+	d := schema.TestResourceDataRaw(t, resourceSchema, raw)
+	assert.NilError(t, updateResourceDataFromVM(vm, d))
+	assert.Equal(t, "", d.Get("image"))
+	assert.DeepEqual(t, raw["root_disk"], d.Get("root_disk"))
+	assert.DeepEqual(t, raw["data_disks"], d.Get("data_disks"))
+}
@@ -5,11 +5,17 @@ import (
 	"log"
 	"time"
 
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/customdiff"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/nrp-nautilus/terraform-provider-kubevirt/kubevirt/client"
 	"github.com/nrp-nautilus/terraform-provider-kubevirt/kubevirt/schema/virtualmachine"
+	"github.com/nrp-nautilus/terraform-provider-kubevirt/kubevirt/schema/virtualmachineinstance"
 	"github.com/nrp-nautilus/terraform-provider-kubevirt/kubevirt/utils"
+	"github.com/nrp-nautilus/terraform-provider-kubevirt/kubevirt/utils/patch"
+	k8sv1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubevirtapiv1 "kubevirt.io/api/core/v1"
 )
 
 func resourceKubevirtVirtualMachine() *schema.Resource {
@@ -28,6 +34,10 @@ func resourceKubevirtVirtualMachine() *schema.Resource {
 			Delete: schema.DefaultTimeout(5 * time.Minute),
 		},
 		Schema: virtualmachine.VirtualMachineFields(),
+		CustomizeDiff: customdiff.All(
+			virtualmachine.ValidatePowerStateDiff,
+			virtualmachineinstance.ValidateSpecDiff("spec.0.template.0.spec"),
+		),
 	}
 }
 
@@ -38,16 +48,31 @@ func resourceKubevirtVirtualMachineCreate(resourceData *schema.ResourceData, met
 	if err != nil {
 		return err
 	}
+	secrets := virtualmachine.ExtractCloudInitSecrets(vm, virtualmachine.CloudInitSecretVolumeNames(resourceData.Get("spec").([]interface{})))
 
 	log.Printf("[INFO] Creating new virtual machine: %#v", vm)
-	if err := cli.CreateVirtualMachine(vm); err != nil {
+	if cli.ServerSideApply() {
+		err = cli.ApplyVirtualMachine(vm)
+	} else {
+		err = cli.CreateVirtualMachine(vm)
+	}
+	if err != nil {
 		return err
 	}
 	log.Printf("[INFO] Submitted new virtual machine: %#v", vm)
-	if err := virtualmachine.ToResourceData(*vm, resourceData); err != nil {
+	// Track the virtual machine right away, so that failing to write its secrets doesn't orphan it.
+	resourceData.SetId(utils.BuildId(vm.ObjectMeta))
+	if err := applyCloudInitSecrets(cli, vm, secrets); err != nil {
+		return err
+	}
+	cloudInitSecretVolumes := virtualmachine.InlineCloudInitSecrets(vm, cloudInitSecretsByName(secrets))
+	if err := virtualmachine.ToResourceData(*vm, cloudInitSecretVolumes, resourceData); err != nil {
+		return err
+	}
+
+	if err := convergeVirtualMachine(cli, vm.Namespace, vm.Name, resourceData, schema.TimeoutCreate); err != nil {
 		return err
 	}
-	resourceData.SetId(utils.BuildId(vm.ObjectMeta))
 
 	log.Printf("[INFO] Successfully created virtual machine: %s", vm.Name)
 	return resourceKubevirtVirtualMachineRead(resourceData, meta)
@@ -73,7 +98,13 @@ func resourceKubevirtVirtualMachineRead(resourceData *schema.ResourceData, meta
 		return fmt.Errorf("failed to read virtual machine: %v", err)
 	}
 
-	if err := virtualmachine.ToResourceData(*vm, resourceData); err != nil {
+	secrets, err := readCloudInitSecrets(cli, vm)
+	if err != nil {
+		return err
+	}
+	cloudInitSecretVolumes := virtualmachine.InlineCloudInitSecrets(vm, cloudInitSecretsByName(secrets))
+
+	if err := virtualmachine.ToResourceData(*vm, cloudInitSecretVolumes, resourceData); err != nil {
 		return fmt.Errorf("failed to convert virtual machine to resource data: %v", err)
 	}
 
@@ -88,26 +119,75 @@ func resourceKubevirtVirtualMachineUpdate(resourceData *schema.ResourceData, met
 		return err
 	}
 
-	log.Printf("[INFO] Updating virtual machine %s", name)
-
-	// Get current VM to get the resource version
-	currentVM, err := cli.GetVirtualMachine(namespace, name)
+	staleSecrets, err := virtualmachine.StaleCloudInitSecretNames(resourceData)
 	if err != nil {
-		return fmt.Errorf("failed to get current virtual machine: %v", err)
+		return err
 	}
 
-	// Create updated VM object
-	updatedVM, err := virtualmachine.FromResourceData(resourceData)
+	if cli.ServerSideApply() {
+		vm, err := virtualmachine.FromResourceData(resourceData)
+		if err != nil {
+			return err
+		}
+		secrets := virtualmachine.ExtractCloudInitSecrets(vm, virtualmachine.CloudInitSecretVolumeNames(resourceData.Get("spec").([]interface{})))
+
+		log.Printf("[INFO] Applying virtual machine: %#v", vm)
+		if err := cli.ApplyVirtualMachine(vm); err != nil {
+			return fmt.Errorf("failed to apply virtual machine: %v", err)
+		}
+		if err := applyCloudInitSecrets(cli, vm, secrets); err != nil {
+			return err
+		}
+		if err := deleteCloudInitSecrets(cli, namespace, staleSecrets); err != nil {
+			return err
+		}
+
+		if err := convergeVirtualMachine(cli, namespace, name, resourceData, schema.TimeoutUpdate); err != nil {
+			return err
+		}
+
+		log.Printf("[INFO] Successfully applied virtual machine: %s", name)
+		return resourceKubevirtVirtualMachineRead(resourceData, meta)
+	}
+
+	// The operations are computed against the live virtual machine, which may differ from the state.
+	out, err := cli.GetVirtualMachine(namespace, name)
+	if err != nil {
+		return fmt.Errorf("failed to get virtual machine: %v", err)
+	}
+	ops, err := virtualmachine.AppendPatchOps("", "", resourceData, out, make([]patch.PatchOperation, 0, 0))
 	if err != nil {
-		return fmt.Errorf("failed to create updated virtual machine object: %v", err)
+		return err
+	}
+	data, err := ops.MarshalJSON()
+	if err != nil {
+		return fmt.Errorf("Failed to marshal update operations: %s", err)
 	}
 
-	// Preserve the resource version for update
-	updatedVM.ObjectMeta.ResourceVersion = currentVM.ObjectMeta.ResourceVersion
+	if len(ops) > 0 {
+		out = &kubevirtapiv1.VirtualMachine{}
+		log.Printf("[INFO] Updating virtual machine: %s", ops)
+		if err := cli.UpdateVirtualMachine(namespace, name, out, data); err != nil {
+			return fmt.Errorf("failed to update virtual machine: %v", err)
+		}
+	}
+
+	vm, err := virtualmachine.FromResourceData(resourceData)
+	if err != nil {
+		return err
+	}
+	if secrets := virtualmachine.ExtractCloudInitSecrets(vm, virtualmachine.CloudInitSecretVolumeNames(resourceData.Get("spec").([]interface{}))); len(secrets) > 0 {
+		// The secrets are owned by the virtual machine.
+		if err := applyCloudInitSecrets(cli, out, secrets); err != nil {
+			return err
+		}
+	}
+	if err := deleteCloudInitSecrets(cli, namespace, staleSecrets); err != nil {
+		return err
+	}
 
-	// Update the VM
-	if err := cli.UpdateVirtualMachine(namespace, name, updatedVM, nil); err != nil {
-		return fmt.Errorf("failed to update virtual machine: %v", err)
+	if err := convergeVirtualMachine(cli, namespace, name, resourceData, schema.TimeoutUpdate); err != nil {
+		return err
 	}
 
 	log.Printf("[INFO] Successfully updated virtual machine: %s", name)
@@ -154,3 +234,136 @@ func resourceKubevirtVirtualMachineExists(resourceData *schema.ResourceData, met
 
 	return true, nil
 }
+
+// applyCloudInitSecrets writes the Secrets holding the cloud-init data of the virtual machine
+// volumes with store_as_secret set, owned by the virtual machine so that they're deleted with it.
+func applyCloudInitSecrets(cli client.Client, vm *kubevirtapiv1.VirtualMachine, secrets []*k8sv1.Secret) error {
+	for _, secret := range secrets {
+		secret.OwnerReferences = []metav1.OwnerReference{virtualmachine.CloudInitSecretOwnerReference(vm)}
+
+		log.Printf("[INFO] Applying cloud-init secret %s of virtual machine %s", secret.Name, vm.Name)
+		if err := cli.ApplySecret(secret); err != nil {
+			return fmt.Errorf("failed to apply cloud-init secret %s: %v", secret.Name, err)
+		}
+	}
+	return nil
+}
+
+// readCloudInitSecrets reads the Secrets holding the cloud-init data of the virtual machine
+// volumes with store_as_secret set. The ones that are missing are left out, so that the
+// volumes referencing them show up as drift.
+func readCloudInitSecrets(cli client.Client, vm *kubevirtapiv1.VirtualMachine) ([]*k8sv1.Secret, error) {
+	var secrets []*k8sv1.Secret
+	for _, name := range virtualmachine.CloudInitSecretNames(vm) {
+		secret, err := cli.GetSecret(vm.Namespace, name)
+		if err != nil {
+			if errors.IsNotFound(err) {
+				log.Printf("[WARN] Cloud-init secret %s of virtual machine %s not found", name, vm.Name)
+				continue
+			}
+			return nil, fmt.Errorf("failed to read cloud-init secret %s: %v", name, err)
+		}
+		secrets = append(secrets, secret)
+	}
+	return secrets, nil
+}
+
+// deleteCloudInitSecrets deletes the Secrets that held cloud-init data the virtual machine no longer stores as secrets.
+func deleteCloudInitSecrets(cli client.Client, namespace string, names []string) error {
+	for _, name := range names {
+		log.Printf("[INFO] Deleting cloud-init secret %s", name)
+		if err := cli.DeleteSecret(namespace, name); err != nil && !errors.IsNotFound(err) {
+			return fmt.Errorf("failed to delete cloud-init secret %s: %v", name, err)
+		}
+	}
+	return nil
+}
+
+func cloudInitSecretsByName(secrets []*k8sv1.Secret) map[string]*k8sv1.Secret {
+	result := make(map[string]*k8sv1.Secret, len(secrets))
+	for _, secret := range secrets {
+		result[secret.Name] = secret
+	}
+	return result
+}
+
+// convergeVirtualMachine drives the virtual machine to a changed power state and
+// waits for it to reach the state requested in wait_for, within the given timeout.
+func convergeVirtualMachine(cli client.Client, namespace string, name string, resourceData *schema.ResourceData, timeoutKey string) error {
+	timeout := resourceData.Timeout(timeoutKey)
+
+	if powerState := resourceData.Get("power_state").(string); powerState != "" && resourceData.HasChange("power_state") {
+		if err := setVirtualMachinePowerState(cli, namespace, name, powerState, timeout); err != nil {
+			return err
+		}
+	}
+
+	if waitFor := virtualmachine.ExpandWaitFor(resourceData.Get("wait_for").([]interface{})); waitFor != nil {
+		return waitForVirtualMachine(cli, namespace, name, waitFor, timeout)
+	}
+	return nil
+}
+
+// setVirtualMachinePowerState drives the virtual machine to the requested power state
+// through the lifecycle subresources and waits for its printable status to converge.
+func setVirtualMachinePowerState(cli client.Client, namespace string, name string, powerState string, timeout time.Duration) error {
+	vm, err := cli.GetVirtualMachine(namespace, name)
+	if err != nil {
+		return fmt.Errorf("failed to get virtual machine: %v", err)
+	}
+	status := vm.Status.PrintableStatus
+
+	log.Printf("[INFO] Setting power state of virtual machine %s to %s (current status: %s)", name, powerState, status)
+
+	switch powerState {
+	case virtualmachine.PowerStateRunning:
+		switch status {
+		case kubevirtapiv1.VirtualMachineStatusPaused:
+			err = cli.UnpauseVirtualMachineInstance(namespace, name)
+		case kubevirtapiv1.VirtualMachineStatusStopped, kubevirtapiv1.VirtualMachineStatusStopping:
+			err = cli.StartVirtualMachine(namespace, name)
+		}
+	case virtualmachine.PowerStateStopped:
+		if status != kubevirtapiv1.VirtualMachineStatusStopped && status != kubevirtapiv1.VirtualMachineStatusStopping {
+			err = cli.StopVirtualMachine(namespace, name)
+		}
+	case virtualmachine.PowerStatePaused:
+		if status == kubevirtapiv1.VirtualMachineStatusPaused {
+			break
+		}
+		// Only a running guest can be paused.
+		if status != kubevirtapiv1.VirtualMachineStatusRunning {
+			if err := setVirtualMachinePowerState(cli, namespace, name, virtualmachine.PowerStateRunning, timeout); err != nil {
+				return err
+			}
+		}
+		err = cli.PauseVirtualMachineInstance(namespace, name)
+	default:
+		return fmt.Errorf("unknown power state %q", powerState)
+	}
+	if err != nil {
+		return err
+	}
+
+	return waitForVirtualMachinePrintableStatus(cli, namespace, name, virtualmachine.PowerStatePrintableStatus(powerState), timeout)
+}
+
+func waitForVirtualMachinePrintableStatus(cli client.Client, namespace string, name string, target kubevirtapiv1.VirtualMachinePrintableStatus, timeout time.Duration) error {
+	_, err := cli.WaitForVMCondition(namespace, name, func(vm *kubevirtapiv1.VirtualMachine) (bool, error) {
+		log.Printf("[DEBUG] virtual machine %s is %s, waiting for %s", name, vm.Status.PrintableStatus, target)
+		return vm.Status.PrintableStatus == target, nil
+	}, timeout)
+	return err
+}
+
+func waitForVirtualMachine(cli client.Client, namespace string, name string, waitFor *virtualmachine.WaitFor, timeout time.Duration) error {
+	_, err := cli.WaitForVMCondition(namespace, name, func(vm *kubevirtapiv1.VirtualMachine) (bool, error) {
+		if waitFor.Satisfied(vm) {
+			return true, nil
+		}
+
+		log.Printf("[DEBUG] waiting for virtual machine %s, current status: %s", name, vm.Status.PrintableStatus)
+		return false, nil
+	}, timeout)
+	return err
+}
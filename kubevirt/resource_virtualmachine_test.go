@@ -0,0 +1,508 @@
+package kubevirt
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+	"github.com/nrp-nautilus/terraform-provider-kubevirt/kubevirt/client"
+	"github.com/nrp-nautilus/terraform-provider-kubevirt/kubevirt/client/mock"
+	"github.com/nrp-nautilus/terraform-provider-kubevirt/kubevirt/schema/virtualmachine"
+	k8sv1 "k8s.io/api/core/v1"
+	kubevirtapiv1 "kubevirt.io/api/core/v1"
+
+	"gotest.tools/assert"
+)
+
+func TestSetVirtualMachinePowerState(t *testing.T) {
+	cases := []struct {
+		name          string
+		powerState    string
+		statuses      []kubevirtapiv1.VirtualMachinePrintableStatus
+		expect        func(cli *mock.MockClientMockRecorder)
+		expectedError string
+	}{
+		{
+			name:       "start a stopped virtual machine",
+			powerState: virtualmachine.PowerStateRunning,
+			statuses:   []kubevirtapiv1.VirtualMachinePrintableStatus{kubevirtapiv1.VirtualMachineStatusStopped},
+			expect: func(cli *mock.MockClientMockRecorder) {
+				cli.StartVirtualMachine("default", "test-vm").Return(nil)
+				expectPrintableStatusWait(cli, kubevirtapiv1.VirtualMachineStatusRunning)
+			},
+		},
+		{
+			name:       "unpause a paused virtual machine",
+			powerState: virtualmachine.PowerStateRunning,
+			statuses:   []kubevirtapiv1.VirtualMachinePrintableStatus{kubevirtapiv1.VirtualMachineStatusPaused},
+			expect: func(cli *mock.MockClientMockRecorder) {
+				cli.UnpauseVirtualMachineInstance("default", "test-vm").Return(nil)
+				expectPrintableStatusWait(cli, kubevirtapiv1.VirtualMachineStatusRunning)
+			},
+		},
+		{
+			name:       "running virtual machine",
+			powerState: virtualmachine.PowerStateRunning,
+			statuses:   []kubevirtapiv1.VirtualMachinePrintableStatus{kubevirtapiv1.VirtualMachineStatusRunning},
+			expect: func(cli *mock.MockClientMockRecorder) {
+				expectPrintableStatusWait(cli, kubevirtapiv1.VirtualMachineStatusRunning)
+			},
+		},
+		{
+			name:       "stop a running virtual machine",
+			powerState: virtualmachine.PowerStateStopped,
+			statuses:   []kubevirtapiv1.VirtualMachinePrintableStatus{kubevirtapiv1.VirtualMachineStatusRunning},
+			expect: func(cli *mock.MockClientMockRecorder) {
+				cli.StopVirtualMachine("default", "test-vm").Return(nil)
+				expectPrintableStatusWait(cli, kubevirtapiv1.VirtualMachineStatusStopped)
+			},
+		},
+		{
+			name:       "stopping virtual machine",
+			powerState: virtualmachine.PowerStateStopped,
+			statuses:   []kubevirtapiv1.VirtualMachinePrintableStatus{kubevirtapiv1.VirtualMachineStatusStopping},
+			expect: func(cli *mock.MockClientMockRecorder) {
+				expectPrintableStatusWait(cli, kubevirtapiv1.VirtualMachineStatusStopped)
+			},
+		},
+		{
+			name:       "pause a running virtual machine",
+			powerState: virtualmachine.PowerStatePaused,
+			statuses:   []kubevirtapiv1.VirtualMachinePrintableStatus{kubevirtapiv1.VirtualMachineStatusRunning},
+			expect: func(cli *mock.MockClientMockRecorder) {
+				cli.PauseVirtualMachineInstance("default", "test-vm").Return(nil)
+				expectPrintableStatusWait(cli, kubevirtapiv1.VirtualMachineStatusPaused)
+			},
+		},
+		{
+			name:       "pause a stopped virtual machine",
+			powerState: virtualmachine.PowerStatePaused,
+			statuses: []kubevirtapiv1.VirtualMachinePrintableStatus{
+				kubevirtapiv1.VirtualMachineStatusStopped,
+				kubevirtapiv1.VirtualMachineStatusStopped,
+			},
+			expect: func(cli *mock.MockClientMockRecorder) {
+				gomock.InOrder(
+					cli.StartVirtualMachine("default", "test-vm").Return(nil),
+					expectPrintableStatusWait(cli, kubevirtapiv1.VirtualMachineStatusRunning),
+					cli.PauseVirtualMachineInstance("default", "test-vm").Return(nil),
+					expectPrintableStatusWait(cli, kubevirtapiv1.VirtualMachineStatusPaused),
+				)
+			},
+		},
+		{
+			name:       "paused virtual machine",
+			powerState: virtualmachine.PowerStatePaused,
+			statuses:   []kubevirtapiv1.VirtualMachinePrintableStatus{kubevirtapiv1.VirtualMachineStatusPaused},
+			expect: func(cli *mock.MockClientMockRecorder) {
+				expectPrintableStatusWait(cli, kubevirtapiv1.VirtualMachineStatusPaused)
+			},
+		},
+		{
+			name:       "failing subresource",
+			powerState: virtualmachine.PowerStateStopped,
+			statuses:   []kubevirtapiv1.VirtualMachinePrintableStatus{kubevirtapiv1.VirtualMachineStatusRunning},
+			expect: func(cli *mock.MockClientMockRecorder) {
+				cli.StopVirtualMachine("default", "test-vm").Return(fmt.Errorf("forbidden"))
+			},
+			expectedError: "forbidden",
+		},
+		{
+			name:          "unknown power state",
+			powerState:    "hibernated",
+			statuses:      []kubevirtapiv1.VirtualMachinePrintableStatus{kubevirtapiv1.VirtualMachineStatusRunning},
+			expectedError: "unknown power state \"hibernated\"",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			cli := mock.NewMockClient(gomock.NewController(t))
+			for _, status := range tc.statuses {
+				cli.EXPECT().GetVirtualMachine("default", "test-vm").Return(virtualMachineWithStatus(status), nil)
+			}
+			if tc.expect != nil {
+				tc.expect(cli.EXPECT())
+			}
+
+			err := setVirtualMachinePowerState(cli, "default", "test-vm", tc.powerState, time.Minute)
+
+			if tc.expectedError == "" {
+				assert.NilError(t, err)
+			} else {
+				assert.Error(t, err, tc.expectedError)
+			}
+		})
+	}
+}
+
+func TestConvergeVirtualMachine(t *testing.T) {
+	cases := []struct {
+		name   string
+		oldRaw map[string]interface{}
+		newRaw map[string]interface{}
+		expect func(cli *mock.MockClientMockRecorder)
+	}{
+		{
+			name:   "nothing to converge",
+			newRaw: rawVirtualMachine(nil),
+		},
+		{
+			name:   "power state set",
+			newRaw: rawVirtualMachine(map[string]interface{}{"power_state": virtualmachine.PowerStateStopped}),
+			expect: func(cli *mock.MockClientMockRecorder) {
+				cli.GetVirtualMachine("default", "test-vm").Return(virtualMachineWithStatus(kubevirtapiv1.VirtualMachineStatusRunning), nil)
+				cli.StopVirtualMachine("default", "test-vm").Return(nil)
+				expectPrintableStatusWait(cli, kubevirtapiv1.VirtualMachineStatusStopped)
+			},
+		},
+		{
+			name:   "power state unchanged",
+			oldRaw: rawVirtualMachine(map[string]interface{}{"power_state": virtualmachine.PowerStateStopped}),
+			newRaw: rawVirtualMachine(map[string]interface{}{"power_state": virtualmachine.PowerStateStopped}),
+		},
+		{
+			name: "wait for",
+			newRaw: rawVirtualMachine(map[string]interface{}{
+				"wait_for": []interface{}{
+					map[string]interface{}{"printable_status": "Running", "conditions": []interface{}{"Ready"}},
+				},
+			}),
+			expect: func(cli *mock.MockClientMockRecorder) {
+				cli.WaitForVMCondition("default", "test-vm", gomock.Any(), gomock.Any()).DoAndReturn(
+					func(namespace string, name string, condition client.VirtualMachineConditionFunc, timeout time.Duration) (*kubevirtapiv1.VirtualMachine, error) {
+						vm := virtualMachineWithStatus(kubevirtapiv1.VirtualMachineStatusRunning)
+						done, err := condition(vm)
+						assert.NilError(t, err)
+						assert.Assert(t, !done, "the Ready condition isn't reported yet")
+
+						vm.Status.Conditions = []kubevirtapiv1.VirtualMachineCondition{{Type: kubevirtapiv1.VirtualMachineReady, Status: "True"}}
+						done, err = condition(vm)
+						assert.NilError(t, err)
+						assert.Assert(t, done)
+						return vm, nil
+					})
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			cli := mock.NewMockClient(gomock.NewController(t))
+			if tc.expect != nil {
+				tc.expect(cli.EXPECT())
+			}
+
+			resourceData := virtualMachineResourceData(t, tc.oldRaw, tc.newRaw)
+			resourceData.SetId("default/test-vm")
+
+			assert.NilError(t, convergeVirtualMachine(cli, "default", "test-vm", resourceData, schema.TimeoutCreate))
+		})
+	}
+}
+
+// expectPrintableStatusWait expects a wait for the virtual machine to report the given
+// printable status, and checks the awaited condition only holds once it does.
+func expectPrintableStatusWait(cli *mock.MockClientMockRecorder, target kubevirtapiv1.VirtualMachinePrintableStatus) *gomock.Call {
+	return cli.WaitForVMCondition("default", "test-vm", gomock.Any(), gomock.Any()).DoAndReturn(
+		func(namespace string, name string, condition client.VirtualMachineConditionFunc, timeout time.Duration) (*kubevirtapiv1.VirtualMachine, error) {
+			if done, err := condition(virtualMachineWithStatus(kubevirtapiv1.VirtualMachineStatusStarting)); done || err != nil {
+				return nil, fmt.Errorf("condition held before the virtual machine was %s", target)
+			}
+			vm := virtualMachineWithStatus(target)
+			if done, err := condition(vm); !done || err != nil {
+				return nil, fmt.Errorf("condition didn't hold once the virtual machine was %s", target)
+			}
+			return vm, nil
+		})
+}
+
+func virtualMachineWithStatus(status kubevirtapiv1.VirtualMachinePrintableStatus) *kubevirtapiv1.VirtualMachine {
+	vm := &kubevirtapiv1.VirtualMachine{}
+	vm.Name = "test-vm"
+	vm.Namespace = "default"
+	vm.Status.PrintableStatus = status
+	return vm
+}
+
+// rawVirtualMachine returns the configuration of a kubevirt_virtual_machine, with the given top level arguments.
+func rawVirtualMachine(raw map[string]interface{}) map[string]interface{} {
+	config := map[string]interface{}{
+		"metadata": []interface{}{
+			map[string]interface{}{
+				"name":      "test-vm",
+				"namespace": "default",
+			},
+		},
+		"spec": []interface{}{
+			map[string]interface{}{
+				"run_strategy": "Manual",
+			},
+		},
+	}
+	for k, v := range raw {
+		config[k] = v
+	}
+	return config
+}
+
+// virtualMachineResourceData returns the resource data of a kubevirt_virtual_machine going
+// from the oldRaw configuration, when set, to the newRaw one. Only existing resources have an ID.
+func virtualMachineResourceData(t *testing.T, oldRaw, newRaw map[string]interface{}) *schema.ResourceData {
+	resource := resourceKubevirtVirtualMachine()
+
+	var state *terraform.InstanceState
+	if oldRaw != nil {
+		old := schema.TestResourceDataRaw(t, resource.Schema, oldRaw)
+		old.SetId("default/test-vm")
+		state = old.State()
+	}
+
+	diff, err := resource.Diff(context.Background(), state, terraform.NewResourceConfigRaw(newRaw), nil)
+	assert.NilError(t, err)
+	resourceData, err := schema.InternalMap(resource.Schema).Data(state, diff)
+	assert.NilError(t, err)
+
+	return resourceData
+}
+
+func TestResourceKubevirtVirtualMachineCreate(t *testing.T) {
+	cases := []struct {
+		name            string
+		serverSideApply bool
+		secretErr       error
+		expectedError   string
+	}{
+		{
+			name: "create",
+		},
+		{
+			name:            "server-side apply",
+			serverSideApply: true,
+		},
+		{
+			name:          "failing secret",
+			secretErr:     fmt.Errorf("forbidden"),
+			expectedError: "failed to apply cloud-init secret test-vm-cloudinitdisk-cloud-init: forbidden",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			cli := mock.NewMockClient(gomock.NewController(t))
+			cli.EXPECT().ServerSideApply().Return(tc.serverSideApply).AnyTimes()
+
+			var created *kubevirtapiv1.VirtualMachine
+			createVirtualMachine := func(vm *kubevirtapiv1.VirtualMachine) error {
+				// The cloud-init data is only referenced by the virtual machine.
+				cloudInit := vm.Spec.Template.Spec.Volumes[0].CloudInitNoCloud
+				assert.Equal(t, "", cloudInit.UserData)
+				assert.Equal(t, "test-vm-cloudinitdisk-cloud-init", cloudInit.UserDataSecretRef.Name)
+
+				vm.UID = "6a1a24a1-4061-4607-8bf4-a3963d0c5895"
+				created = vm.DeepCopy()
+				return nil
+			}
+			var createCall *gomock.Call
+			if tc.serverSideApply {
+				createCall = cli.EXPECT().ApplyVirtualMachine(gomock.Any()).DoAndReturn(createVirtualMachine)
+			} else {
+				createCall = cli.EXPECT().CreateVirtualMachine(gomock.Any()).DoAndReturn(createVirtualMachine)
+			}
+			cli.EXPECT().ApplySecret(gomock.Any()).After(createCall).DoAndReturn(func(secret *k8sv1.Secret) error {
+				assert.Equal(t, "test-vm-cloudinitdisk-cloud-init", secret.Name)
+				assert.Equal(t, "default", secret.Namespace)
+				assert.Equal(t, "#cloud-config\n", string(secret.Data["userdata"]))
+				assert.Equal(t, 1, len(secret.OwnerReferences))
+				assert.Equal(t, created.UID, secret.OwnerReferences[0].UID)
+				if tc.secretErr != nil {
+					return tc.secretErr
+				}
+
+				cli.EXPECT().GetVirtualMachine("default", "test-vm").Return(created, nil)
+				cli.EXPECT().GetSecret("default", "test-vm-cloudinitdisk-cloud-init").Return(secret, nil)
+				return nil
+			})
+
+			resourceData := virtualMachineResourceData(t, nil, rawCloudInitVirtualMachine("#cloud-config\n", true))
+
+			err := resourceKubevirtVirtualMachineCreate(resourceData, cli)
+
+			// The virtual machine is tracked even when its secret can't be written, so that it isn't orphaned.
+			assert.Equal(t, "default/test-vm", resourceData.Id())
+			if tc.expectedError != "" {
+				assert.Error(t, err, tc.expectedError)
+				return
+			}
+			assert.NilError(t, err)
+			assert.Equal(t, "#cloud-config\n", resourceData.Get(cloudInitNoCloudKey+"user_data"))
+			assert.Equal(t, true, resourceData.Get(cloudInitNoCloudKey+"store_as_secret"))
+		})
+	}
+}
+
+func TestResourceKubevirtVirtualMachineUpdate(t *testing.T) {
+	cases := []struct {
+		name            string
+		serverSideApply bool
+		oldRaw          map[string]interface{}
+		newRaw          map[string]interface{}
+		userData        string
+		expect          func(cli *mock.MockClientMockRecorder, vm *kubevirtapiv1.VirtualMachine)
+	}{
+		{
+			name:     "user data stored as secret changed",
+			oldRaw:   rawCloudInitVirtualMachine("#cloud-config\n", true),
+			newRaw:   rawCloudInitVirtualMachine("#cloud-config\nhostname: test-vm\n", true),
+			userData: "#cloud-config\nhostname: test-vm\n",
+			expect: func(cli *mock.MockClientMockRecorder, vm *kubevirtapiv1.VirtualMachine) {
+				// Only the secret changes, so the virtual machine is read but not patched.
+				cli.GetVirtualMachine("default", "test-vm").Return(vm, nil)
+				cli.ApplySecret(gomock.Any()).DoAndReturn(func(secret *k8sv1.Secret) error {
+					assert.Equal(t, "#cloud-config\nhostname: test-vm\n", string(secret.Data["userdata"]))
+					assert.Equal(t, vm.UID, secret.OwnerReferences[0].UID)
+					cli.GetVirtualMachine("default", "test-vm").Return(vm, nil)
+					cli.GetSecret("default", secret.Name).Return(secret, nil)
+					return nil
+				})
+			},
+		},
+		{
+			name:     "user data no longer stored as secret",
+			oldRaw:   rawCloudInitVirtualMachine("#cloud-config\n", true),
+			newRaw:   rawCloudInitVirtualMachine("#cloud-config\n", false),
+			userData: "#cloud-config\n",
+			expect: func(cli *mock.MockClientMockRecorder, vm *kubevirtapiv1.VirtualMachine) {
+				gomock.InOrder(
+					cli.GetVirtualMachine("default", "test-vm").Return(vm, nil),
+					cli.UpdateVirtualMachine("default", "test-vm", gomock.Any(), gomock.Any()).DoAndReturn(
+						func(namespace string, name string, out *kubevirtapiv1.VirtualMachine, data []byte) error {
+							assert.Assert(t, strings.Contains(string(data), `"path":"/spec/template/spec/volumes/0/cloudInitNoCloud/userData"`), "unexpected patch %s", data)
+							return nil
+						}),
+					cli.DeleteSecret("default", "test-vm-cloudinitdisk-cloud-init").Return(nil),
+					cli.GetVirtualMachine("default", "test-vm").Return(inlineCloudInit(vm, "#cloud-config\n"), nil),
+				)
+			},
+		},
+		{
+			name:            "server-side apply of user data no longer stored as secret",
+			serverSideApply: true,
+			oldRaw:          rawCloudInitVirtualMachine("#cloud-config\n", true),
+			newRaw:          rawCloudInitVirtualMachine("#cloud-config\n", false),
+			userData:        "#cloud-config\n",
+			expect: func(cli *mock.MockClientMockRecorder, vm *kubevirtapiv1.VirtualMachine) {
+				gomock.InOrder(
+					cli.ApplyVirtualMachine(gomock.Any()).DoAndReturn(func(applied *kubevirtapiv1.VirtualMachine) error {
+						cloudInit := applied.Spec.Template.Spec.Volumes[0].CloudInitNoCloud
+						assert.Equal(t, "#cloud-config\n", cloudInit.UserData)
+						assert.Assert(t, cloudInit.UserDataSecretRef == nil)
+						return nil
+					}),
+					cli.DeleteSecret("default", "test-vm-cloudinitdisk-cloud-init").Return(nil),
+					cli.GetVirtualMachine("default", "test-vm").Return(inlineCloudInit(vm, "#cloud-config\n"), nil),
+				)
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			cli := mock.NewMockClient(gomock.NewController(t))
+			cli.EXPECT().ServerSideApply().Return(tc.serverSideApply).AnyTimes()
+
+			resourceData := virtualMachineResourceData(t, tc.oldRaw, tc.newRaw)
+			// The server holds the virtual machine of the old state.
+			vm, err := virtualmachine.FromResourceData(schema.TestResourceDataRaw(t, resourceKubevirtVirtualMachine().Schema, tc.oldRaw))
+			assert.NilError(t, err)
+			vm.UID = "6a1a24a1-4061-4607-8bf4-a3963d0c5895"
+			virtualmachine.ExtractCloudInitSecrets(vm, virtualmachine.CloudInitSecretVolumeNames(tc.oldRaw["spec"].([]interface{})))
+			tc.expect(cli.EXPECT(), vm)
+
+			assert.NilError(t, resourceKubevirtVirtualMachineUpdate(resourceData, cli))
+
+			assert.Equal(t, tc.userData, resourceData.Get(cloudInitNoCloudKey+"user_data"))
+		})
+	}
+}
+
+func TestResourceKubevirtVirtualMachineCloudInitDiff(t *testing.T) {
+	resource := resourceKubevirtVirtualMachine()
+
+	// Oversized inline user data fails at plan time, unless it's stored as a secret.
+	_, err := resource.Diff(context.Background(), nil, terraform.NewResourceConfigRaw(rawCloudInitVirtualMachine(strings.Repeat("#", 2049), false)), nil)
+	assert.ErrorContains(t, err, `volume "cloudinitdisk": cloud_init_no_cloud: inline user data exceeds 2048 bytes, set store_as_secret or store it in a Secret and reference it with user_data_secret_ref`)
+
+	_, err = resource.Diff(context.Background(), nil, terraform.NewResourceConfigRaw(rawCloudInitVirtualMachine(strings.Repeat("#", 2049), true)), nil)
+	assert.NilError(t, err)
+}
+
+// cloudInitNoCloudKey is the key of the cloud-init volume of rawCloudInitVirtualMachine.
+const cloudInitNoCloudKey = "spec.0.template.0.spec.0.volume.0.volume_source.0.cloud_init_no_cloud.0."
+
+// rawCloudInitVirtualMachine returns the configuration of a kubevirt_virtual_machine
+// booting with the given cloud-init user data.
+func rawCloudInitVirtualMachine(userData string, storeAsSecret bool) map[string]interface{} {
+	raw := rawVirtualMachine(nil)
+	raw["spec"].([]interface{})[0].(map[string]interface{})["template"] = []interface{}{
+		map[string]interface{}{
+			"spec": []interface{}{
+				map[string]interface{}{
+					"domain": []interface{}{
+						map[string]interface{}{
+							"resources": []interface{}{
+								map[string]interface{}{
+									"requests": map[string]interface{}{"memory": "1Gi"},
+								},
+							},
+							"devices": []interface{}{
+								map[string]interface{}{
+									"disk": []interface{}{
+										map[string]interface{}{
+											"name": "cloudinitdisk",
+											"disk_device": []interface{}{
+												map[string]interface{}{
+													"disk": []interface{}{
+														map[string]interface{}{"bus": "virtio"},
+													},
+												},
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+					"volume": []interface{}{
+						map[string]interface{}{
+							"name": "cloudinitdisk",
+							"volume_source": []interface{}{
+								map[string]interface{}{
+									"cloud_init_no_cloud": []interface{}{
+										map[string]interface{}{
+											"user_data":       userData,
+											"store_as_secret": storeAsSecret,
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	return raw
+}
+
+// inlineCloudInit returns a copy of the virtual machine with the cloud-init user data inlined.
+func inlineCloudInit(vm *kubevirtapiv1.VirtualMachine, userData string) *kubevirtapiv1.VirtualMachine {
+	out := vm.DeepCopy()
+	out.Spec.Template.Spec.Volumes[0].CloudInitNoCloud = &kubevirtapiv1.CloudInitNoCloudSource{UserData: userData}
+	return out
+}
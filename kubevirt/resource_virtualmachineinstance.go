@@ -0,0 +1,191 @@
+package kubevirt
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/customdiff"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/nrp-nautilus/terraform-provider-kubevirt/kubevirt/client"
+	"github.com/nrp-nautilus/terraform-provider-kubevirt/kubevirt/schema/virtualmachineinstance"
+	"github.com/nrp-nautilus/terraform-provider-kubevirt/kubevirt/utils"
+	"github.com/nrp-nautilus/terraform-provider-kubevirt/kubevirt/utils/patch"
+	"k8s.io/apimachinery/pkg/api/errors"
+	kubevirtapiv1 "kubevirt.io/api/core/v1"
+)
+
+func resourceKubevirtVirtualMachineInstance() *schema.Resource {
+	return &schema.Resource{
+		Create: resourceKubevirtVirtualMachineInstanceCreate,
+		Read:   resourceKubevirtVirtualMachineInstanceRead,
+		Update: resourceKubevirtVirtualMachineInstanceUpdate,
+		Delete: resourceKubevirtVirtualMachineInstanceDelete,
+		Exists: resourceKubevirtVirtualMachineInstanceExists,
+		Importer: &schema.ResourceImporter{
+			State: schema.ImportStatePassthrough,
+		},
+		Timeouts: &schema.ResourceTimeout{
+			Delete: schema.DefaultTimeout(5 * time.Minute),
+		},
+		CustomizeDiff: customdiff.All(
+			virtualmachineinstance.ForceNewOnSpecChange,
+			virtualmachineinstance.ValidateSpecDiff("spec"),
+		),
+		Schema: virtualmachineinstance.VirtualMachineInstanceFields(),
+	}
+}
+
+func resourceKubevirtVirtualMachineInstanceCreate(resourceData *schema.ResourceData, meta interface{}) error {
+	cli := (meta).(client.Client)
+
+	if len(virtualmachineinstance.CloudInitSecretVolumeNames(resourceData.Get("spec").([]interface{}))) > 0 {
+		return fmt.Errorf("store_as_secret is only supported by kubevirt_virtual_machine")
+	}
+	vmi, err := virtualmachineinstance.FromResourceData(resourceData)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("[INFO] Creating new virtual machine instance: %#v", vmi)
+	if cli.ServerSideApply() {
+		err = cli.ApplyVirtualMachineInstance(vmi)
+	} else {
+		err = cli.CreateVirtualMachineInstance(vmi)
+	}
+	if err != nil {
+		return err
+	}
+	log.Printf("[INFO] Submitted new virtual machine instance: %#v", vmi)
+	if err := virtualMachineInstanceToResourceData(vmi, resourceData); err != nil {
+		return err
+	}
+	resourceData.SetId(utils.BuildId(vmi.ObjectMeta))
+
+	return resourceKubevirtVirtualMachineInstanceRead(resourceData, meta)
+}
+
+func resourceKubevirtVirtualMachineInstanceRead(resourceData *schema.ResourceData, meta interface{}) error {
+	cli := (meta).(client.Client)
+
+	namespace, name, err := utils.IdParts(resourceData.Id())
+	if err != nil {
+		return err
+	}
+
+	log.Printf("[INFO] Reading virtual machine instance %s", name)
+
+	vmi, err := cli.GetVirtualMachineInstance(namespace, name)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			log.Printf("[WARN] Virtual machine instance %s not found, removing from state", name)
+			resourceData.SetId("")
+			return nil
+		}
+		return fmt.Errorf("failed to read virtual machine instance: %v", err)
+	}
+	log.Printf("[INFO] Received virtual machine instance: %#v", vmi)
+
+	return virtualMachineInstanceToResourceData(vmi, resourceData)
+}
+
+// virtualMachineInstanceToResourceData stores the instance, leaving out the values the API
+// server defaulted unless the resource data already sets them.
+func virtualMachineInstanceToResourceData(vmi *kubevirtapiv1.VirtualMachineInstance, resourceData *schema.ResourceData) error {
+	configured, err := virtualmachineinstance.FromResourceData(resourceData)
+	if err != nil {
+		return err
+	}
+	virtualmachineinstance.OmitServerDefaults(&vmi.Spec, configured.Spec)
+
+	return virtualmachineinstance.ToResourceData(*vmi, resourceData)
+}
+
+func resourceKubevirtVirtualMachineInstanceUpdate(resourceData *schema.ResourceData, meta interface{}) error {
+	cli := (meta).(client.Client)
+
+	namespace, name, err := utils.IdParts(resourceData.Id())
+	if err != nil {
+		return err
+	}
+
+	if cli.ServerSideApply() {
+		vmi, err := virtualmachineinstance.FromResourceData(resourceData)
+		if err != nil {
+			return err
+		}
+
+		log.Printf("[INFO] Applying virtual machine instance: %#v", vmi)
+		if err := cli.ApplyVirtualMachineInstance(vmi); err != nil {
+			return err
+		}
+
+		log.Printf("[INFO] Submitted applied virtual machine instance: %#v", vmi)
+		return resourceKubevirtVirtualMachineInstanceRead(resourceData, meta)
+	}
+
+	// Only the metadata can change, any change to the spec replaces the virtual machine instance.
+	ops := virtualmachineinstance.AppendPatchOps("", "", resourceData, make([]patch.PatchOperation, 0, 0))
+	if len(ops) > 0 {
+		data, err := ops.MarshalJSON()
+		if err != nil {
+			return fmt.Errorf("Failed to marshal update operations: %s", err)
+		}
+
+		log.Printf("[INFO] Updating virtual machine instance: %s", ops)
+		out := &kubevirtapiv1.VirtualMachineInstance{}
+		if err := cli.UpdateVirtualMachineInstance(namespace, name, out, data); err != nil {
+			return err
+		}
+
+		log.Printf("[INFO] Submitted updated virtual machine instance: %#v", out)
+	}
+
+	return resourceKubevirtVirtualMachineInstanceRead(resourceData, meta)
+}
+
+func resourceKubevirtVirtualMachineInstanceDelete(resourceData *schema.ResourceData, meta interface{}) error {
+	cli := (meta).(client.Client)
+
+	namespace, name, err := utils.IdParts(resourceData.Id())
+	if err != nil {
+		return err
+	}
+
+	log.Printf("[INFO] Deleting virtual machine instance: %s", name)
+	if err := cli.DeleteVirtualMachineInstance(namespace, name); err != nil {
+		if errors.IsNotFound(err) {
+			log.Printf("[WARN] Virtual machine instance %s not found during deletion", name)
+			resourceData.SetId("")
+			return nil
+		}
+		return fmt.Errorf("failed to delete virtual machine instance: %v", err)
+	}
+
+	if err := cli.WaitForDeletion(client.VirtualMachineInstanceResource, namespace, name, resourceData.Timeout(schema.TimeoutDelete)); err != nil {
+		return err
+	}
+
+	log.Printf("[INFO] virtual machine instance %s deleted", name)
+
+	resourceData.SetId("")
+	return nil
+}
+
+func resourceKubevirtVirtualMachineInstanceExists(resourceData *schema.ResourceData, meta interface{}) (bool, error) {
+	cli := (meta).(client.Client)
+
+	namespace, name, err := utils.IdParts(resourceData.Id())
+	if err != nil {
+		return false, err
+	}
+
+	log.Printf("[INFO] Checking virtual machine instance %s", name)
+	if _, err := cli.GetVirtualMachineInstance(namespace, name); err != nil {
+		if errors.IsNotFound(err) {
+			return false, nil
+		}
+		return true, fmt.Errorf("failed to check virtual machine instance existence: %v", err)
+	}
+	return true, nil
+}
@@ -7,6 +7,8 @@ import (
 	"github.com/nrp-nautilus/terraform-provider-kubevirt/kubevirt/test_utils/flatten_utils"
 	"gotest.tools/assert"
 
+	k8sv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	cdiv1 "kubevirt.io/containerized-data-importer-api/pkg/apis/core/v1beta1"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
@@ -48,6 +50,61 @@ func TestExpandDataVolumeTemplates(t *testing.T) {
 			},
 			expectedErrorMessage: "quantities must match the regular expression '^([+-]?[0-9.]+)([eEinumkKMGTP]*[-+]?[0-9]*)$'",
 		},
+		{
+			name:        "storage instead of pvc",
+			shouldError: false,
+			modifier: func(input interface{}) {
+				spec := test_utils.GetDataVolumeSpec(input)
+				delete(spec, "pvc")
+				spec["storage"] = []interface{}{
+					map[string]interface{}{
+						"access_modes": schema.NewSet(schema.HashString, []interface{}{}),
+						"resources": []interface{}{
+							map[string]interface{}{
+								"requests": map[string]interface{}{
+									"storage": "10Gi",
+								},
+							},
+						},
+						"storage_class_name": "standard",
+						"volume_mode":        "Block",
+					},
+				}
+			},
+			expectedOutput: []cdiv1.DataVolume{
+				(func() cdiv1.DataVolume {
+					output := expand_utils.GetBaseOutputForDataVolume()
+					output.Spec.PVC = nil
+					output.Spec.Storage = &cdiv1.StorageSpec{
+						Resources: k8sv1.ResourceRequirements{
+							Requests: k8sv1.ResourceList{
+								"storage": resource.MustParse("10Gi"),
+							},
+						},
+						StorageClassName: (func() *string { str := "standard"; return &str })(),
+						VolumeMode:       (func() *k8sv1.PersistentVolumeMode { mode := k8sv1.PersistentVolumeBlock; return &mode })(),
+					}
+					return output
+				})(),
+			},
+		},
+		{
+			name:        "both pvc and storage",
+			shouldError: true,
+			modifier: func(input interface{}) {
+				spec := test_utils.GetDataVolumeSpec(input)
+				spec["storage"] = spec["pvc"]
+			},
+			expectedErrorMessage: "exactly one of pvc or storage must be set in the data volume spec",
+		},
+		{
+			name:        "neither pvc nor storage",
+			shouldError: true,
+			modifier: func(input interface{}) {
+				delete(test_utils.GetDataVolumeSpec(input), "pvc")
+			},
+			expectedErrorMessage: "exactly one of pvc or storage must be set in the data volume spec",
+		},
 	}
 
 	for _, tc := range cases {
@@ -63,7 +120,7 @@ func TestExpandDataVolumeTemplates(t *testing.T) {
 				assert.Equal(t, tc.expectedErrorMessage, err.Error())
 			} else {
 				assert.NilError(t, err)
-				assert.DeepEqual(t, output[0], baseOutput)
+				assert.DeepEqual(t, output[0], tc.expectedOutput[0])
 			}
 		})
 	}
@@ -4,13 +4,22 @@ import (
 	"fmt"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"github.com/nrp-nautilus/terraform-provider-kubevirt/kubevirt/utils"
 	cdiv1 "kubevirt.io/containerized-data-importer-api/pkg/apis/core/v1beta1"
 )
 
 func dataVolumeSourceFields() map[string]*schema.Schema {
 	return map[string]*schema.Schema{
-		"http": dataVolumeSourceHTTPSchema(),
-		"pvc":  dataVolumeSourcePVCSchema(),
+		"http":     dataVolumeSourceHTTPSchema(),
+		"s3":       dataVolumeSourceS3Schema(),
+		"registry": dataVolumeSourceRegistrySchema(),
+		"pvc":      dataVolumeSourcePVCSchema(),
+		"upload":   dataVolumeSourceUploadSchema(),
+		"blank":    dataVolumeSourceBlankSchema(),
+		"imageio":  dataVolumeSourceImageIOSchema(),
+		"vddk":     dataVolumeSourceVDDKSchema(),
+		"snapshot": dataVolumeSourceSnapshotSchema(),
 	}
 }
 
@@ -46,6 +55,18 @@ func dataVolumeSourceHTTPFields() map[string]*schema.Schema {
 			Description: "Cert_config_map provides a reference to the Registry certs.",
 			Optional:    true,
 		},
+		"extra_headers": {
+			Type:        schema.TypeList,
+			Description: "Extra_headers is a list of strings containing extra headers to include with HTTP transfer requests.",
+			Optional:    true,
+			Elem:        &schema.Schema{Type: schema.TypeString},
+		},
+		"secret_extra_headers": {
+			Type:        schema.TypeList,
+			Description: "Secret_extra_headers is a list of Secret references, each containing an extra HTTP header that may include sensitive information.",
+			Optional:    true,
+			Elem:        &schema.Schema{Type: schema.TypeString},
+		},
 	}
 }
 
@@ -64,6 +85,90 @@ func dataVolumeSourceHTTPSchema() *schema.Schema {
 
 }
 
+func dataVolumeSourceS3Fields() map[string]*schema.Schema {
+	return map[string]*schema.Schema{
+		"url": {
+			Type:        schema.TypeString,
+			Description: "url is the URL of the S3 source.",
+			Required:    true,
+		},
+		"secret_ref": {
+			Type:        schema.TypeString,
+			Description: "Secret_ref provides the secret reference needed to access the S3 source.",
+			Optional:    true,
+		},
+		"cert_config_map": {
+			Type:        schema.TypeString,
+			Description: "Cert_config_map is a configmap reference, containing a Certificate Authority(CA) public key, and a base64 encoded pem certificate.",
+			Optional:    true,
+		},
+	}
+}
+
+func dataVolumeSourceS3Schema() *schema.Schema {
+	fields := dataVolumeSourceS3Fields()
+
+	return &schema.Schema{
+		Type:        schema.TypeList,
+		Description: "DataVolumeSourceS3 provides the parameters to create a Data Volume from an S3 source.",
+		Optional:    true,
+		MaxItems:    1,
+		Elem: &schema.Resource{
+			Schema: fields,
+		},
+	}
+
+}
+
+func dataVolumeSourceRegistryFields() map[string]*schema.Schema {
+	return map[string]*schema.Schema{
+		"url": {
+			Type:        schema.TypeString,
+			Description: "url is the url of the registry source (starting with the scheme: docker, oci-archive).",
+			Optional:    true,
+		},
+		"image_stream": {
+			Type:        schema.TypeString,
+			Description: "Image_stream is the name of image stream for import.",
+			Optional:    true,
+		},
+		"pull_method": {
+			Type:        schema.TypeString,
+			Description: "Pull_method can be either \"pod\" (default import), or \"node\" (node docker cache based import).",
+			Optional:    true,
+			ValidateFunc: validation.StringInSlice([]string{
+				string(cdiv1.RegistryPullPod),
+				string(cdiv1.RegistryPullNode),
+			}, false),
+		},
+		"secret_ref": {
+			Type:        schema.TypeString,
+			Description: "Secret_ref provides the secret reference needed to access the Registry source.",
+			Optional:    true,
+		},
+		"cert_config_map": {
+			Type:        schema.TypeString,
+			Description: "Cert_config_map provides a reference to the Registry certs.",
+			Optional:    true,
+		},
+	}
+}
+
+func dataVolumeSourceRegistrySchema() *schema.Schema {
+	fields := dataVolumeSourceRegistryFields()
+
+	return &schema.Schema{
+		Type:        schema.TypeList,
+		Description: "DataVolumeSourceRegistry provides the parameters to create a Data Volume from an registry source. Exactly one of url or image_stream has to be set.",
+		Optional:    true,
+		MaxItems:    1,
+		Elem: &schema.Resource{
+			Schema: fields,
+		},
+	}
+
+}
+
 func dataVolumeSourcePVCFields() map[string]*schema.Schema {
 	return map[string]*schema.Schema{
 		"namespace": {
@@ -94,21 +199,197 @@ func dataVolumeSourcePVCSchema() *schema.Schema {
 
 }
 
+func dataVolumeSourceUploadSchema() *schema.Schema {
+	return &schema.Schema{
+		Type:        schema.TypeList,
+		Description: "DataVolumeSourceUpload provides the parameters to create a Data Volume by uploading the source.",
+		Optional:    true,
+		MaxItems:    1,
+		Elem: &schema.Resource{
+			Schema: map[string]*schema.Schema{},
+		},
+	}
+
+}
+
+func dataVolumeSourceBlankSchema() *schema.Schema {
+	return &schema.Schema{
+		Type:        schema.TypeList,
+		Description: "DataVolumeBlankImage provides the parameters to create a new raw blank image for the PVC.",
+		Optional:    true,
+		MaxItems:    1,
+		Elem: &schema.Resource{
+			Schema: map[string]*schema.Schema{},
+		},
+	}
+
+}
+
+func dataVolumeSourceImageIOFields() map[string]*schema.Schema {
+	return map[string]*schema.Schema{
+		"url": {
+			Type:        schema.TypeString,
+			Description: "url is the URL of the ovirt-engine.",
+			Required:    true,
+		},
+		"disk_id": {
+			Type:        schema.TypeString,
+			Description: "Disk_id provides id of a disk to be imported.",
+			Required:    true,
+		},
+		"secret_ref": {
+			Type:        schema.TypeString,
+			Description: "Secret_ref provides the secret reference needed to access the ovirt-engine.",
+			Optional:    true,
+		},
+		"cert_config_map": {
+			Type:        schema.TypeString,
+			Description: "Cert_config_map provides a reference to the CA cert.",
+			Optional:    true,
+		},
+	}
+}
+
+func dataVolumeSourceImageIOSchema() *schema.Schema {
+	fields := dataVolumeSourceImageIOFields()
+
+	return &schema.Schema{
+		Type:        schema.TypeList,
+		Description: "DataVolumeSourceImageIO provides the parameters to create a Data Volume from an imageio source.",
+		Optional:    true,
+		MaxItems:    1,
+		Elem: &schema.Resource{
+			Schema: fields,
+		},
+	}
+
+}
+
+func dataVolumeSourceVDDKFields() map[string]*schema.Schema {
+	return map[string]*schema.Schema{
+		"url": {
+			Type:        schema.TypeString,
+			Description: "url is the URL of the vCenter or ESXi host with the VM to migrate.",
+			Optional:    true,
+		},
+		"uuid": {
+			Type:        schema.TypeString,
+			Description: "Uuid is the UUID of the virtual machine that the backing file is attached to in vCenter/ESXi.",
+			Optional:    true,
+		},
+		"backing_file": {
+			Type:        schema.TypeString,
+			Description: "Backing_file is the path to the virtual hard disk to migrate from vCenter/ESXi.",
+			Optional:    true,
+		},
+		"thumbprint": {
+			Type:        schema.TypeString,
+			Description: "Thumbprint is the certificate thumbprint of the vCenter or ESXi host.",
+			Optional:    true,
+		},
+		"secret_ref": {
+			Type:        schema.TypeString,
+			Description: "Secret_ref provides a reference to a secret containing the username and password needed to access the vCenter or ESXi host.",
+			Optional:    true,
+		},
+		"init_image_url": {
+			Type:        schema.TypeString,
+			Description: "Init_image_url is an optional URL to an image containing an extracted VDDK library, overrides v2v-vmware config map.",
+			Optional:    true,
+		},
+	}
+}
+
+func dataVolumeSourceVDDKSchema() *schema.Schema {
+	fields := dataVolumeSourceVDDKFields()
+
+	return &schema.Schema{
+		Type:        schema.TypeList,
+		Description: "DataVolumeSourceVDDK provides the parameters to create a Data Volume from a Vmware source.",
+		Optional:    true,
+		MaxItems:    1,
+		Elem: &schema.Resource{
+			Schema: fields,
+		},
+	}
+
+}
+
+func dataVolumeSourceSnapshotFields() map[string]*schema.Schema {
+	return map[string]*schema.Schema{
+		"namespace": {
+			Type:        schema.TypeString,
+			Description: "The namespace of the source VolumeSnapshot.",
+			Required:    true,
+		},
+		"name": {
+			Type:        schema.TypeString,
+			Description: "The name of the source VolumeSnapshot.",
+			Required:    true,
+		},
+	}
+}
+
+func dataVolumeSourceSnapshotSchema() *schema.Schema {
+	fields := dataVolumeSourceSnapshotFields()
+
+	return &schema.Schema{
+		Type:        schema.TypeList,
+		Description: "DataVolumeSourceSnapshot provides the parameters to create a Data Volume from an existing VolumeSnapshot.",
+		Optional:    true,
+		MaxItems:    1,
+		Elem: &schema.Resource{
+			Schema: fields,
+		},
+	}
+
+}
+
 // Expanders
 
-func expandDataVolumeSource(dataVolumeSource []interface{}) *cdiv1.DataVolumeSource {
+func expandDataVolumeSource(dataVolumeSource []interface{}) (*cdiv1.DataVolumeSource, error) {
 	result := &cdiv1.DataVolumeSource{}
 
 	if len(dataVolumeSource) == 0 || dataVolumeSource[0] == nil {
-		return result
+		return result, nil
 	}
 
 	in := dataVolumeSource[0].(map[string]interface{})
 
-	result.HTTP = expandDataVolumeSourceHTTP(in["http"].([]interface{}))
-	result.PVC = expandDataVolumeSourcePVC(in["pvc"].([]interface{}))
+	if v, ok := in["http"].([]interface{}); ok {
+		result.HTTP = expandDataVolumeSourceHTTP(v)
+	}
+	if v, ok := in["s3"].([]interface{}); ok {
+		result.S3 = expandDataVolumeSourceS3(v)
+	}
+	if v, ok := in["registry"].([]interface{}); ok {
+		registry, err := expandDataVolumeSourceRegistry(v)
+		if err != nil {
+			return result, err
+		}
+		result.Registry = registry
+	}
+	if v, ok := in["pvc"].([]interface{}); ok {
+		result.PVC = expandDataVolumeSourcePVC(v)
+	}
+	// Upload and blank sources have no parameters, so an empty block enables them.
+	if v, ok := in["upload"].([]interface{}); ok && len(v) > 0 {
+		result.Upload = &cdiv1.DataVolumeSourceUpload{}
+	}
+	if v, ok := in["blank"].([]interface{}); ok && len(v) > 0 {
+		result.Blank = &cdiv1.DataVolumeBlankImage{}
+	}
+	if v, ok := in["imageio"].([]interface{}); ok {
+		result.Imageio = expandDataVolumeSourceImageIO(v)
+	}
+	if v, ok := in["vddk"].([]interface{}); ok {
+		result.VDDK = expandDataVolumeSourceVDDK(v)
+	}
+	if v, ok := in["snapshot"].([]interface{}); ok {
+		result.Snapshot = expandDataVolumeSourceSnapshot(v)
+	}
 
-	return result
+	return result, nil
 }
 
 func expandDataVolumeSourceHTTP(dataVolumeSourceHTTP []interface{}) *cdiv1.DataVolumeSourceHTTP {
@@ -120,6 +401,34 @@ func expandDataVolumeSourceHTTP(dataVolumeSourceHTTP []interface{}) *cdiv1.DataV
 
 	in := dataVolumeSourceHTTP[0].(map[string]interface{})
 
+	if v, ok := in["url"].(string); ok {
+		result.URL = v
+	}
+	if v, ok := in["secret_ref"].(string); ok {
+		result.SecretRef = v
+	}
+	if v, ok := in["cert_config_map"].(string); ok {
+		result.CertConfigMap = v
+	}
+	if v, ok := in["extra_headers"].([]interface{}); ok && len(v) > 0 {
+		result.ExtraHeaders = utils.ExpandStringSlice(v)
+	}
+	if v, ok := in["secret_extra_headers"].([]interface{}); ok && len(v) > 0 {
+		result.SecretExtraHeaders = utils.ExpandStringSlice(v)
+	}
+
+	return result
+}
+
+func expandDataVolumeSourceS3(dataVolumeSourceS3 []interface{}) *cdiv1.DataVolumeSourceS3 {
+	if len(dataVolumeSourceS3) == 0 || dataVolumeSourceS3[0] == nil {
+		return nil
+	}
+
+	result := &cdiv1.DataVolumeSourceS3{}
+
+	in := dataVolumeSourceS3[0].(map[string]interface{})
+
 	if v, ok := in["url"].(string); ok {
 		result.URL = v
 	}
@@ -133,6 +442,41 @@ func expandDataVolumeSourceHTTP(dataVolumeSourceHTTP []interface{}) *cdiv1.DataV
 	return result
 }
 
+func expandDataVolumeSourceRegistry(dataVolumeSourceRegistry []interface{}) (*cdiv1.DataVolumeSourceRegistry, error) {
+	if len(dataVolumeSourceRegistry) == 0 {
+		return nil, nil
+	}
+	if dataVolumeSourceRegistry[0] == nil {
+		return nil, fmt.Errorf("exactly one of url or image_stream must be set in a registry source")
+	}
+
+	result := &cdiv1.DataVolumeSourceRegistry{}
+
+	in := dataVolumeSourceRegistry[0].(map[string]interface{})
+
+	if v, ok := in["url"].(string); ok && v != "" {
+		result.URL = &v
+	}
+	if v, ok := in["image_stream"].(string); ok && v != "" {
+		result.ImageStream = &v
+	}
+	if v, ok := in["pull_method"].(string); ok && v != "" {
+		pullMethod := cdiv1.RegistryPullMethod(v)
+		result.PullMethod = &pullMethod
+	}
+	if v, ok := in["secret_ref"].(string); ok && v != "" {
+		result.SecretRef = &v
+	}
+	if v, ok := in["cert_config_map"].(string); ok && v != "" {
+		result.CertConfigMap = &v
+	}
+	if (result.URL == nil) == (result.ImageStream == nil) {
+		return result, fmt.Errorf("exactly one of url or image_stream must be set in a registry source")
+	}
+
+	return result, nil
+}
+
 func expandDataVolumeSourcePVC(dataVolumeSourcePVC []interface{}) *cdiv1.DataVolumeSourcePVC {
 	if len(dataVolumeSourcePVC) == 0 || dataVolumeSourcePVC[0] == nil {
 		return nil
@@ -152,17 +496,117 @@ func expandDataVolumeSourcePVC(dataVolumeSourcePVC []interface{}) *cdiv1.DataVol
 	return result
 }
 
+func expandDataVolumeSourceImageIO(dataVolumeSourceImageIO []interface{}) *cdiv1.DataVolumeSourceImageIO {
+	if len(dataVolumeSourceImageIO) == 0 || dataVolumeSourceImageIO[0] == nil {
+		return nil
+	}
+
+	result := &cdiv1.DataVolumeSourceImageIO{}
+
+	in := dataVolumeSourceImageIO[0].(map[string]interface{})
+
+	if v, ok := in["url"].(string); ok {
+		result.URL = v
+	}
+	if v, ok := in["disk_id"].(string); ok {
+		result.DiskID = v
+	}
+	if v, ok := in["secret_ref"].(string); ok {
+		result.SecretRef = v
+	}
+	if v, ok := in["cert_config_map"].(string); ok {
+		result.CertConfigMap = v
+	}
+
+	return result
+}
+
+func expandDataVolumeSourceVDDK(dataVolumeSourceVDDK []interface{}) *cdiv1.DataVolumeSourceVDDK {
+	if len(dataVolumeSourceVDDK) == 0 || dataVolumeSourceVDDK[0] == nil {
+		return nil
+	}
+
+	result := &cdiv1.DataVolumeSourceVDDK{}
+
+	in := dataVolumeSourceVDDK[0].(map[string]interface{})
+
+	if v, ok := in["url"].(string); ok {
+		result.URL = v
+	}
+	if v, ok := in["uuid"].(string); ok {
+		result.UUID = v
+	}
+	if v, ok := in["backing_file"].(string); ok {
+		result.BackingFile = v
+	}
+	if v, ok := in["thumbprint"].(string); ok {
+		result.Thumbprint = v
+	}
+	if v, ok := in["secret_ref"].(string); ok {
+		result.SecretRef = v
+	}
+	if v, ok := in["init_image_url"].(string); ok {
+		result.InitImageURL = v
+	}
+
+	return result
+}
+
+func expandDataVolumeSourceSnapshot(dataVolumeSourceSnapshot []interface{}) *cdiv1.DataVolumeSourceSnapshot {
+	if len(dataVolumeSourceSnapshot) == 0 || dataVolumeSourceSnapshot[0] == nil {
+		return nil
+	}
+
+	result := &cdiv1.DataVolumeSourceSnapshot{}
+
+	in := dataVolumeSourceSnapshot[0].(map[string]interface{})
+
+	if v, ok := in["namespace"].(string); ok {
+		result.Namespace = v
+	}
+	if v, ok := in["name"].(string); ok {
+		result.Name = v
+	}
+
+	return result
+}
+
 // Flatteners
 
 func flattenDataVolumeSource(in *cdiv1.DataVolumeSource) []interface{} {
+	if in == nil {
+		return []interface{}{}
+	}
+
 	att := make(map[string]interface{})
 
 	if in.HTTP != nil {
 		att["http"] = flattenDataVolumeSourceHTTP(*in.HTTP)
 	}
+	if in.S3 != nil {
+		att["s3"] = flattenDataVolumeSourceS3(*in.S3)
+	}
+	if in.Registry != nil {
+		att["registry"] = flattenDataVolumeSourceRegistry(*in.Registry)
+	}
 	if in.PVC != nil {
 		att["pvc"] = flattenDataVolumeSourcePVC(*in.PVC)
 	}
+	if in.Upload != nil {
+		att["upload"] = []interface{}{map[string]interface{}{}}
+	}
+	if in.Blank != nil {
+		att["blank"] = []interface{}{map[string]interface{}{}}
+	}
+	if in.Imageio != nil {
+		att["imageio"] = flattenDataVolumeSourceImageIO(*in.Imageio)
+	}
+	if in.VDDK != nil {
+		att["vddk"] = flattenDataVolumeSourceVDDK(*in.VDDK)
+	}
+	if in.Snapshot != nil {
+		att["snapshot"] = flattenDataVolumeSourceSnapshot(*in.Snapshot)
+	}
 
 	return []interface{}{att}
 }
@@ -173,6 +617,43 @@ func flattenDataVolumeSourceHTTP(in cdiv1.DataVolumeSourceHTTP) []interface{} {
 		"secret_ref":      in.SecretRef,
 		"cert_config_map": in.CertConfigMap,
 	}
+	if len(in.ExtraHeaders) > 0 {
+		att["extra_headers"] = in.ExtraHeaders
+	}
+	if len(in.SecretExtraHeaders) > 0 {
+		att["secret_extra_headers"] = in.SecretExtraHeaders
+	}
+	return []interface{}{att}
+}
+
+func flattenDataVolumeSourceS3(in cdiv1.DataVolumeSourceS3) []interface{} {
+	att := map[string]interface{}{
+		"url":             in.URL,
+		"secret_ref":      in.SecretRef,
+		"cert_config_map": in.CertConfigMap,
+	}
+	return []interface{}{att}
+}
+
+func flattenDataVolumeSourceRegistry(in cdiv1.DataVolumeSourceRegistry) []interface{} {
+	att := make(map[string]interface{})
+
+	if in.URL != nil {
+		att["url"] = *in.URL
+	}
+	if in.ImageStream != nil {
+		att["image_stream"] = *in.ImageStream
+	}
+	if in.PullMethod != nil {
+		att["pull_method"] = string(*in.PullMethod)
+	}
+	if in.SecretRef != nil {
+		att["secret_ref"] = *in.SecretRef
+	}
+	if in.CertConfigMap != nil {
+		att["cert_config_map"] = *in.CertConfigMap
+	}
+
 	return []interface{}{att}
 }
 
@@ -183,3 +664,33 @@ func flattenDataVolumeSourcePVC(in cdiv1.DataVolumeSourcePVC) []interface{} {
 	}
 	return []interface{}{att}
 }
+
+func flattenDataVolumeSourceImageIO(in cdiv1.DataVolumeSourceImageIO) []interface{} {
+	att := map[string]interface{}{
+		"url":             in.URL,
+		"disk_id":         in.DiskID,
+		"secret_ref":      in.SecretRef,
+		"cert_config_map": in.CertConfigMap,
+	}
+	return []interface{}{att}
+}
+
+func flattenDataVolumeSourceVDDK(in cdiv1.DataVolumeSourceVDDK) []interface{} {
+	att := map[string]interface{}{
+		"url":            in.URL,
+		"uuid":           in.UUID,
+		"backing_file":   in.BackingFile,
+		"thumbprint":     in.Thumbprint,
+		"secret_ref":     in.SecretRef,
+		"init_image_url": in.InitImageURL,
+	}
+	return []interface{}{att}
+}
+
+func flattenDataVolumeSourceSnapshot(in cdiv1.DataVolumeSourceSnapshot) []interface{} {
+	att := map[string]interface{}{
+		"namespace": in.Namespace,
+		"name":      in.Name,
+	}
+	return []interface{}{att}
+}
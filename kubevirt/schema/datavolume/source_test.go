@@ -0,0 +1,272 @@
+package datavolume
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/nrp-nautilus/terraform-provider-kubevirt/kubevirt/utils"
+	cdiv1 "kubevirt.io/containerized-data-importer-api/pkg/apis/core/v1beta1"
+
+	"gotest.tools/assert"
+)
+
+func TestExpandFlattenDataVolumeSource(t *testing.T) {
+	pullNode := cdiv1.RegistryPullNode
+
+	cases := []struct {
+		name           string
+		input          map[string]interface{}
+		expectedOutput *cdiv1.DataVolumeSource
+	}{
+		{
+			name: "http",
+			input: map[string]interface{}{
+				"http": []interface{}{
+					map[string]interface{}{
+						"url":                  "https://example.com/fedora.qcow2",
+						"secret_ref":           "http-credentials",
+						"cert_config_map":      "http-ca",
+						"extra_headers":        []interface{}{"X-First: 1", "X-Second: 2"},
+						"secret_extra_headers": []interface{}{"http-token-header"},
+					},
+				},
+			},
+			expectedOutput: &cdiv1.DataVolumeSource{
+				HTTP: &cdiv1.DataVolumeSourceHTTP{
+					URL:                "https://example.com/fedora.qcow2",
+					SecretRef:          "http-credentials",
+					CertConfigMap:      "http-ca",
+					ExtraHeaders:       []string{"X-First: 1", "X-Second: 2"},
+					SecretExtraHeaders: []string{"http-token-header"},
+				},
+			},
+		},
+		{
+			name: "http without extra headers",
+			input: map[string]interface{}{
+				"http": []interface{}{
+					map[string]interface{}{
+						"url": "https://example.com/fedora.qcow2",
+					},
+				},
+			},
+			expectedOutput: &cdiv1.DataVolumeSource{
+				HTTP: &cdiv1.DataVolumeSourceHTTP{URL: "https://example.com/fedora.qcow2"},
+			},
+		},
+		{
+			name: "s3",
+			input: map[string]interface{}{
+				"s3": []interface{}{
+					map[string]interface{}{
+						"url":             "https://s3.example.com/bucket/fedora.qcow2",
+						"secret_ref":      "s3-credentials",
+						"cert_config_map": "s3-ca",
+					},
+				},
+			},
+			expectedOutput: &cdiv1.DataVolumeSource{
+				S3: &cdiv1.DataVolumeSourceS3{
+					URL:           "https://s3.example.com/bucket/fedora.qcow2",
+					SecretRef:     "s3-credentials",
+					CertConfigMap: "s3-ca",
+				},
+			},
+		},
+		{
+			name: "registry url",
+			input: map[string]interface{}{
+				"registry": []interface{}{
+					map[string]interface{}{
+						"url":             "docker://quay.io/containerdisks/fedora:38",
+						"pull_method":     "node",
+						"secret_ref":      "registry-credentials",
+						"cert_config_map": "registry-ca",
+					},
+				},
+			},
+			expectedOutput: &cdiv1.DataVolumeSource{
+				Registry: &cdiv1.DataVolumeSourceRegistry{
+					URL:           utils.PtrToString("docker://quay.io/containerdisks/fedora:38"),
+					PullMethod:    &pullNode,
+					SecretRef:     utils.PtrToString("registry-credentials"),
+					CertConfigMap: utils.PtrToString("registry-ca"),
+				},
+			},
+		},
+		{
+			name: "registry image stream",
+			input: map[string]interface{}{
+				"registry": []interface{}{
+					map[string]interface{}{
+						"image_stream": "fedora",
+						"pull_method":  "node",
+					},
+				},
+			},
+			expectedOutput: &cdiv1.DataVolumeSource{
+				Registry: &cdiv1.DataVolumeSourceRegistry{
+					ImageStream: utils.PtrToString("fedora"),
+					PullMethod:  &pullNode,
+				},
+			},
+		},
+		{
+			name: "pvc",
+			input: map[string]interface{}{
+				"pvc": []interface{}{
+					map[string]interface{}{
+						"namespace": "golden-images",
+						"name":      "fedora",
+					},
+				},
+			},
+			expectedOutput: &cdiv1.DataVolumeSource{
+				PVC: &cdiv1.DataVolumeSourcePVC{Namespace: "golden-images", Name: "fedora"},
+			},
+		},
+		{
+			name: "upload",
+			input: map[string]interface{}{
+				"upload": []interface{}{nil},
+			},
+			expectedOutput: &cdiv1.DataVolumeSource{
+				Upload: &cdiv1.DataVolumeSourceUpload{},
+			},
+		},
+		{
+			name: "blank",
+			input: map[string]interface{}{
+				"blank": []interface{}{nil},
+			},
+			expectedOutput: &cdiv1.DataVolumeSource{
+				Blank: &cdiv1.DataVolumeBlankImage{},
+			},
+		},
+		{
+			name: "imageio",
+			input: map[string]interface{}{
+				"imageio": []interface{}{
+					map[string]interface{}{
+						"url":             "https://engine.example.com/ovirt-engine/api",
+						"disk_id":         "b6c6a4b5-1f2a-4f9c-9d51-0b1c6f3e2a7d",
+						"secret_ref":      "engine-credentials",
+						"cert_config_map": "engine-ca",
+					},
+				},
+			},
+			expectedOutput: &cdiv1.DataVolumeSource{
+				Imageio: &cdiv1.DataVolumeSourceImageIO{
+					URL:           "https://engine.example.com/ovirt-engine/api",
+					DiskID:        "b6c6a4b5-1f2a-4f9c-9d51-0b1c6f3e2a7d",
+					SecretRef:     "engine-credentials",
+					CertConfigMap: "engine-ca",
+				},
+			},
+		},
+		{
+			name: "vddk",
+			input: map[string]interface{}{
+				"vddk": []interface{}{
+					map[string]interface{}{
+						"url":            "https://vcenter.example.com",
+						"uuid":           "52260566-b032-36cb-55b1-79bf29e30490",
+						"backing_file":   "[datastore1] fedora/fedora.vmdk",
+						"thumbprint":     "20:6C:8A:5D:44:40:B3:79:4B:28:EA:76:13:60:90:6E:49:D9:D9:A3",
+						"secret_ref":     "vcenter-credentials",
+						"init_image_url": "quay.io/example/vddk:latest",
+					},
+				},
+			},
+			expectedOutput: &cdiv1.DataVolumeSource{
+				VDDK: &cdiv1.DataVolumeSourceVDDK{
+					URL:          "https://vcenter.example.com",
+					UUID:         "52260566-b032-36cb-55b1-79bf29e30490",
+					BackingFile:  "[datastore1] fedora/fedora.vmdk",
+					Thumbprint:   "20:6C:8A:5D:44:40:B3:79:4B:28:EA:76:13:60:90:6E:49:D9:D9:A3",
+					SecretRef:    "vcenter-credentials",
+					InitImageURL: "quay.io/example/vddk:latest",
+				},
+			},
+		},
+		{
+			name: "snapshot",
+			input: map[string]interface{}{
+				"snapshot": []interface{}{
+					map[string]interface{}{
+						"namespace": "golden-images",
+						"name":      "fedora-snapshot",
+					},
+				},
+			},
+			expectedOutput: &cdiv1.DataVolumeSource{
+				Snapshot: &cdiv1.DataVolumeSourceSnapshot{Namespace: "golden-images", Name: "fedora-snapshot"},
+			},
+		},
+	}
+
+	resource := &schema.Resource{
+		Schema: map[string]*schema.Schema{"source": dataVolumeSourceSchema()},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			output, err := expandDataVolumeSource([]interface{}{tc.input})
+
+			assert.NilError(t, err)
+			assert.DeepEqual(t, tc.expectedOutput, output)
+
+			// Reading the source back through the schema has to yield the same source.
+			resourceData := resource.TestResourceData()
+			assert.NilError(t, resourceData.Set("source", flattenDataVolumeSource(output)))
+			roundTrip, err := expandDataVolumeSource(resourceData.Get("source").([]interface{}))
+			assert.NilError(t, err)
+			assert.DeepEqual(t, tc.expectedOutput, roundTrip)
+		})
+	}
+}
+
+func TestExpandDataVolumeSourceRegistry(t *testing.T) {
+	cases := []struct {
+		name          string
+		input         []interface{}
+		expectedError string
+	}{
+		{
+			name: "url and image stream",
+			input: []interface{}{
+				map[string]interface{}{
+					"url":          "docker://quay.io/containerdisks/fedora:38",
+					"image_stream": "fedora",
+				},
+			},
+			expectedError: "exactly one of url or image_stream must be set in a registry source",
+		},
+		{
+			name: "neither url nor image stream",
+			input: []interface{}{
+				map[string]interface{}{
+					"url":          "",
+					"image_stream": "",
+					"pull_method":  "pod",
+				},
+			},
+			expectedError: "exactly one of url or image_stream must be set in a registry source",
+		},
+		{
+			name:          "empty block",
+			input:         []interface{}{nil},
+			expectedError: "exactly one of url or image_stream must be set in a registry source",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := expandDataVolumeSource([]interface{}{
+				map[string]interface{}{"registry": tc.input},
+			})
+
+			assert.Error(t, err, tc.expectedError)
+		})
+	}
+}
@@ -11,8 +11,9 @@ import (
 
 func dataVolumeSpecFields() map[string]*schema.Schema {
 	return map[string]*schema.Schema{
-		"source": dataVolumeSourceSchema(),
-		"pvc":    k8s.PersistentVolumeClaimSpecSchema(),
+		"source":  dataVolumeSourceSchema(),
+		"pvc":     dataVolumePVCSpecSchema(),
+		"storage": dataVolumeStorageSpecSchema(),
 		"content_type": {
 			Type:        schema.TypeString,
 			Description: "ContentType options: \"kubevirt\", \"archive\".",
@@ -40,6 +41,15 @@ func DataVolumeSpecSchema() *schema.Schema {
 
 }
 
+func dataVolumePVCSpecSchema() *schema.Schema {
+	result := k8s.PersistentVolumeClaimSpecSchema()
+	result.Description = "PVC is the PVC specification of the DataVolume. Exactly one of pvc or storage has to be set."
+	result.Required = false
+	result.Optional = true
+
+	return result
+}
+
 func ExpandDataVolumeSpec(dataVolumeSpec []interface{}) (cdiv1.DataVolumeSpec, error) {
 	result := cdiv1.DataVolumeSpec{}
 
@@ -49,12 +59,30 @@ func ExpandDataVolumeSpec(dataVolumeSpec []interface{}) (cdiv1.DataVolumeSpec, e
 
 	in := dataVolumeSpec[0].(map[string]interface{})
 
-	result.Source = expandDataVolumeSource(in["source"].([]interface{}))
-	p, err := k8s.ExpandPersistentVolumeClaimSpec(in["pvc"].([]interface{}))
-	if err != nil {
-		return result, err
+	if v, ok := in["source"].([]interface{}); ok {
+		source, err := expandDataVolumeSource(v)
+		if err != nil {
+			return result, err
+		}
+		result.Source = source
+	}
+	if v, ok := in["pvc"].([]interface{}); ok && len(v) > 0 && v[0] != nil {
+		pvc, err := k8s.ExpandPersistentVolumeClaimSpec(v)
+		if err != nil {
+			return result, err
+		}
+		result.PVC = pvc
+	}
+	if v, ok := in["storage"].([]interface{}); ok {
+		storage, err := expandDataVolumeStorageSpec(v)
+		if err != nil {
+			return result, err
+		}
+		result.Storage = storage
+	}
+	if (result.PVC == nil) == (result.Storage == nil) {
+		return result, fmt.Errorf("exactly one of pvc or storage must be set in the data volume spec")
 	}
-	result.PVC = p
 
 	if v, ok := in["content_type"].(string); ok {
 		result.ContentType = cdiv1.DataVolumeContentType(v)
@@ -66,8 +94,13 @@ func ExpandDataVolumeSpec(dataVolumeSpec []interface{}) (cdiv1.DataVolumeSpec, e
 func FlattenDataVolumeSpec(spec cdiv1.DataVolumeSpec) []interface{} {
 	att := map[string]interface{}{
 		"source":       flattenDataVolumeSource(spec.Source),
-		"pvc":          k8s.FlattenPersistentVolumeClaimSpec(*spec.PVC),
 		"content_type": string(spec.ContentType),
 	}
+	if spec.PVC != nil {
+		att["pvc"] = k8s.FlattenPersistentVolumeClaimSpec(*spec.PVC)
+	}
+	if spec.Storage != nil {
+		att["storage"] = flattenDataVolumeStorageSpec(*spec.Storage)
+	}
 	return []interface{}{att}
 }
@@ -0,0 +1,58 @@
+package datavolume
+
+import (
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/nrp-nautilus/terraform-provider-kubevirt/kubevirt/schema/k8s"
+	k8sv1 "k8s.io/api/core/v1"
+	cdiv1 "kubevirt.io/containerized-data-importer-api/pkg/apis/core/v1beta1"
+)
+
+// The storage spec mirrors the PVC spec, except that CDI fills in the access modes,
+// volume mode and size from the StorageProfile of the storage class when they're omitted.
+func dataVolumeStorageSpecSchema() *schema.Schema {
+	result := k8s.PersistentVolumeClaimSpecSchema()
+	result.Description = "Storage is the requested storage for the DataVolume. Unlike pvc, the access modes, volume mode and size default to the StorageProfile of the storage class."
+	result.Required = false
+	result.Optional = true
+
+	fields := result.Elem.(*schema.Resource).Schema
+	fields["access_modes"].Required = false
+	fields["access_modes"].Optional = true
+	fields["resources"].Required = false
+	fields["resources"].Optional = true
+
+	return result
+}
+
+func expandDataVolumeStorageSpec(dataVolumeStorageSpec []interface{}) (*cdiv1.StorageSpec, error) {
+	if len(dataVolumeStorageSpec) == 0 || dataVolumeStorageSpec[0] == nil {
+		return nil, nil
+	}
+
+	pvc, err := k8s.ExpandPersistentVolumeClaimSpec(dataVolumeStorageSpec)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &cdiv1.StorageSpec{
+		AccessModes:      pvc.AccessModes,
+		Selector:         pvc.Selector,
+		Resources:        pvc.Resources,
+		VolumeName:       pvc.VolumeName,
+		StorageClassName: pvc.StorageClassName,
+		VolumeMode:       pvc.VolumeMode,
+	}
+
+	return result, nil
+}
+
+func flattenDataVolumeStorageSpec(in cdiv1.StorageSpec) []interface{} {
+	return k8s.FlattenPersistentVolumeClaimSpec(k8sv1.PersistentVolumeClaimSpec{
+		AccessModes:      in.AccessModes,
+		Selector:         in.Selector,
+		Resources:        in.Resources,
+		VolumeName:       in.VolumeName,
+		StorageClassName: in.StorageClassName,
+		VolumeMode:       in.VolumeMode,
+	})
+}
@@ -71,6 +71,16 @@ func persistentVolumeClaimSpecFields() map[string]*schema.Schema {
 			Computed:    true,
 			ForceNew:    true,
 		},
+		"volume_mode": {
+			Type:        schema.TypeString,
+			Description: "Defines what type of volume is required by the claim, \"Filesystem\" or \"Block\".",
+			Optional:    true,
+			ForceNew:    true,
+			ValidateFunc: validation.StringInSlice([]string{
+				string(v1.PersistentVolumeFilesystem),
+				string(v1.PersistentVolumeBlock),
+			}, false),
+		},
 	}
 }
 
@@ -104,6 +114,9 @@ func FlattenPersistentVolumeClaimSpec(in v1.PersistentVolumeClaimSpec) []interfa
 	if in.StorageClassName != nil {
 		att["storage_class_name"] = *in.StorageClassName
 	}
+	if in.VolumeMode != nil {
+		att["volume_mode"] = string(*in.VolumeMode)
+	}
 	return []interface{}{att}
 }
 
@@ -126,12 +139,16 @@ func ExpandPersistentVolumeClaimSpec(l []interface{}) (*v1.PersistentVolumeClaim
 		return obj, nil
 	}
 	in := l[0].(map[string]interface{})
-	resourceRequirements, err := expandResourceRequirements(in["resources"].([]interface{}))
-	if err != nil {
-		return nil, err
+	if v, ok := in["resources"].([]interface{}); ok {
+		resourceRequirements, err := expandResourceRequirements(v)
+		if err != nil {
+			return nil, err
+		}
+		obj.Resources = *resourceRequirements
+	}
+	if v, ok := in["access_modes"].(*schema.Set); ok && v.Len() > 0 {
+		obj.AccessModes = expandPersistentVolumeAccessModes(v.List())
 	}
-	obj.AccessModes = expandPersistentVolumeAccessModes(in["access_modes"].(*schema.Set).List())
-	obj.Resources = *resourceRequirements
 	if v, ok := in["selector"].([]interface{}); ok && len(v) > 0 {
 		obj.Selector = expandLabelSelector(v)
 	}
@@ -141,6 +158,10 @@ func ExpandPersistentVolumeClaimSpec(l []interface{}) (*v1.PersistentVolumeClaim
 	if v, ok := in["storage_class_name"].(string); ok && v != "" {
 		obj.StorageClassName = utils.PtrToString(v)
 	}
+	if v, ok := in["volume_mode"].(string); ok && v != "" {
+		volumeMode := v1.PersistentVolumeMode(v)
+		obj.VolumeMode = &volumeMode
+	}
 	return obj, nil
 }
 
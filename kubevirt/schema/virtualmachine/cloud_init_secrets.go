@@ -0,0 +1,103 @@
+package virtualmachine
+
+import (
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/nrp-nautilus/terraform-provider-kubevirt/kubevirt/schema/virtualmachineinstance"
+	k8sv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubevirtapiv1 "kubevirt.io/api/core/v1"
+)
+
+// CloudInitSecretVolumeNames returns the names of the template volumes of a virtual machine
+// spec block with store_as_secret set.
+func CloudInitSecretVolumeNames(spec []interface{}) []string {
+	if len(spec) == 0 || spec[0] == nil {
+		return nil
+	}
+	template, _ := spec[0].(map[string]interface{})["template"].([]interface{})
+	return virtualmachineinstance.CloudInitSecretVolumeNames(templateSpec(template))
+}
+
+// templateSpec returns the spec block of a template block.
+func templateSpec(template []interface{}) []interface{} {
+	if len(template) == 0 || template[0] == nil {
+		return nil
+	}
+	spec, _ := template[0].(map[string]interface{})["spec"].([]interface{})
+	return spec
+}
+
+// ExtractCloudInitSecrets moves the data of the given cloud-init volumes out of the virtual
+// machine template and returns the Secrets the template now references.
+func ExtractCloudInitSecrets(vm *kubevirtapiv1.VirtualMachine, volumeNames []string) []*k8sv1.Secret {
+	if vm.Spec.Template == nil {
+		return nil
+	}
+
+	secrets := virtualmachineinstance.ExtractCloudInitSecrets(vm.Name, &vm.Spec.Template.Spec, volumeNames)
+	for _, secret := range secrets {
+		secret.Namespace = vm.Namespace
+		secret.Type = k8sv1.SecretTypeOpaque
+	}
+
+	return secrets
+}
+
+// CloudInitSecretNames returns the names of the Secrets holding the cloud-init data of
+// the virtual machine volumes with store_as_secret set.
+func CloudInitSecretNames(vm *kubevirtapiv1.VirtualMachine) []string {
+	if vm.Spec.Template == nil {
+		return nil
+	}
+
+	return virtualmachineinstance.CloudInitSecretNames(vm.Name, vm.Spec.Template.Spec)
+}
+
+// InlineCloudInitSecrets puts the data of the given Secrets back into the cloud-init volumes
+// of the virtual machine they were extracted from, and returns the names of these volumes.
+func InlineCloudInitSecrets(vm *kubevirtapiv1.VirtualMachine, secrets map[string]*k8sv1.Secret) []string {
+	if vm.Spec.Template == nil {
+		return nil
+	}
+
+	return virtualmachineinstance.InlineCloudInitSecrets(vm.Name, &vm.Spec.Template.Spec, secrets)
+}
+
+// CloudInitSecretOwnerReference ties a Secret holding cloud-init data to the virtual machine,
+// so that it's garbage collected along with it.
+func CloudInitSecretOwnerReference(vm *kubevirtapiv1.VirtualMachine) metav1.OwnerReference {
+	return *metav1.NewControllerRef(vm, kubevirtapiv1.VirtualMachineGroupVersionKind)
+}
+
+// StaleCloudInitSecretNames returns the names of the Secrets the previous configuration
+// stored cloud-init data in, and that the new one no longer uses.
+func StaleCloudInitSecretNames(resourceData *schema.ResourceData) ([]string, error) {
+	if !resourceData.HasChange("spec") {
+		return nil, nil
+	}
+
+	oldV, newV := resourceData.GetChange("spec")
+	oldSpec, err := expandVirtualMachineSpec(oldV.([]interface{}))
+	if err != nil {
+		return nil, err
+	}
+	newSpec, err := expandVirtualMachineSpec(newV.([]interface{}))
+	if err != nil {
+		return nil, err
+	}
+
+	name := resourceData.Get("metadata.0.name").(string)
+	inUse := make(map[string]bool)
+	for _, secret := range ExtractCloudInitSecrets(&kubevirtapiv1.VirtualMachine{ObjectMeta: metav1.ObjectMeta{Name: name}, Spec: newSpec}, CloudInitSecretVolumeNames(newV.([]interface{}))) {
+		inUse[secret.Name] = true
+	}
+
+	var stale []string
+	for _, secret := range ExtractCloudInitSecrets(&kubevirtapiv1.VirtualMachine{ObjectMeta: metav1.ObjectMeta{Name: name}, Spec: oldSpec}, CloudInitSecretVolumeNames(oldV.([]interface{}))) {
+		if !inUse[secret.Name] {
+			stale = append(stale, secret.Name)
+		}
+	}
+
+	return stale, nil
+}
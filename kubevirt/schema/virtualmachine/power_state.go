@@ -0,0 +1,70 @@
+package virtualmachine
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	kubevirtapiv1 "kubevirt.io/api/core/v1"
+)
+
+const (
+	PowerStateRunning = "running"
+	PowerStateStopped = "stopped"
+	PowerStatePaused  = "paused"
+)
+
+func powerStateSchema() *schema.Schema {
+	return &schema.Schema{
+		Type:        schema.TypeString,
+		Description: "Desired power state of the virtual machine, one of \"running\", \"stopped\" or \"paused\". The virtual machine is driven there through the KubeVirt start, stop, pause and unpause subresources, which also update its run strategy, so it requires run_strategy \"Manual\".",
+		Optional:    true,
+		ValidateFunc: validation.StringInSlice([]string{
+			PowerStateRunning,
+			PowerStateStopped,
+			PowerStatePaused,
+		}, false),
+	}
+}
+
+// ValidatePowerStateDiff rejects a power_state set along with any run strategy but Manual. The
+// lifecycle subresources rewrite the others, so both would flip back and forth on every apply.
+func ValidatePowerStateDiff(ctx context.Context, diff *schema.ResourceDiff, meta interface{}) error {
+	powerState := diff.Get("power_state").(string)
+	if powerState == "" {
+		return nil
+	}
+
+	runStrategy := kubevirtapiv1.VirtualMachineRunStrategy(diff.Get("spec.0.run_strategy").(string))
+	if runStrategy != kubevirtapiv1.RunStrategyManual {
+		return fmt.Errorf("power_state %q requires run_strategy %q, got %q: the start and stop subresources rewrite any other run strategy", powerState, kubevirtapiv1.RunStrategyManual, runStrategy)
+	}
+
+	return nil
+}
+
+// PowerStatePrintableStatus returns the printable status a virtual machine reports once it reached the given power state.
+func PowerStatePrintableStatus(powerState string) kubevirtapiv1.VirtualMachinePrintableStatus {
+	switch powerState {
+	case PowerStateRunning:
+		return kubevirtapiv1.VirtualMachineStatusRunning
+	case PowerStateStopped:
+		return kubevirtapiv1.VirtualMachineStatusStopped
+	case PowerStatePaused:
+		return kubevirtapiv1.VirtualMachineStatusPaused
+	}
+	return ""
+}
+
+func flattenPowerState(in kubevirtapiv1.VirtualMachinePrintableStatus) string {
+	switch in {
+	case kubevirtapiv1.VirtualMachineStatusRunning:
+		return PowerStateRunning
+	case kubevirtapiv1.VirtualMachineStatusStopped:
+		return PowerStateStopped
+	case kubevirtapiv1.VirtualMachineStatusPaused:
+		return PowerStatePaused
+	}
+	return ""
+}
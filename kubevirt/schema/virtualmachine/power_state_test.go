@@ -0,0 +1,77 @@
+package virtualmachine
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+
+	"gotest.tools/assert"
+)
+
+func TestValidatePowerStateDiff(t *testing.T) {
+	cases := []struct {
+		name          string
+		powerState    string
+		runStrategy   string
+		expectedError string
+	}{
+		{
+			name:        "no power state",
+			runStrategy: "Always",
+		},
+		{
+			name:        "manual run strategy",
+			powerState:  PowerStateStopped,
+			runStrategy: "Manual",
+		},
+		{
+			name:          "rerun on failure run strategy",
+			powerState:    PowerStatePaused,
+			runStrategy:   "RerunOnFailure",
+			expectedError: `power_state "paused" requires run_strategy "Manual", got "RerunOnFailure": the start and stop subresources rewrite any other run strategy`,
+		},
+		{
+			name:          "always run strategy",
+			powerState:    PowerStateStopped,
+			runStrategy:   "Always",
+			expectedError: `power_state "stopped" requires run_strategy "Manual", got "Always": the start and stop subresources rewrite any other run strategy`,
+		},
+		{
+			name:          "halted run strategy",
+			powerState:    PowerStateRunning,
+			runStrategy:   "Halted",
+			expectedError: `power_state "running" requires run_strategy "Manual", got "Halted": the start and stop subresources rewrite any other run strategy`,
+		},
+		{
+			name:          "empty run strategy",
+			powerState:    PowerStateRunning,
+			runStrategy:   "",
+			expectedError: `power_state "running" requires run_strategy "Manual", got "": the start and stop subresources rewrite any other run strategy`,
+		},
+	}
+
+	resource := &schema.Resource{
+		Schema:        VirtualMachineFields(),
+		CustomizeDiff: ValidatePowerStateDiff,
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			raw := getRawVirtualMachine()
+			getRawSpec(raw)["run_strategy"] = tc.runStrategy
+			if tc.powerState != "" {
+				raw["power_state"] = tc.powerState
+			}
+
+			_, err := resource.Diff(context.Background(), nil, terraform.NewResourceConfigRaw(raw), nil)
+
+			if tc.expectedError == "" {
+				assert.NilError(t, err)
+			} else {
+				assert.Error(t, err, tc.expectedError)
+			}
+		})
+	}
+}
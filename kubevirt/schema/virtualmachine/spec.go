@@ -5,7 +5,9 @@ import (
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"github.com/nrp-nautilus/terraform-provider-kubevirt/kubevirt/schema/k8s"
 	"github.com/nrp-nautilus/terraform-provider-kubevirt/kubevirt/schema/virtualmachineinstance"
+	"github.com/nrp-nautilus/terraform-provider-kubevirt/kubevirt/utils/patch"
 	kubevirtapiv1 "kubevirt.io/api/core/v1"
 )
 
@@ -101,3 +103,64 @@ func flattenVirtualMachineSpec(in kubevirtapiv1.VirtualMachineSpec) []interface{
 
 	return []interface{}{att}
 }
+
+func appendVirtualMachineSpecPatchOps(keyPrefix, pathPrefix string, resourceData *schema.ResourceData, live kubevirtapiv1.VirtualMachineSpec, ops []patch.PatchOperation) (patch.PatchOperations, error) {
+	if resourceData.HasChange(keyPrefix + "run_strategy") {
+		oldV, newV := resourceData.GetChange(keyPrefix + "run_strategy")
+		ops = append(ops, patch.DiffValue(pathPrefix+"runStrategy", oldV.(string), newV.(string), live.RunStrategy)...)
+	}
+	if resourceData.HasChange(keyPrefix + "template") {
+		templateOps, err := appendVirtualMachineTemplatePatchOps(keyPrefix+"template", pathPrefix+"template", resourceData, live.Template)
+		if err != nil {
+			return ops, err
+		}
+		ops = append(ops, templateOps...)
+	}
+	if resourceData.HasChange(keyPrefix + "data_volume_templates") {
+		oldV, newV := resourceData.GetChange(keyPrefix + "data_volume_templates")
+		oldTemplates, err := expandDataVolumeTemplates(oldV.([]interface{}))
+		if err != nil {
+			return ops, err
+		}
+		newTemplates, err := expandDataVolumeTemplates(newV.([]interface{}))
+		if err != nil {
+			return ops, err
+		}
+		ops = append(ops, patch.DiffValue(pathPrefix+"dataVolumeTemplates", oldTemplates, newTemplates, live.DataVolumeTemplates)...)
+	}
+
+	return ops, nil
+}
+
+func appendVirtualMachineTemplatePatchOps(key, path string, resourceData *schema.ResourceData, live *kubevirtapiv1.VirtualMachineInstanceTemplateSpec) (patch.PatchOperations, error) {
+	oldV, newV := resourceData.GetChange(key)
+	oldTemplate, err := virtualmachineinstance.ExpandVirtualMachineInstanceTemplateSpec(oldV.([]interface{}))
+	if err != nil {
+		return nil, err
+	}
+	newTemplate, err := virtualmachineinstance.ExpandVirtualMachineInstanceTemplateSpec(newV.([]interface{}))
+	if err != nil {
+		return nil, err
+	}
+
+	// The cloud-init data stored as secrets is written to the Secrets, the template only references them.
+	name := resourceData.Get("metadata.0.name").(string)
+	if oldTemplate != nil {
+		virtualmachineinstance.ExtractCloudInitSecrets(name, &oldTemplate.Spec, virtualmachineinstance.CloudInitSecretVolumeNames(templateSpec(oldV.([]interface{}))))
+	}
+	if newTemplate != nil {
+		virtualmachineinstance.ExtractCloudInitSecrets(name, &newTemplate.Spec, virtualmachineinstance.CloudInitSecretVolumeNames(templateSpec(newV.([]interface{}))))
+	}
+
+	// A template that is added or removed as a whole can't be patched field by field.
+	if oldTemplate == nil || newTemplate == nil || live == nil {
+		return patch.DiffValue(path, oldTemplate, newTemplate, live), nil
+	}
+
+	ops := k8s.AppendPatchOps(key+".0.metadata.0.", path+"/metadata/", resourceData, make([]patch.PatchOperation, 0, 0))
+	if resourceData.HasChange(key + ".0.spec") {
+		ops = append(ops, patch.DiffValue(path+"/spec", &oldTemplate.Spec, &newTemplate.Spec, &live.Spec)...)
+	}
+
+	return ops, nil
+}
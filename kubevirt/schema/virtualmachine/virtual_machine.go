@@ -3,15 +3,18 @@ package virtualmachine
 import (
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/nrp-nautilus/terraform-provider-kubevirt/kubevirt/schema/k8s"
+	"github.com/nrp-nautilus/terraform-provider-kubevirt/kubevirt/schema/virtualmachineinstance"
 	"github.com/nrp-nautilus/terraform-provider-kubevirt/kubevirt/utils/patch"
 	kubevirtapiv1 "kubevirt.io/api/core/v1"
 )
 
 func VirtualMachineFields() map[string]*schema.Schema {
 	return map[string]*schema.Schema{
-		"metadata": k8s.NamespacedMetadataSchema("VirtualMachine", false),
-		"spec":     virtualMachineSpecSchema(),
-		"status":   virtualMachineStatusSchema(),
+		"metadata":    k8s.NamespacedMetadataSchema("VirtualMachine", false),
+		"spec":        virtualMachineSpecSchema(),
+		"status":      virtualMachineStatusSchema(),
+		"power_state": powerStateSchema(),
+		"wait_for":    waitForSchema(),
 	}
 }
 
@@ -73,20 +76,38 @@ func FromResourceData(resourceData *schema.ResourceData) (*kubevirtapiv1.Virtual
 	return result, nil
 }
 
-func ToResourceData(vm kubevirtapiv1.VirtualMachine, resourceData *schema.ResourceData) error {
+// ToResourceData sets the resource data from the virtual machine, with store_as_secret set
+// on the given cloud-init volumes, whose data was read back from their Secrets.
+func ToResourceData(vm kubevirtapiv1.VirtualMachine, cloudInitSecretVolumes []string, resourceData *schema.ResourceData) error {
 	if err := resourceData.Set("metadata", k8s.FlattenMetadata(vm.ObjectMeta)); err != nil {
 		return err
 	}
-	if err := resourceData.Set("spec", flattenVirtualMachineSpec(vm.Spec)); err != nil {
+	spec := flattenVirtualMachineSpec(vm.Spec)
+	if template, ok := spec[0].(map[string]interface{})["template"].([]interface{}); ok {
+		virtualmachineinstance.SetCloudInitStoredAsSecret(templateSpec(template), cloudInitSecretVolumes)
+	}
+	if err := resourceData.Set("spec", spec); err != nil {
 		return err
 	}
 	if err := resourceData.Set("status", flattenVirtualMachineStatus(vm.Status)); err != nil {
 		return err
 	}
+	// The power state is only tracked when it's managed, and only once the
+	// virtual machine settled in one of the states it can be driven to.
+	if _, ok := resourceData.GetOk("power_state"); ok {
+		if powerState := flattenPowerState(vm.Status.PrintableStatus); powerState != "" {
+			if err := resourceData.Set("power_state", powerState); err != nil {
+				return err
+			}
+		}
+	}
 
 	return nil
 }
 
-func AppendPatchOps(keyPrefix, pathPrefix string, resourceData *schema.ResourceData, ops []patch.PatchOperation) patch.PatchOperations {
-	return k8s.AppendPatchOps(keyPrefix+"metadata.0.", pathPrefix+"/metadata/", resourceData, ops)
+// AppendPatchOps appends the operations turning the live virtual machine into the one of the
+// resource data, for the fields that changed in the resource data.
+func AppendPatchOps(keyPrefix, pathPrefix string, resourceData *schema.ResourceData, live *kubevirtapiv1.VirtualMachine, ops []patch.PatchOperation) (patch.PatchOperations, error) {
+	ops = k8s.AppendPatchOps(keyPrefix+"metadata.0.", pathPrefix+"/metadata/", resourceData, ops)
+	return appendVirtualMachineSpecPatchOps(keyPrefix+"spec.0.", pathPrefix+"/spec/", resourceData, live.Spec, ops)
 }
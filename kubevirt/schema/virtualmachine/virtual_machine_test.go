@@ -1,12 +1,16 @@
 package virtualmachine
 
 import (
+	"context"
 	"testing"
 
+	k8sv1 "k8s.io/api/core/v1"
 	kubevirtapiv1 "kubevirt.io/api/core/v1"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
 	"github.com/nrp-nautilus/terraform-provider-kubevirt/kubevirt/test_utils"
+	"github.com/nrp-nautilus/terraform-provider-kubevirt/kubevirt/utils/patch"
 
 	"github.com/nrp-nautilus/terraform-provider-kubevirt/kubevirt/test_utils/expand_utils"
 	"github.com/nrp-nautilus/terraform-provider-kubevirt/kubevirt/test_utils/flatten_utils"
@@ -127,6 +131,314 @@ func TestFlattenVirtualMachineSpec(t *testing.T) {
 	}
 }
 
+func TestAppendPatchOps(t *testing.T) {
+	cases := []struct {
+		name          string
+		modifier      func(map[string]interface{})
+		expectedPaths map[string]string
+	}{
+		{
+			name:          "no change",
+			expectedPaths: map[string]string{},
+		},
+		{
+			name: "run strategy",
+			modifier: func(raw map[string]interface{}) {
+				getRawSpec(raw)["run_strategy"] = "Halted"
+			},
+			expectedPaths: map[string]string{
+				"/spec/runStrategy": "replace",
+			},
+		},
+		{
+			name: "labels",
+			modifier: func(raw map[string]interface{}) {
+				raw["metadata"].([]interface{})[0].(map[string]interface{})["labels"] = map[string]interface{}{
+					"app": "test",
+				}
+			},
+			expectedPaths: map[string]string{
+				"/metadata/labels": "add",
+			},
+		},
+		{
+			name: "template spec",
+			modifier: func(raw map[string]interface{}) {
+				getRawTemplate(raw)["spec"].([]interface{})[0].(map[string]interface{})["hostname"] = "other"
+			},
+			expectedPaths: map[string]string{
+				"/spec/template/spec/hostname": "replace",
+			},
+		},
+		{
+			name: "template spec nested field",
+			modifier: func(raw map[string]interface{}) {
+				disk := getRawTemplateDomain(raw)["devices"].([]interface{})[0].(map[string]interface{})["disk"].([]interface{})[0].(map[string]interface{})
+				disk["disk_device"].([]interface{})[0].(map[string]interface{})["disk"].([]interface{})[0].(map[string]interface{})["bus"] = "sata"
+			},
+			expectedPaths: map[string]string{
+				"/spec/template/spec/domain/devices/disks/0/disk/bus": "replace",
+			},
+		},
+		{
+			name: "template spec removed and added fields",
+			modifier: func(raw map[string]interface{}) {
+				delete(getRawTemplate(raw)["spec"].([]interface{})[0].(map[string]interface{}), "hostname")
+				getRawTemplateDomain(raw)["resources"].([]interface{})[0].(map[string]interface{})["limits"] = map[string]interface{}{
+					"memory": "2Gi",
+				}
+			},
+			expectedPaths: map[string]string{
+				"/spec/template/spec/hostname":                "remove",
+				"/spec/template/spec/domain/resources/limits": "add",
+			},
+		},
+		{
+			name: "template annotations",
+			modifier: func(raw map[string]interface{}) {
+				getRawTemplate(raw)["metadata"].([]interface{})[0].(map[string]interface{})["annotations"] = map[string]interface{}{
+					"key": "value",
+				}
+			},
+			expectedPaths: map[string]string{
+				"/spec/template/metadata/annotations": "add",
+			},
+		},
+		{
+			name: "data volume templates",
+			modifier: func(raw map[string]interface{}) {
+				dataVolume := getRawSpec(raw)["data_volume_templates"].([]interface{})[0].(map[string]interface{})
+				dataVolume["metadata"].([]interface{})[0].(map[string]interface{})["name"] = "other-bootvolume"
+			},
+			expectedPaths: map[string]string{
+				"/spec/dataVolumeTemplates/0/metadata/name": "replace",
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			newRaw := getRawVirtualMachine()
+			if tc.modifier != nil {
+				tc.modifier(newRaw)
+			}
+			resourceData := resourceDataWithChange(t, getRawVirtualMachine(), newRaw)
+			live, err := FromResourceData(schema.TestResourceDataRaw(t, VirtualMachineFields(), getRawVirtualMachine()))
+			assert.NilError(t, err)
+
+			ops, err := AppendPatchOps("", "", resourceData, live, make([]patch.PatchOperation, 0, 0))
+			assert.NilError(t, err)
+
+			paths := make(map[string]string)
+			for _, op := range ops {
+				switch op.(type) {
+				case *patch.AddOperation:
+					paths[op.GetPath()] = "add"
+				case *patch.ReplaceOperation:
+					paths[op.GetPath()] = "replace"
+				case *patch.RemoveOperation:
+					paths[op.GetPath()] = "remove"
+				}
+			}
+			assert.Equal(t, len(ops), len(tc.expectedPaths))
+			assert.DeepEqual(t, paths, tc.expectedPaths)
+		})
+	}
+}
+
+func TestWaitForSatisfied(t *testing.T) {
+	waitFor := ExpandWaitFor([]interface{}{
+		map[string]interface{}{
+			"conditions":       []interface{}{"Ready", "AgentConnected"},
+			"printable_status": "Running",
+		},
+	})
+
+	cases := []struct {
+		name     string
+		status   kubevirtapiv1.VirtualMachineStatus
+		expected bool
+	}{
+		{
+			name:     "no status",
+			expected: false,
+		},
+		{
+			name: "running without agent",
+			status: kubevirtapiv1.VirtualMachineStatus{
+				PrintableStatus: kubevirtapiv1.VirtualMachineStatusRunning,
+				Conditions: []kubevirtapiv1.VirtualMachineCondition{
+					{Type: "Ready", Status: k8sv1.ConditionTrue},
+					{Type: "AgentConnected", Status: k8sv1.ConditionFalse},
+				},
+			},
+			expected: false,
+		},
+		{
+			name: "starting with all conditions",
+			status: kubevirtapiv1.VirtualMachineStatus{
+				PrintableStatus: kubevirtapiv1.VirtualMachineStatusStarting,
+				Conditions: []kubevirtapiv1.VirtualMachineCondition{
+					{Type: "Ready", Status: k8sv1.ConditionTrue},
+					{Type: "AgentConnected", Status: k8sv1.ConditionTrue},
+				},
+			},
+			expected: false,
+		},
+		{
+			name: "running with all conditions",
+			status: kubevirtapiv1.VirtualMachineStatus{
+				PrintableStatus: kubevirtapiv1.VirtualMachineStatusRunning,
+				Conditions: []kubevirtapiv1.VirtualMachineCondition{
+					{Type: "Ready", Status: k8sv1.ConditionTrue},
+					{Type: "AgentConnected", Status: k8sv1.ConditionTrue},
+				},
+			},
+			expected: true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			vm := &kubevirtapiv1.VirtualMachine{Status: tc.status}
+			assert.Equal(t, waitFor.Satisfied(vm), tc.expected)
+		})
+	}
+}
+
+func TestWaitForPrintableStatusValidation(t *testing.T) {
+	cases := []struct {
+		printableStatus string
+		expectedErrors  int
+	}{
+		{printableStatus: "Running"},
+		{printableStatus: "Stopped"},
+		{printableStatus: "WaitingForVolumeBinding"},
+		{printableStatus: "running", expectedErrors: 1},
+		{printableStatus: "Runing", expectedErrors: 1},
+	}
+
+	validate := waitForFields()["printable_status"].ValidateFunc
+	for _, tc := range cases {
+		t.Run(tc.printableStatus, func(t *testing.T) {
+			_, errs := validate(tc.printableStatus, "printable_status")
+			assert.Equal(t, len(errs), tc.expectedErrors)
+		})
+	}
+}
+
+func resourceDataWithChange(t *testing.T, oldRaw, newRaw map[string]interface{}) *schema.ResourceData {
+	fields := VirtualMachineFields()
+
+	old := schema.TestResourceDataRaw(t, fields, oldRaw)
+	old.SetId("default/test-vm")
+
+	diff, err := schema.InternalMap(fields).Diff(context.Background(), old.State(), terraform.NewResourceConfigRaw(newRaw), nil, nil, true)
+	assert.NilError(t, err)
+	resourceData, err := schema.InternalMap(fields).Data(old.State(), diff)
+	assert.NilError(t, err)
+
+	return resourceData
+}
+
+func getRawSpec(raw map[string]interface{}) map[string]interface{} {
+	return raw["spec"].([]interface{})[0].(map[string]interface{})
+}
+
+func getRawTemplate(raw map[string]interface{}) map[string]interface{} {
+	return getRawSpec(raw)["template"].([]interface{})[0].(map[string]interface{})
+}
+
+func getRawTemplateDomain(raw map[string]interface{}) map[string]interface{} {
+	return getRawTemplate(raw)["spec"].([]interface{})[0].(map[string]interface{})["domain"].([]interface{})[0].(map[string]interface{})
+}
+
+func getRawVirtualMachine() map[string]interface{} {
+	return map[string]interface{}{
+		"metadata": []interface{}{
+			map[string]interface{}{
+				"name":      "test-vm",
+				"namespace": "default",
+			},
+		},
+		"spec": []interface{}{
+			map[string]interface{}{
+				"run_strategy": "Always",
+				"data_volume_templates": []interface{}{
+					map[string]interface{}{
+						"metadata": []interface{}{
+							map[string]interface{}{
+								"name": "test-vm-bootvolume",
+							},
+						},
+						"spec": []interface{}{
+							map[string]interface{}{
+								"pvc": []interface{}{
+									map[string]interface{}{
+										"access_modes": []interface{}{"ReadWriteOnce"},
+										"resources": []interface{}{
+											map[string]interface{}{
+												"requests": map[string]interface{}{
+													"storage": "10Gi",
+												},
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+				"template": []interface{}{
+					map[string]interface{}{
+						"metadata": []interface{}{
+							map[string]interface{}{
+								"labels": map[string]interface{}{
+									"kubevirt.io/vm": "test-vm",
+								},
+							},
+						},
+						"spec": []interface{}{
+							map[string]interface{}{
+								"hostname": "test-vm",
+								"domain": []interface{}{
+									map[string]interface{}{
+										"resources": []interface{}{
+											map[string]interface{}{
+												"requests": map[string]interface{}{
+													"memory": "1Gi",
+												},
+											},
+										},
+										"devices": []interface{}{
+											map[string]interface{}{
+												"disk": []interface{}{
+													map[string]interface{}{
+														"name": "rootdisk",
+														"disk_device": []interface{}{
+															map[string]interface{}{
+																"disk": []interface{}{
+																	map[string]interface{}{
+																		"bus": "virtio",
+																	},
+																},
+															},
+														},
+													},
+												},
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
 func nullifyUncomparableFields(output *[]interface{}) {
 	accessModes := (*output)[0].(map[string]interface{})["data_volume_templates"].([]interface{})[0].(map[string]interface{})["spec"].([]interface{})[0].(map[string]interface{})["pvc"].([]interface{})[0].(map[string]interface{})["access_modes"]
 	test_utils.NullifySchemaSetFunction(accessModes.(*schema.Set))
@@ -0,0 +1,108 @@
+package virtualmachine
+
+import (
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	k8sv1 "k8s.io/api/core/v1"
+	kubevirtapiv1 "kubevirt.io/api/core/v1"
+)
+
+func waitForFields() map[string]*schema.Schema {
+	return map[string]*schema.Schema{
+		"conditions": {
+			Type:        schema.TypeList,
+			Description: "Condition types that have to report status \"True\" on the virtual machine, e.g. Ready or AgentConnected.",
+			Optional:    true,
+			Elem:        &schema.Schema{Type: schema.TypeString},
+		},
+		"printable_status": {
+			Type:        schema.TypeString,
+			Description: "Printable status the virtual machine has to report, e.g. Running or Stopped.",
+			Optional:    true,
+			ValidateFunc: validation.StringInSlice([]string{
+				string(kubevirtapiv1.VirtualMachineStatusStopped),
+				string(kubevirtapiv1.VirtualMachineStatusProvisioning),
+				string(kubevirtapiv1.VirtualMachineStatusStarting),
+				string(kubevirtapiv1.VirtualMachineStatusRunning),
+				string(kubevirtapiv1.VirtualMachineStatusPaused),
+				string(kubevirtapiv1.VirtualMachineStatusStopping),
+				string(kubevirtapiv1.VirtualMachineStatusTerminating),
+				string(kubevirtapiv1.VirtualMachineStatusCrashLoopBackOff),
+				string(kubevirtapiv1.VirtualMachineStatusMigrating),
+				string(kubevirtapiv1.VirtualMachineStatusUnknown),
+				string(kubevirtapiv1.VirtualMachineStatusUnschedulable),
+				string(kubevirtapiv1.VirtualMachineStatusErrImagePull),
+				string(kubevirtapiv1.VirtualMachineStatusImagePullBackOff),
+				string(kubevirtapiv1.VirtualMachineStatusPvcNotFound),
+				string(kubevirtapiv1.VirtualMachineStatusDataVolumeError),
+				string(kubevirtapiv1.VirtualMachineStatusWaitingForVolumeBinding),
+			}, false),
+		},
+	}
+}
+
+func waitForSchema() *schema.Schema {
+	fields := waitForFields()
+
+	return &schema.Schema{
+		Type:        schema.TypeList,
+		Description: "Wait for the virtual machine to reach the given state before considering it created or updated. Waiting is bound by the create and update timeouts.",
+		Optional:    true,
+		MaxItems:    1,
+		Elem: &schema.Resource{
+			Schema: fields,
+		},
+	}
+
+}
+
+// WaitFor describes the state a virtual machine has to reach before it's considered created or updated.
+type WaitFor struct {
+	Conditions      []kubevirtapiv1.VirtualMachineConditionType
+	PrintableStatus kubevirtapiv1.VirtualMachinePrintableStatus
+}
+
+func ExpandWaitFor(waitFor []interface{}) *WaitFor {
+	if len(waitFor) == 0 || waitFor[0] == nil {
+		return nil
+	}
+
+	result := &WaitFor{}
+
+	in := waitFor[0].(map[string]interface{})
+
+	if v, ok := in["conditions"].([]interface{}); ok {
+		for _, condition := range v {
+			if condition != nil {
+				result.Conditions = append(result.Conditions, kubevirtapiv1.VirtualMachineConditionType(condition.(string)))
+			}
+		}
+	}
+	if v, ok := in["printable_status"].(string); ok {
+		result.PrintableStatus = kubevirtapiv1.VirtualMachinePrintableStatus(v)
+	}
+
+	return result
+}
+
+// Satisfied reports whether the virtual machine reached the awaited state.
+func (w *WaitFor) Satisfied(vm *kubevirtapiv1.VirtualMachine) bool {
+	if w.PrintableStatus != "" && vm.Status.PrintableStatus != w.PrintableStatus {
+		return false
+	}
+	for _, conditionType := range w.Conditions {
+		if !hasTrueCondition(vm.Status.Conditions, conditionType) {
+			return false
+		}
+	}
+	return true
+}
+
+func hasTrueCondition(conditions []kubevirtapiv1.VirtualMachineCondition, conditionType kubevirtapiv1.VirtualMachineConditionType) bool {
+	for _, condition := range conditions {
+		if condition.Type == conditionType {
+			return condition.Status == k8sv1.ConditionTrue
+		}
+	}
+	return false
+}
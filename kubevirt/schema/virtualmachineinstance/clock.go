@@ -0,0 +1,281 @@
+package virtualmachineinstance
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	kubevirtapiv1 "kubevirt.io/api/core/v1"
+)
+
+func clockFields() map[string]*schema.Schema {
+	return map[string]*schema.Schema{
+		"utc": {
+			Type:        schema.TypeList,
+			Description: "UTC sets the guest clock to UTC on each boot. If an offset is specified, guest changes to the clock will be kept during reboots and are not reset.",
+			Optional:    true,
+			MaxItems:    1,
+			Elem: &schema.Resource{
+				Schema: map[string]*schema.Schema{
+					"offset_seconds": {
+						Type:        schema.TypeInt,
+						Description: "OffsetSeconds specifies an offset in seconds, relative to UTC. If set, guest changes to the clock will be kept during reboots and not reset.",
+						Optional:    true,
+					},
+				},
+			},
+		},
+		"timezone": {
+			Type:        schema.TypeString,
+			Description: "Timezone sets the guest clock to the specified timezone. Zone name follows the TZ environment variable format (e.g. 'America/New_York').",
+			Optional:    true,
+		},
+		"timer": {
+			Type:        schema.TypeList,
+			Description: "Timer specifies which timers are attached to the vmi.",
+			Optional:    true,
+			MaxItems:    1,
+			Elem: &schema.Resource{
+				Schema: map[string]*schema.Schema{
+					"hpet": timerSchema("HPET (High Precision Event Timer) - multiple timers with periodic interrupts.", []string{
+						string(kubevirtapiv1.HPETTickPolicyDelay),
+						string(kubevirtapiv1.HPETTickPolicyCatchup),
+						string(kubevirtapiv1.HPETTickPolicyMerge),
+						string(kubevirtapiv1.HPETTickPolicyDiscard),
+					}),
+					"kvm": timerSchema("KVM (KVM clock) - lets guests read the host's wall clock time (paravirtualized). For linux guests.", nil),
+					"pit": timerSchema("PIT (Programmable Interval Timer) - a timer with periodic interrupts.", []string{
+						string(kubevirtapiv1.PITTickPolicyDelay),
+						string(kubevirtapiv1.PITTickPolicyCatchup),
+						string(kubevirtapiv1.PITTickPolicyDiscard),
+					}),
+					"rtc":    rtcTimerSchema(),
+					"hyperv": timerSchema("Hyperv (Hypervclock) - lets guests read the host's wall clock time (paravirtualized). For windows guests.", nil),
+				},
+			},
+		},
+	}
+}
+
+func clockSchema() *schema.Schema {
+	fields := clockFields()
+
+	return &schema.Schema{
+		Type:        schema.TypeList,
+		Description: "Clock sets the clock and timers of the vmi. At most one of utc or timezone may be set.",
+		Optional:    true,
+		MaxItems:    1,
+		Elem: &schema.Resource{
+			Schema: fields,
+		},
+	}
+}
+
+// timerSchema returns the schema of a timer, with a tick_policy attribute when the timer supports any.
+func timerSchema(description string, tickPolicies []string) *schema.Schema {
+	fields := map[string]*schema.Schema{
+		"present": {
+			Type:        schema.TypeBool,
+			Description: "Enabled set to false makes sure that the machine type or a preset can't add the timer. Defaults to true.",
+			Optional:    true,
+			Default:     true,
+		},
+	}
+	if len(tickPolicies) > 0 {
+		fields["tick_policy"] = &schema.Schema{
+			Type:         schema.TypeString,
+			Description:  fmt.Sprintf("TickPolicy determines what happens when QEMU misses a deadline for injecting a tick to the guest. One of %q.", tickPolicies),
+			Optional:     true,
+			ValidateFunc: validation.StringInSlice(tickPolicies, false),
+		}
+	}
+
+	return &schema.Schema{
+		Type:        schema.TypeList,
+		Description: description,
+		Optional:    true,
+		MaxItems:    1,
+		Elem: &schema.Resource{
+			Schema: fields,
+		},
+	}
+}
+
+func rtcTimerSchema() *schema.Schema {
+	result := timerSchema("RTC (Real Time Clock) - a continuously running timer with periodic interrupts.", []string{
+		string(kubevirtapiv1.RTCTickPolicyDelay),
+		string(kubevirtapiv1.RTCTickPolicyCatchup),
+	})
+	result.Elem.(*schema.Resource).Schema["track"] = &schema.Schema{
+		Type:        schema.TypeString,
+		Description: "Track the guest or the wall clock.",
+		Optional:    true,
+		ValidateFunc: validation.StringInSlice([]string{
+			string(kubevirtapiv1.TrackGuest),
+			string(kubevirtapiv1.TrackWall),
+		}, false),
+	}
+
+	return result
+}
+
+func expandClock(clock []interface{}) (*kubevirtapiv1.Clock, error) {
+	if len(clock) == 0 || clock[0] == nil {
+		return nil, nil
+	}
+
+	result := &kubevirtapiv1.Clock{}
+
+	in := clock[0].(map[string]interface{})
+
+	if v, ok := in["utc"].([]interface{}); ok && len(v) > 0 {
+		result.UTC = &kubevirtapiv1.ClockOffsetUTC{}
+		if utc, ok := v[0].(map[string]interface{}); ok {
+			if offset, ok := utc["offset_seconds"].(int); ok && offset != 0 {
+				result.UTC.OffsetSeconds = &offset
+			}
+		}
+	}
+	if v, ok := in["timezone"].(string); ok && v != "" {
+		timezone := kubevirtapiv1.ClockOffsetTimezone(v)
+		result.Timezone = &timezone
+	}
+	if result.UTC != nil && result.Timezone != nil {
+		return result, fmt.Errorf("at most one of utc or timezone may be set in the clock")
+	}
+	if v, ok := in["timer"].([]interface{}); ok {
+		result.Timer = expandTimer(v)
+	}
+
+	return result, nil
+}
+
+func expandTimer(timer []interface{}) *kubevirtapiv1.Timer {
+	if len(timer) == 0 {
+		return nil
+	}
+
+	result := &kubevirtapiv1.Timer{}
+
+	in, ok := timer[0].(map[string]interface{})
+	if !ok {
+		return result
+	}
+
+	if v, ok := in["hpet"].([]interface{}); ok && len(v) > 0 {
+		present, tickPolicy, _ := expandTimerSettings(v[0])
+		result.HPET = &kubevirtapiv1.HPETTimer{
+			Enabled:    present,
+			TickPolicy: kubevirtapiv1.HPETTickPolicy(tickPolicy),
+		}
+	}
+	if v, ok := in["kvm"].([]interface{}); ok && len(v) > 0 {
+		present, _, _ := expandTimerSettings(v[0])
+		result.KVM = &kubevirtapiv1.KVMTimer{
+			Enabled: present,
+		}
+	}
+	if v, ok := in["pit"].([]interface{}); ok && len(v) > 0 {
+		present, tickPolicy, _ := expandTimerSettings(v[0])
+		result.PIT = &kubevirtapiv1.PITTimer{
+			Enabled:    present,
+			TickPolicy: kubevirtapiv1.PITTickPolicy(tickPolicy),
+		}
+	}
+	if v, ok := in["rtc"].([]interface{}); ok && len(v) > 0 {
+		present, tickPolicy, track := expandTimerSettings(v[0])
+		result.RTC = &kubevirtapiv1.RTCTimer{
+			Enabled:    present,
+			TickPolicy: kubevirtapiv1.RTCTickPolicy(tickPolicy),
+			Track:      kubevirtapiv1.RTCTimerTrack(track),
+		}
+	}
+	if v, ok := in["hyperv"].([]interface{}); ok && len(v) > 0 {
+		present, _, _ := expandTimerSettings(v[0])
+		result.Hyperv = &kubevirtapiv1.HypervTimer{
+			Enabled: present,
+		}
+	}
+
+	return result
+}
+
+// expandTimerSettings reads the attributes shared by the timers. An empty block
+// attaches the timer, matching the API default.
+func expandTimerSettings(timer interface{}) (*bool, string, string) {
+	present := true
+	tickPolicy := ""
+	track := ""
+
+	if in, ok := timer.(map[string]interface{}); ok {
+		if v, ok := in["present"].(bool); ok {
+			present = v
+		}
+		if v, ok := in["tick_policy"].(string); ok {
+			tickPolicy = v
+		}
+		if v, ok := in["track"].(string); ok {
+			track = v
+		}
+	}
+
+	return &present, tickPolicy, track
+}
+
+func flattenClock(in kubevirtapiv1.Clock) []interface{} {
+	att := make(map[string]interface{})
+
+	if in.UTC != nil {
+		utc := map[string]interface{}{
+			"offset_seconds": 0,
+		}
+		if in.UTC.OffsetSeconds != nil {
+			utc["offset_seconds"] = *in.UTC.OffsetSeconds
+		}
+		att["utc"] = []interface{}{utc}
+	}
+	if in.Timezone != nil {
+		att["timezone"] = string(*in.Timezone)
+	}
+	if in.Timer != nil {
+		att["timer"] = flattenTimer(*in.Timer)
+	}
+
+	return []interface{}{att}
+}
+
+func flattenTimer(in kubevirtapiv1.Timer) []interface{} {
+	att := make(map[string]interface{})
+
+	if in.HPET != nil {
+		att["hpet"] = []interface{}{map[string]interface{}{
+			"present":     flattenFeatureEnabled(in.HPET.Enabled),
+			"tick_policy": string(in.HPET.TickPolicy),
+		}}
+	}
+	if in.KVM != nil {
+		att["kvm"] = []interface{}{map[string]interface{}{
+			"present": flattenFeatureEnabled(in.KVM.Enabled),
+		}}
+	}
+	if in.PIT != nil {
+		att["pit"] = []interface{}{map[string]interface{}{
+			"present":     flattenFeatureEnabled(in.PIT.Enabled),
+			"tick_policy": string(in.PIT.TickPolicy),
+		}}
+	}
+	if in.RTC != nil {
+		att["rtc"] = []interface{}{map[string]interface{}{
+			"present":     flattenFeatureEnabled(in.RTC.Enabled),
+			"tick_policy": string(in.RTC.TickPolicy),
+			"track":       string(in.RTC.Track),
+		}}
+	}
+	if in.Hyperv != nil {
+		att["hyperv"] = []interface{}{map[string]interface{}{
+			"present": flattenFeatureEnabled(in.Hyperv.Enabled),
+		}}
+	}
+
+	return []interface{}{att}
+}
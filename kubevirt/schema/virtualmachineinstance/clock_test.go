@@ -0,0 +1,164 @@
+package virtualmachineinstance
+
+import (
+	"testing"
+
+	"github.com/nrp-nautilus/terraform-provider-kubevirt/kubevirt/utils"
+	kubevirtapiv1 "kubevirt.io/api/core/v1"
+
+	"gotest.tools/assert"
+)
+
+func TestExpandFlattenClock(t *testing.T) {
+	offset := 3600
+	timezone := kubevirtapiv1.ClockOffsetTimezone("Europe/Berlin")
+
+	cases := []struct {
+		name           string
+		input          []interface{}
+		expectedOutput *kubevirtapiv1.Clock
+	}{
+		{
+			name: "utc",
+			input: []interface{}{
+				map[string]interface{}{
+					"utc": []interface{}{
+						map[string]interface{}{
+							"offset_seconds": 0,
+						},
+					},
+				},
+			},
+			expectedOutput: &kubevirtapiv1.Clock{
+				ClockOffset: kubevirtapiv1.ClockOffset{
+					UTC: &kubevirtapiv1.ClockOffsetUTC{},
+				},
+			},
+		},
+		{
+			name: "utc with an offset",
+			input: []interface{}{
+				map[string]interface{}{
+					"utc": []interface{}{
+						map[string]interface{}{
+							"offset_seconds": 3600,
+						},
+					},
+				},
+			},
+			expectedOutput: &kubevirtapiv1.Clock{
+				ClockOffset: kubevirtapiv1.ClockOffset{
+					UTC: &kubevirtapiv1.ClockOffsetUTC{OffsetSeconds: &offset},
+				},
+			},
+		},
+		{
+			name: "timezone and timers",
+			input: []interface{}{
+				map[string]interface{}{
+					"timezone": "Europe/Berlin",
+					"timer": []interface{}{
+						map[string]interface{}{
+							"hpet": []interface{}{
+								map[string]interface{}{
+									"present":     true,
+									"tick_policy": "merge",
+								},
+							},
+							"kvm": []interface{}{
+								map[string]interface{}{
+									"present": true,
+								},
+							},
+							"pit": []interface{}{
+								map[string]interface{}{
+									"present":     false,
+									"tick_policy": "",
+								},
+							},
+							"rtc": []interface{}{
+								map[string]interface{}{
+									"present":     true,
+									"tick_policy": "delay",
+									"track":       "wall",
+								},
+							},
+						},
+					},
+				},
+			},
+			expectedOutput: &kubevirtapiv1.Clock{
+				ClockOffset: kubevirtapiv1.ClockOffset{
+					Timezone: &timezone,
+				},
+				Timer: &kubevirtapiv1.Timer{
+					HPET: &kubevirtapiv1.HPETTimer{
+						Enabled:    utils.PtrToBool(true),
+						TickPolicy: kubevirtapiv1.HPETTickPolicyMerge,
+					},
+					KVM: &kubevirtapiv1.KVMTimer{Enabled: utils.PtrToBool(true)},
+					PIT: &kubevirtapiv1.PITTimer{Enabled: utils.PtrToBool(false)},
+					RTC: &kubevirtapiv1.RTCTimer{
+						Enabled:    utils.PtrToBool(true),
+						TickPolicy: kubevirtapiv1.RTCTickPolicyDelay,
+						Track:      kubevirtapiv1.TrackWall,
+					},
+				},
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			output, err := expandClock(tc.input)
+
+			assert.NilError(t, err)
+			assert.DeepEqual(t, tc.expectedOutput, output)
+			assert.DeepEqual(t, tc.input, flattenClock(*output))
+		})
+	}
+}
+
+func TestExpandClockErrors(t *testing.T) {
+	cases := []struct {
+		name          string
+		input         map[string]interface{}
+		expectedError string
+	}{
+		{
+			name: "utc and timezone",
+			input: map[string]interface{}{
+				"utc":      []interface{}{nil},
+				"timezone": "Europe/Berlin",
+			},
+			expectedError: "at most one of utc or timezone may be set in the clock",
+		},
+		{
+			name: "utc offset and timezone",
+			input: map[string]interface{}{
+				"utc": []interface{}{
+					map[string]interface{}{
+						"offset_seconds": 3600,
+					},
+				},
+				"timezone": "Europe/Berlin",
+			},
+			expectedError: "at most one of utc or timezone may be set in the clock",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := expandClock([]interface{}{tc.input})
+
+			assert.Error(t, err, tc.expectedError)
+		})
+	}
+}
+
+func TestExpandNoClock(t *testing.T) {
+	output, err := expandClock([]interface{}{})
+
+	assert.NilError(t, err)
+	assert.Assert(t, output == nil)
+}
@@ -0,0 +1,183 @@
+package virtualmachineinstance
+
+import (
+	"fmt"
+
+	k8sv1 "k8s.io/api/core/v1"
+	kubevirtapiv1 "kubevirt.io/api/core/v1"
+)
+
+const (
+	// CloudInitSecretUserDataKey is the key of the user data in a Secret holding cloud-init data.
+	CloudInitSecretUserDataKey = "userdata"
+	// CloudInitSecretNetworkDataKey is the key of the network data in a Secret holding cloud-init data.
+	CloudInitSecretNetworkDataKey = "networkdata"
+)
+
+// cloudInitData points at the fields of a cloud-init source, so that the config drive
+// and NoCloud sources can be moved in and out of Secrets the same way.
+type cloudInitData struct {
+	userData             *string
+	networkData          *string
+	userDataSecretRef    **k8sv1.LocalObjectReference
+	networkDataSecretRef **k8sv1.LocalObjectReference
+}
+
+func volumeCloudInitData(in *kubevirtapiv1.VolumeSource) *cloudInitData {
+	if in.CloudInitConfigDrive != nil {
+		return &cloudInitData{
+			userData:             &in.CloudInitConfigDrive.UserData,
+			networkData:          &in.CloudInitConfigDrive.NetworkData,
+			userDataSecretRef:    &in.CloudInitConfigDrive.UserDataSecretRef,
+			networkDataSecretRef: &in.CloudInitConfigDrive.NetworkDataSecretRef,
+		}
+	}
+	if in.CloudInitNoCloud != nil {
+		return &cloudInitData{
+			userData:             &in.CloudInitNoCloud.UserData,
+			networkData:          &in.CloudInitNoCloud.NetworkData,
+			userDataSecretRef:    &in.CloudInitNoCloud.UserDataSecretRef,
+			networkDataSecretRef: &in.CloudInitNoCloud.NetworkDataSecretRef,
+		}
+	}
+
+	return nil
+}
+
+// CloudInitSecretVolumeNames returns the names of the volumes of a spec block with
+// store_as_secret set.
+func CloudInitSecretVolumeNames(spec []interface{}) []string {
+	if len(spec) == 0 || spec[0] == nil {
+		return nil
+	}
+
+	var names []string
+	volumes, _ := spec[0].(map[string]interface{})["volume"].([]interface{})
+	for _, v := range volumes {
+		volume, ok := v.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		for _, in := range rawCloudInitSources(volume) {
+			if storeAsSecret, _ := in["store_as_secret"].(bool); storeAsSecret {
+				names = append(names, volume["name"].(string))
+			}
+		}
+	}
+
+	return names
+}
+
+// SetCloudInitStoredAsSecret sets store_as_secret on the cloud-init blocks of the given
+// volumes of a flattened spec block.
+func SetCloudInitStoredAsSecret(spec []interface{}, volumeNames []string) {
+	if len(spec) == 0 || spec[0] == nil {
+		return
+	}
+
+	volumes, _ := spec[0].(map[string]interface{})["volume"].([]interface{})
+	for _, v := range volumes {
+		volume, ok := v.(map[string]interface{})
+		if !ok || !containsString(volumeNames, volume["name"].(string)) {
+			continue
+		}
+		for _, in := range rawCloudInitSources(volume) {
+			in["store_as_secret"] = true
+		}
+	}
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// CloudInitSecretName returns the name of the Secret holding the cloud-init data
+// of a volume with store_as_secret set.
+func CloudInitSecretName(ownerName string, volumeName string) string {
+	return fmt.Sprintf("%s-%s-cloud-init", ownerName, volumeName)
+}
+
+// ExtractCloudInitSecrets moves the data of the given cloud-init volumes out of the spec,
+// referencing the Secrets it returns instead.
+func ExtractCloudInitSecrets(ownerName string, spec *kubevirtapiv1.VirtualMachineInstanceSpec, volumeNames []string) []*k8sv1.Secret {
+	var secrets []*k8sv1.Secret
+
+	for i := range spec.Volumes {
+		if !containsString(volumeNames, spec.Volumes[i].Name) {
+			continue
+		}
+		data := volumeCloudInitData(&spec.Volumes[i].VolumeSource)
+		if data == nil {
+			continue
+		}
+
+		secret := &k8sv1.Secret{
+			Data: map[string][]byte{
+				CloudInitSecretUserDataKey: []byte(*data.userData),
+			},
+		}
+		secret.Name = CloudInitSecretName(ownerName, spec.Volumes[i].Name)
+		*data.userDataSecretRef = &k8sv1.LocalObjectReference{Name: secret.Name}
+		*data.userData = ""
+		if *data.networkData != "" {
+			secret.Data[CloudInitSecretNetworkDataKey] = []byte(*data.networkData)
+			*data.networkDataSecretRef = &k8sv1.LocalObjectReference{Name: secret.Name}
+			*data.networkData = ""
+		}
+
+		secrets = append(secrets, secret)
+	}
+
+	return secrets
+}
+
+// CloudInitSecretNames returns the names of the Secrets holding cloud-init data that
+// the spec references on behalf of volumes with store_as_secret set.
+func CloudInitSecretNames(ownerName string, spec kubevirtapiv1.VirtualMachineInstanceSpec) []string {
+	var names []string
+
+	for i := range spec.Volumes {
+		data := volumeCloudInitData(&spec.Volumes[i].VolumeSource)
+		if data == nil || *data.userDataSecretRef == nil {
+			continue
+		}
+		if name := CloudInitSecretName(ownerName, spec.Volumes[i].Name); (*data.userDataSecretRef).Name == name {
+			names = append(names, name)
+		}
+	}
+
+	return names
+}
+
+// InlineCloudInitSecrets reverses ExtractCloudInitSecrets with the Secrets read back from
+// the cluster, so that their data can be compared against the configuration. It returns
+// the names of the volumes whose data it put back.
+func InlineCloudInitSecrets(ownerName string, spec *kubevirtapiv1.VirtualMachineInstanceSpec, secrets map[string]*k8sv1.Secret) []string {
+	var volumeNames []string
+
+	for i := range spec.Volumes {
+		data := volumeCloudInitData(&spec.Volumes[i].VolumeSource)
+		if data == nil || *data.userDataSecretRef == nil {
+			continue
+		}
+		secret, ok := secrets[(*data.userDataSecretRef).Name]
+		if !ok || secret.Name != CloudInitSecretName(ownerName, spec.Volumes[i].Name) {
+			continue
+		}
+
+		*data.userData = string(secret.Data[CloudInitSecretUserDataKey])
+		*data.userDataSecretRef = nil
+		if *data.networkDataSecretRef != nil && (*data.networkDataSecretRef).Name == secret.Name {
+			*data.networkData = string(secret.Data[CloudInitSecretNetworkDataKey])
+			*data.networkDataSecretRef = nil
+		}
+		volumeNames = append(volumeNames, spec.Volumes[i].Name)
+	}
+
+	return volumeNames
+}
@@ -0,0 +1,99 @@
+package virtualmachineinstance
+
+import (
+	"testing"
+
+	k8sv1 "k8s.io/api/core/v1"
+	kubevirtapiv1 "kubevirt.io/api/core/v1"
+
+	"gotest.tools/assert"
+)
+
+func TestExtractInlineCloudInitSecrets(t *testing.T) {
+	volumes := []interface{}{
+		map[string]interface{}{
+			"name": "cloudinit",
+			"volume_source": []interface{}{
+				map[string]interface{}{
+					"cloud_init_no_cloud": []interface{}{
+						map[string]interface{}{
+							"user_data":       "#cloud-config",
+							"network_data":    "version: 2",
+							"store_as_secret": true,
+						},
+					},
+				},
+			},
+		},
+		map[string]interface{}{
+			"name": "configdrive",
+			"volume_source": []interface{}{
+				map[string]interface{}{
+					"cloud_init_config_drive": []interface{}{
+						map[string]interface{}{
+							"user_data": "#cloud-config",
+						},
+					},
+				},
+			},
+		},
+	}
+	expanded, err := expandVolumes(volumes)
+	assert.NilError(t, err)
+	// store_as_secret stays in the configuration, the expanded volume keeps its data inline.
+	assert.DeepEqual(t, &kubevirtapiv1.CloudInitNoCloudSource{UserData: "#cloud-config", NetworkData: "version: 2"}, expanded[0].CloudInitNoCloud)
+	spec := kubevirtapiv1.VirtualMachineInstanceSpec{Volumes: expanded}
+
+	volumeNames := CloudInitSecretVolumeNames([]interface{}{map[string]interface{}{"volume": volumes}})
+	assert.DeepEqual(t, []string{"cloudinit"}, volumeNames)
+	secrets := ExtractCloudInitSecrets("test-vm", &spec, volumeNames)
+
+	assert.DeepEqual(t, []*k8sv1.Secret{
+		{
+			ObjectMeta: secrets[0].ObjectMeta,
+			Data: map[string][]byte{
+				CloudInitSecretUserDataKey:    []byte("#cloud-config"),
+				CloudInitSecretNetworkDataKey: []byte("version: 2"),
+			},
+		},
+	}, secrets)
+	assert.Equal(t, "test-vm-cloudinit-cloud-init", secrets[0].Name)
+	assert.DeepEqual(t, &kubevirtapiv1.CloudInitNoCloudSource{
+		UserDataSecretRef:    &k8sv1.LocalObjectReference{Name: "test-vm-cloudinit-cloud-init"},
+		NetworkDataSecretRef: &k8sv1.LocalObjectReference{Name: "test-vm-cloudinit-cloud-init"},
+	}, spec.Volumes[0].CloudInitNoCloud)
+	assert.DeepEqual(t, &kubevirtapiv1.CloudInitConfigDriveSource{UserData: "#cloud-config"}, spec.Volumes[1].CloudInitConfigDrive)
+	assert.DeepEqual(t, []string{"test-vm-cloudinit-cloud-init"}, CloudInitSecretNames("test-vm", spec))
+
+	// Inlining the secrets read back has to yield the configured volumes, so that plans stay clean.
+	assert.DeepEqual(t, volumeNames, InlineCloudInitSecrets("test-vm", &spec, map[string]*k8sv1.Secret{secrets[0].Name: secrets[0]}))
+	assert.DeepEqual(t, expanded, spec.Volumes)
+	flattened := []interface{}{map[string]interface{}{"volume": flattenVolumes(spec.Volumes)}}
+	SetCloudInitStoredAsSecret(flattened, volumeNames)
+	assert.DeepEqual(t, volumeNames, CloudInitSecretVolumeNames(flattened))
+	output, err := expandVolumes(flattened[0].(map[string]interface{})["volume"].([]interface{}))
+	assert.NilError(t, err)
+	assert.DeepEqual(t, expanded, output)
+}
+
+func TestInlineCloudInitSecretsIgnoresForeignSecrets(t *testing.T) {
+	volumes := []kubevirtapiv1.Volume{
+		{
+			Name: "cloudinit",
+			VolumeSource: kubevirtapiv1.VolumeSource{
+				CloudInitNoCloud: &kubevirtapiv1.CloudInitNoCloudSource{
+					UserDataSecretRef: &k8sv1.LocalObjectReference{Name: "userdata"},
+				},
+			},
+		},
+	}
+	spec := kubevirtapiv1.VirtualMachineInstanceSpec{Volumes: volumes}
+	secret := &k8sv1.Secret{Data: map[string][]byte{CloudInitSecretUserDataKey: []byte("#cloud-config")}}
+	secret.Name = "userdata"
+
+	// Secrets the user references themselves aren't managed by the provider.
+	assert.Assert(t, CloudInitSecretNames("test-vm", spec) == nil)
+	assert.Assert(t, InlineCloudInitSecrets("test-vm", &spec, map[string]*k8sv1.Secret{secret.Name: secret}) == nil)
+	assert.Equal(t, "userdata", spec.Volumes[0].CloudInitNoCloud.UserDataSecretRef.Name)
+	assert.Equal(t, "", spec.Volumes[0].CloudInitNoCloud.UserData)
+}
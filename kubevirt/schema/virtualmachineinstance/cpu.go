@@ -0,0 +1,259 @@
+package virtualmachineinstance
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"github.com/nrp-nautilus/terraform-provider-kubevirt/kubevirt/utils"
+	kubevirtapiv1 "kubevirt.io/api/core/v1"
+)
+
+func cpuFields() map[string]*schema.Schema {
+	return map[string]*schema.Schema{
+		"cores": {
+			Type:         schema.TypeInt,
+			Description:  "Cores specifies the number of cores inside the vmi. Must be a value greater or equal 1.",
+			Optional:     true,
+			ValidateFunc: utils.ValidateNonNegativeInteger,
+		},
+		"sockets": {
+			Type:         schema.TypeInt,
+			Description:  "Sockets specifies the number of sockets inside the vmi. Must be a value greater or equal 1.",
+			Optional:     true,
+			ValidateFunc: utils.ValidateNonNegativeInteger,
+		},
+		"threads": {
+			Type:         schema.TypeInt,
+			Description:  "Threads specifies the number of threads inside the vmi. Must be a value greater or equal 1.",
+			Optional:     true,
+			ValidateFunc: utils.ValidateNonNegativeInteger,
+		},
+		"model": {
+			Type:        schema.TypeString,
+			Description: "Model specifies the CPU model inside the VMI, e.g. host-passthrough, host-model or a named model such as Conroe. Defaults to the cluster-wide default model.",
+			Optional:    true,
+		},
+		"feature": {
+			Type:        schema.TypeList,
+			Description: "Features specifies the CPU features list inside the VMI.",
+			Optional:    true,
+			Elem: &schema.Resource{
+				Schema: map[string]*schema.Schema{
+					"name": {
+						Type:        schema.TypeString,
+						Description: "Name of the CPU feature.",
+						Required:    true,
+					},
+					"policy": {
+						Type:        schema.TypeString,
+						Description: "Policy is the CPU feature attribute which can have the following attributes: force, require, optional, disable or forbid. Defaults to require.",
+						Optional:    true,
+						ValidateFunc: validation.StringInSlice([]string{
+							"force",
+							"require",
+							"optional",
+							"disable",
+							"forbid",
+						}, false),
+					},
+				},
+			},
+		},
+		"dedicated_cpu_placement": {
+			Type:        schema.TypeBool,
+			Description: "DedicatedCPUPlacement requests the scheduler to place the VirtualMachineInstance on a node with enough dedicated pCPUs and pin the vCPUs to them.",
+			Optional:    true,
+		},
+		"isolate_emulator_thread": {
+			Type:        schema.TypeBool,
+			Description: "IsolateEmulatorThread requests one more dedicated pCPU to be allocated for the VMI to place the emulator thread on it. Requires dedicated_cpu_placement.",
+			Optional:    true,
+		},
+		"numa": {
+			Type:        schema.TypeList,
+			Description: "NUMA allows specifying settings for the guest NUMA topology. Requires dedicated_cpu_placement.",
+			Optional:    true,
+			MaxItems:    1,
+			Elem: &schema.Resource{
+				Schema: map[string]*schema.Schema{
+					"guest_mapping_passthrough": {
+						Type:        schema.TypeList,
+						Description: "GuestMappingPassthrough will create an efficient guest topology based on host CPUs exclusively assigned to a pod. The created topology ensures that memory and CPUs on the virtual numa nodes never cross boundaries of host numa nodes. Requires hugepages.",
+						Optional:    true,
+						MaxItems:    1,
+						Elem: &schema.Resource{
+							Schema: map[string]*schema.Schema{},
+						},
+					},
+				},
+			},
+		},
+		"realtime": {
+			Type:        schema.TypeList,
+			Description: "Realtime instructs the virt-launcher to tune the VMI for lower latency. Requires dedicated_cpu_placement.",
+			Optional:    true,
+			MaxItems:    1,
+			Elem: &schema.Resource{
+				Schema: map[string]*schema.Schema{
+					"mask": {
+						Type:        schema.TypeString,
+						Description: "Mask defines the vcpu mask expression that defines which vcpus are used for realtime, e.g. \"0-3,^1\". Defaults to all vcpus.",
+						Optional:    true,
+					},
+				},
+			},
+		},
+	}
+}
+
+func cpuSchema() *schema.Schema {
+	fields := cpuFields()
+
+	return &schema.Schema{
+		Type:        schema.TypeList,
+		Description: "CPU allows specifying the CPU topology, model, features and placement of the vmi.",
+		Optional:    true,
+		MaxItems:    1,
+		Elem: &schema.Resource{
+			Schema: fields,
+		},
+	}
+}
+
+func expandCPU(cpu []interface{}) (*kubevirtapiv1.CPU, error) {
+	if len(cpu) == 0 || cpu[0] == nil {
+		return nil, nil
+	}
+
+	result := &kubevirtapiv1.CPU{}
+
+	in := cpu[0].(map[string]interface{})
+
+	if v, ok := in["cores"].(int); ok {
+		result.Cores = uint32(v)
+	}
+	if v, ok := in["sockets"].(int); ok {
+		result.Sockets = uint32(v)
+	}
+	if v, ok := in["threads"].(int); ok {
+		result.Threads = uint32(v)
+	}
+	if v, ok := in["model"].(string); ok {
+		result.Model = v
+	}
+	if v, ok := in["feature"].([]interface{}); ok {
+		result.Features = expandCPUFeatures(v)
+	}
+	if v, ok := in["dedicated_cpu_placement"].(bool); ok {
+		result.DedicatedCPUPlacement = v
+	}
+	if v, ok := in["isolate_emulator_thread"].(bool); ok {
+		result.IsolateEmulatorThread = v
+	}
+	if v, ok := in["numa"].([]interface{}); ok {
+		result.NUMA = expandNUMA(v)
+	}
+	if v, ok := in["realtime"].([]interface{}); ok && len(v) > 0 {
+		result.Realtime = &kubevirtapiv1.Realtime{}
+		if realtime, ok := v[0].(map[string]interface{}); ok {
+			if mask, ok := realtime["mask"].(string); ok {
+				result.Realtime.Mask = mask
+			}
+		}
+	}
+
+	if !result.DedicatedCPUPlacement {
+		if result.IsolateEmulatorThread {
+			return result, fmt.Errorf("isolate_emulator_thread requires dedicated_cpu_placement")
+		}
+		if result.NUMA != nil && result.NUMA.GuestMappingPassthrough != nil {
+			return result, fmt.Errorf("numa guest_mapping_passthrough requires dedicated_cpu_placement")
+		}
+		if result.Realtime != nil {
+			return result, fmt.Errorf("realtime requires dedicated_cpu_placement")
+		}
+	}
+
+	return result, nil
+}
+
+func expandCPUFeatures(features []interface{}) []kubevirtapiv1.CPUFeature {
+	result := make([]kubevirtapiv1.CPUFeature, 0, len(features))
+
+	for _, feature := range features {
+		in, ok := feature.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		f := kubevirtapiv1.CPUFeature{}
+		if v, ok := in["name"].(string); ok {
+			f.Name = v
+		}
+		if v, ok := in["policy"].(string); ok {
+			f.Policy = v
+		}
+		result = append(result, f)
+	}
+
+	return result
+}
+
+func expandNUMA(numa []interface{}) *kubevirtapiv1.NUMA {
+	if len(numa) == 0 {
+		return nil
+	}
+
+	result := &kubevirtapiv1.NUMA{}
+	in, ok := numa[0].(map[string]interface{})
+	if !ok {
+		return result
+	}
+
+	// The passthrough mapping has no parameters, so an empty block enables it.
+	if v, ok := in["guest_mapping_passthrough"].([]interface{}); ok && len(v) > 0 {
+		result.GuestMappingPassthrough = &kubevirtapiv1.NUMAGuestMappingPassthrough{}
+	}
+
+	return result
+}
+
+func flattenCPU(in kubevirtapiv1.CPU) []interface{} {
+	att := make(map[string]interface{})
+
+	att["cores"] = int(in.Cores)
+	att["sockets"] = int(in.Sockets)
+	att["threads"] = int(in.Threads)
+	att["model"] = in.Model
+	att["feature"] = flattenCPUFeatures(in.Features)
+	att["dedicated_cpu_placement"] = in.DedicatedCPUPlacement
+	att["isolate_emulator_thread"] = in.IsolateEmulatorThread
+	if in.NUMA != nil {
+		numa := make(map[string]interface{})
+		if in.NUMA.GuestMappingPassthrough != nil {
+			numa["guest_mapping_passthrough"] = []interface{}{map[string]interface{}{}}
+		}
+		att["numa"] = []interface{}{numa}
+	}
+	if in.Realtime != nil {
+		att["realtime"] = []interface{}{map[string]interface{}{
+			"mask": in.Realtime.Mask,
+		}}
+	}
+
+	return []interface{}{att}
+}
+
+func flattenCPUFeatures(in []kubevirtapiv1.CPUFeature) []interface{} {
+	att := make([]interface{}, len(in))
+
+	for i, v := range in {
+		c := make(map[string]interface{})
+		c["name"] = v.Name
+		c["policy"] = v.Policy
+		att[i] = c
+	}
+
+	return att
+}
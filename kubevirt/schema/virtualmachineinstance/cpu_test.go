@@ -0,0 +1,150 @@
+package virtualmachineinstance
+
+import (
+	"testing"
+
+	kubevirtapiv1 "kubevirt.io/api/core/v1"
+
+	"gotest.tools/assert"
+)
+
+func TestExpandCPUPlacement(t *testing.T) {
+	cases := []struct {
+		name          string
+		input         map[string]interface{}
+		expectedError string
+	}{
+		{
+			name: "dedicated placement with isolated emulator thread",
+			input: map[string]interface{}{
+				"dedicated_cpu_placement": true,
+				"isolate_emulator_thread": true,
+			},
+		},
+		{
+			name: "isolated emulator thread without dedicated placement",
+			input: map[string]interface{}{
+				"isolate_emulator_thread": true,
+			},
+			expectedError: "isolate_emulator_thread requires dedicated_cpu_placement",
+		},
+		{
+			name: "numa passthrough without dedicated placement",
+			input: map[string]interface{}{
+				"numa": []interface{}{
+					map[string]interface{}{
+						"guest_mapping_passthrough": []interface{}{nil},
+					},
+				},
+			},
+			expectedError: "numa guest_mapping_passthrough requires dedicated_cpu_placement",
+		},
+		{
+			name: "realtime without dedicated placement",
+			input: map[string]interface{}{
+				"realtime": []interface{}{nil},
+			},
+			expectedError: "realtime requires dedicated_cpu_placement",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := expandCPU([]interface{}{tc.input})
+
+			if tc.expectedError == "" {
+				assert.NilError(t, err)
+			} else {
+				assert.Error(t, err, tc.expectedError)
+			}
+		})
+	}
+}
+
+func TestExpandFlattenCPU(t *testing.T) {
+	cases := []struct {
+		name           string
+		input          []interface{}
+		expectedOutput *kubevirtapiv1.CPU
+	}{
+		{
+			name: "topology and model",
+			input: []interface{}{
+				map[string]interface{}{
+					"cores":   2,
+					"sockets": 1,
+					"threads": 2,
+					"model":   "host-passthrough",
+					"feature": []interface{}{
+						map[string]interface{}{
+							"name":   "vmx",
+							"policy": "require",
+						},
+						map[string]interface{}{
+							"name":   "svm",
+							"policy": "disable",
+						},
+					},
+					"dedicated_cpu_placement": false,
+					"isolate_emulator_thread": false,
+				},
+			},
+			expectedOutput: &kubevirtapiv1.CPU{
+				Cores:   2,
+				Sockets: 1,
+				Threads: 2,
+				Model:   "host-passthrough",
+				Features: []kubevirtapiv1.CPUFeature{
+					{Name: "vmx", Policy: "require"},
+					{Name: "svm", Policy: "disable"},
+				},
+			},
+		},
+		{
+			name: "dedicated placement",
+			input: []interface{}{
+				map[string]interface{}{
+					"cores":                   4,
+					"sockets":                 1,
+					"threads":                 1,
+					"model":                   "",
+					"feature":                 []interface{}{},
+					"dedicated_cpu_placement": true,
+					"isolate_emulator_thread": true,
+					"numa": []interface{}{
+						map[string]interface{}{
+							"guest_mapping_passthrough": []interface{}{map[string]interface{}{}},
+						},
+					},
+					"realtime": []interface{}{
+						map[string]interface{}{
+							"mask": "0-3,^1",
+						},
+					},
+				},
+			},
+			expectedOutput: &kubevirtapiv1.CPU{
+				Cores:                 4,
+				Sockets:               1,
+				Threads:               1,
+				Features:              []kubevirtapiv1.CPUFeature{},
+				DedicatedCPUPlacement: true,
+				IsolateEmulatorThread: true,
+				NUMA: &kubevirtapiv1.NUMA{
+					GuestMappingPassthrough: &kubevirtapiv1.NUMAGuestMappingPassthrough{},
+				},
+				Realtime: &kubevirtapiv1.Realtime{Mask: "0-3,^1"},
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			output, err := expandCPU(tc.input)
+
+			assert.NilError(t, err)
+			assert.DeepEqual(t, tc.expectedOutput, output)
+			assert.DeepEqual(t, tc.input, flattenCPU(*output))
+		})
+	}
+}
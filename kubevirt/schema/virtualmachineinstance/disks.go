@@ -0,0 +1,448 @@
+package virtualmachineinstance
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	kubevirtapiv1 "kubevirt.io/api/core/v1"
+)
+
+func diskFields() map[string]*schema.Schema {
+	return map[string]*schema.Schema{
+		"name": {
+			Type:        schema.TypeString,
+			Description: "Name is the device name",
+			Required:    true,
+		},
+		"disk_device": {
+			Type:        schema.TypeList,
+			Description: "DiskDevice specifies as which device the disk should be added to the guest. Exactly one of disk, lun or cdrom must be set.",
+			Required:    true,
+			MaxItems:    1,
+			Elem: &schema.Resource{
+				Schema: map[string]*schema.Schema{
+					"disk": {
+						Type:        schema.TypeList,
+						Description: "Attach a volume as a disk to the vmi.",
+						Optional:    true,
+						MaxItems:    1,
+						Elem: &schema.Resource{
+							Schema: map[string]*schema.Schema{
+								"bus": {
+									Type:        schema.TypeString,
+									Description: "Bus indicates the type of disk device to emulate.",
+									Required:    true,
+								},
+								"read_only": {
+									Type:        schema.TypeBool,
+									Description: "ReadOnly. Defaults to false.",
+									Optional:    true,
+								},
+								"pci_address": {
+									Type:        schema.TypeString,
+									Description: "If specified, the virtual disk will be placed on the guests pci address with the specifed PCI address. For example: 0000:81:01.10",
+									Optional:    true,
+								},
+							},
+						},
+					},
+					"lun": {
+						Type:        schema.TypeList,
+						Description: "Attach a volume as a LUN to the vmi.",
+						Optional:    true,
+						MaxItems:    1,
+						Elem: &schema.Resource{
+							Schema: map[string]*schema.Schema{
+								"bus": {
+									Type:        schema.TypeString,
+									Description: "Bus indicates the type of disk device to emulate. One of virtio, sata or scsi.",
+									Optional:    true,
+								},
+								"read_only": {
+									Type:        schema.TypeBool,
+									Description: "ReadOnly. Defaults to false.",
+									Optional:    true,
+								},
+							},
+						},
+					},
+					"cdrom": {
+						Type:        schema.TypeList,
+						Description: "Attach a volume as a cdrom to the vmi.",
+						Optional:    true,
+						MaxItems:    1,
+						Elem: &schema.Resource{
+							Schema: map[string]*schema.Schema{
+								"bus": {
+									Type:        schema.TypeString,
+									Description: "Bus indicates the type of disk device to emulate. One of virtio, sata or scsi.",
+									Optional:    true,
+								},
+								"read_only": {
+									Type:        schema.TypeBool,
+									Description: "ReadOnly. Defaults to true.",
+									Optional:    true,
+									Default:     true,
+								},
+								"tray": {
+									Type:        schema.TypeString,
+									Description: "Tray indicates if the tray of the device is open or closed. Defaults to closed.",
+									Optional:    true,
+									ValidateFunc: validation.StringInSlice([]string{
+										string(kubevirtapiv1.TrayStateOpen),
+										string(kubevirtapiv1.TrayStateClosed),
+									}, false),
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		"serial": {
+			Type:        schema.TypeString,
+			Description: "Serial provides the ability to specify a serial number for the disk device.",
+			Optional:    true,
+		},
+		"boot_order": {
+			Type:         schema.TypeInt,
+			Description:  "BootOrder is an integer value > 0, used to determine ordering of boot devices. Lower values take precedence. Each disk or interface that has a boot order must have a unique value.",
+			Optional:     true,
+			ValidateFunc: validation.IntAtLeast(1),
+		},
+		"dedicated_io_thread": {
+			Type:        schema.TypeBool,
+			Description: "DedicatedIOThread indicates this disk should have an exclusive IO Thread. Defaults to false.",
+			Optional:    true,
+		},
+		"cache": {
+			Type:        schema.TypeString,
+			Description: "Cache specifies which kvm disk cache mode should be used.",
+			Optional:    true,
+			ValidateFunc: validation.StringInSlice([]string{
+				string(kubevirtapiv1.CacheNone),
+				string(kubevirtapiv1.CacheWriteThrough),
+				string(kubevirtapiv1.CacheWriteBack),
+			}, false),
+		},
+		"io": {
+			Type:        schema.TypeString,
+			Description: "IO specifies which QEMU disk IO mode should be used.",
+			Optional:    true,
+			ValidateFunc: validation.StringInSlice([]string{
+				string(kubevirtapiv1.IONative),
+				string(kubevirtapiv1.IOThreads),
+				string(kubevirtapiv1.IODefault),
+			}, false),
+		},
+		"block_size": {
+			Type:        schema.TypeList,
+			Description: "If specified, the virtual disk will be presented with the given block sizes.",
+			Optional:    true,
+			MaxItems:    1,
+			Elem: &schema.Resource{
+				Schema: map[string]*schema.Schema{
+					"custom": {
+						Type:        schema.TypeList,
+						Description: "Custom block sizes of the disk.",
+						Optional:    true,
+						MaxItems:    1,
+						Elem: &schema.Resource{
+							Schema: map[string]*schema.Schema{
+								"logical": {
+									Type:         schema.TypeInt,
+									Description:  "Logical block size in bytes.",
+									Required:     true,
+									ValidateFunc: validation.IntAtLeast(1),
+								},
+								"physical": {
+									Type:         schema.TypeInt,
+									Description:  "Physical block size in bytes.",
+									Required:     true,
+									ValidateFunc: validation.IntAtLeast(1),
+								},
+							},
+						},
+					},
+					"match_volume": featureStateSchema("Detect the block sizes of the underlying volume."),
+				},
+			},
+		},
+		"shareable": {
+			Type:        schema.TypeBool,
+			Description: "If specified the disk is made sharable and multiple write from different VMs are permitted.",
+			Optional:    true,
+		},
+	}
+}
+
+func diskSchema() *schema.Schema {
+	fields := diskFields()
+
+	return &schema.Schema{
+		Type:        schema.TypeList,
+		Description: "Disks describes disks, cdroms, floppy and luns which are connected to the vmi.",
+		Required:    true,
+		Elem: &schema.Resource{
+			Schema: fields,
+		},
+	}
+}
+
+func expandDisks(disks []interface{}) ([]kubevirtapiv1.Disk, error) {
+	result := make([]kubevirtapiv1.Disk, len(disks))
+
+	if len(disks) == 0 || disks[0] == nil {
+		return result, nil
+	}
+
+	for i, condition := range disks {
+		in := condition.(map[string]interface{})
+
+		if v, ok := in["name"].(string); ok {
+			result[i].Name = v
+		}
+		if v, ok := in["disk_device"].([]interface{}); ok {
+			diskDevice, err := expandDiskDevice(v)
+			if err != nil {
+				return result, fmt.Errorf("disk %q: %w", result[i].Name, err)
+			}
+			result[i].DiskDevice = diskDevice
+		}
+		if v, ok := in["serial"].(string); ok {
+			result[i].Serial = v
+		}
+		if v, ok := in["boot_order"].(int); ok && v > 0 {
+			bootOrder := uint(v)
+			result[i].BootOrder = &bootOrder
+		}
+		if v, ok := in["dedicated_io_thread"].(bool); ok && v {
+			result[i].DedicatedIOThread = &v
+		}
+		if v, ok := in["cache"].(string); ok {
+			result[i].Cache = kubevirtapiv1.DriverCache(v)
+		}
+		if v, ok := in["io"].(string); ok {
+			result[i].IO = kubevirtapiv1.DriverIO(v)
+		}
+		if v, ok := in["block_size"].([]interface{}); ok {
+			result[i].BlockSize = expandBlockSize(v)
+		}
+		if v, ok := in["shareable"].(bool); ok && v {
+			result[i].Shareable = &v
+		}
+	}
+
+	return result, nil
+}
+
+func expandDiskDevice(diskDevice []interface{}) (kubevirtapiv1.DiskDevice, error) {
+	result := kubevirtapiv1.DiskDevice{}
+
+	if len(diskDevice) == 0 || diskDevice[0] == nil {
+		return result, fmt.Errorf("exactly one of disk, lun or cdrom must be set in disk_device")
+	}
+
+	in := diskDevice[0].(map[string]interface{})
+
+	if v, ok := in["disk"].([]interface{}); ok {
+		result.Disk = expandDiskTarget(v)
+	}
+	if v, ok := in["lun"].([]interface{}); ok {
+		result.LUN = expandLunTarget(v)
+	}
+	if v, ok := in["cdrom"].([]interface{}); ok {
+		result.CDRom = expandCDRomTarget(v)
+	}
+
+	deviceTypes := 0
+	for _, set := range []bool{result.Disk != nil, result.LUN != nil, result.CDRom != nil} {
+		if set {
+			deviceTypes++
+		}
+	}
+	if deviceTypes != 1 {
+		return result, fmt.Errorf("exactly one of disk, lun or cdrom must be set in disk_device")
+	}
+
+	return result, nil
+}
+
+func expandDiskTarget(disk []interface{}) *kubevirtapiv1.DiskTarget {
+	if len(disk) == 0 {
+		return nil
+	}
+
+	result := &kubevirtapiv1.DiskTarget{}
+
+	in, ok := disk[0].(map[string]interface{})
+	if !ok {
+		return result
+	}
+
+	if v, ok := in["bus"].(string); ok {
+		result.Bus = kubevirtapiv1.DiskBus(v)
+	}
+	if v, ok := in["read_only"].(bool); ok {
+		result.ReadOnly = v
+	}
+	if v, ok := in["pci_address"].(string); ok {
+		result.PciAddress = v
+	}
+
+	return result
+}
+
+func expandLunTarget(lun []interface{}) *kubevirtapiv1.LunTarget {
+	if len(lun) == 0 {
+		return nil
+	}
+
+	result := &kubevirtapiv1.LunTarget{}
+
+	in, ok := lun[0].(map[string]interface{})
+	if !ok {
+		return result
+	}
+
+	if v, ok := in["bus"].(string); ok {
+		result.Bus = kubevirtapiv1.DiskBus(v)
+	}
+	if v, ok := in["read_only"].(bool); ok {
+		result.ReadOnly = v
+	}
+
+	return result
+}
+
+func expandCDRomTarget(cdrom []interface{}) *kubevirtapiv1.CDRomTarget {
+	if len(cdrom) == 0 {
+		return nil
+	}
+
+	// An empty block attaches a read only cdrom, matching the API default.
+	readOnly := true
+	result := &kubevirtapiv1.CDRomTarget{
+		ReadOnly: &readOnly,
+	}
+
+	in, ok := cdrom[0].(map[string]interface{})
+	if !ok {
+		return result
+	}
+
+	if v, ok := in["bus"].(string); ok {
+		result.Bus = kubevirtapiv1.DiskBus(v)
+	}
+	if v, ok := in["read_only"].(bool); ok {
+		readOnly = v
+	}
+	if v, ok := in["tray"].(string); ok {
+		result.Tray = kubevirtapiv1.TrayState(v)
+	}
+
+	return result
+}
+
+func expandBlockSize(blockSize []interface{}) *kubevirtapiv1.BlockSize {
+	if len(blockSize) == 0 {
+		return nil
+	}
+
+	result := &kubevirtapiv1.BlockSize{}
+
+	in, ok := blockSize[0].(map[string]interface{})
+	if !ok {
+		return result
+	}
+
+	if v, ok := in["custom"].([]interface{}); ok && len(v) > 0 && v[0] != nil {
+		custom := v[0].(map[string]interface{})
+		result.Custom = &kubevirtapiv1.CustomBlockSize{
+			Logical:  uint(custom["logical"].(int)),
+			Physical: uint(custom["physical"].(int)),
+		}
+	}
+	if v, ok := in["match_volume"].([]interface{}); ok {
+		result.MatchVolume = expandFeatureState(v)
+	}
+
+	return result
+}
+
+func flattenDisks(in []kubevirtapiv1.Disk) []interface{} {
+	att := make([]interface{}, len(in))
+
+	for i, v := range in {
+		c := make(map[string]interface{})
+
+		c["name"] = v.Name
+		c["disk_device"] = flattenDiskDevice(v.DiskDevice)
+		c["serial"] = v.Serial
+		c["boot_order"] = 0
+		if v.BootOrder != nil {
+			c["boot_order"] = int(*v.BootOrder)
+		}
+		c["dedicated_io_thread"] = v.DedicatedIOThread != nil && *v.DedicatedIOThread
+		c["cache"] = string(v.Cache)
+		c["io"] = string(v.IO)
+		if v.BlockSize != nil {
+			c["block_size"] = flattenBlockSize(*v.BlockSize)
+		}
+		c["shareable"] = v.Shareable != nil && *v.Shareable
+
+		att[i] = c
+	}
+
+	return att
+}
+
+func flattenDiskDevice(in kubevirtapiv1.DiskDevice) []interface{} {
+	att := make(map[string]interface{})
+
+	if in.Disk != nil {
+		att["disk"] = flattenDiskTarget(*in.Disk)
+	}
+	if in.LUN != nil {
+		att["lun"] = []interface{}{map[string]interface{}{
+			"bus":       string(in.LUN.Bus),
+			"read_only": in.LUN.ReadOnly,
+		}}
+	}
+	if in.CDRom != nil {
+		att["cdrom"] = []interface{}{map[string]interface{}{
+			"bus":       string(in.CDRom.Bus),
+			"read_only": in.CDRom.ReadOnly == nil || *in.CDRom.ReadOnly,
+			"tray":      string(in.CDRom.Tray),
+		}}
+	}
+
+	return []interface{}{att}
+}
+
+func flattenDiskTarget(in kubevirtapiv1.DiskTarget) []interface{} {
+	att := make(map[string]interface{})
+
+	att["bus"] = string(in.Bus)
+	att["read_only"] = in.ReadOnly
+	att["pci_address"] = in.PciAddress
+
+	return []interface{}{att}
+}
+
+func flattenBlockSize(in kubevirtapiv1.BlockSize) []interface{} {
+	att := make(map[string]interface{})
+
+	if in.Custom != nil {
+		att["custom"] = []interface{}{map[string]interface{}{
+			"logical":  int(in.Custom.Logical),
+			"physical": int(in.Custom.Physical),
+		}}
+	}
+	if in.MatchVolume != nil {
+		att["match_volume"] = flattenFeatureState(*in.MatchVolume)
+	}
+
+	return []interface{}{att}
+}
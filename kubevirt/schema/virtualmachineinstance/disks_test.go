@@ -0,0 +1,148 @@
+package virtualmachineinstance
+
+import (
+	"testing"
+
+	"github.com/nrp-nautilus/terraform-provider-kubevirt/kubevirt/utils"
+	kubevirtapiv1 "kubevirt.io/api/core/v1"
+
+	"gotest.tools/assert"
+)
+
+func TestExpandDiskDevice(t *testing.T) {
+	cases := []struct {
+		name          string
+		input         map[string]interface{}
+		expected      kubevirtapiv1.DiskDevice
+		expectedError string
+	}{
+		{
+			name: "cdrom",
+			input: map[string]interface{}{
+				"cdrom": []interface{}{
+					map[string]interface{}{
+						"bus":  "sata",
+						"tray": "open",
+					},
+				},
+			},
+			expected: kubevirtapiv1.DiskDevice{
+				CDRom: &kubevirtapiv1.CDRomTarget{
+					Bus:      "sata",
+					ReadOnly: utils.PtrToBool(true),
+					Tray:     kubevirtapiv1.TrayStateOpen,
+				},
+			},
+		},
+		{
+			name: "lun",
+			input: map[string]interface{}{
+				"lun": []interface{}{
+					map[string]interface{}{
+						"bus":       "scsi",
+						"read_only": true,
+					},
+				},
+			},
+			expected: kubevirtapiv1.DiskDevice{
+				LUN: &kubevirtapiv1.LunTarget{
+					Bus:      "scsi",
+					ReadOnly: true,
+				},
+			},
+		},
+		{
+			name:          "no device type",
+			input:         map[string]interface{}{},
+			expectedError: "exactly one of disk, lun or cdrom must be set in disk_device",
+		},
+		{
+			name: "disk and cdrom",
+			input: map[string]interface{}{
+				"disk": []interface{}{
+					map[string]interface{}{
+						"bus": "virtio",
+					},
+				},
+				"cdrom": []interface{}{nil},
+			},
+			expectedError: "exactly one of disk, lun or cdrom must be set in disk_device",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			output, err := expandDiskDevice([]interface{}{tc.input})
+			if tc.expectedError != "" {
+				assert.Error(t, err, tc.expectedError)
+				return
+			}
+			assert.NilError(t, err)
+			assert.DeepEqual(t, tc.expected, output)
+
+			// Flattening has to yield the same device once expanded again, so that plans stay clean.
+			roundTrip, err := expandDiskDevice(flattenDiskDevice(output))
+			assert.NilError(t, err)
+			assert.DeepEqual(t, tc.expected, roundTrip)
+		})
+	}
+}
+
+func TestExpandFlattenDiskTuning(t *testing.T) {
+	disks := []interface{}{
+		map[string]interface{}{
+			"name": "rootdisk",
+			"disk_device": []interface{}{
+				map[string]interface{}{
+					"disk": []interface{}{
+						map[string]interface{}{
+							"bus": "virtio",
+						},
+					},
+				},
+			},
+			"boot_order":          2,
+			"dedicated_io_thread": true,
+			"cache":               "writethrough",
+			"io":                  "threads",
+			"block_size": []interface{}{
+				map[string]interface{}{
+					"match_volume": []interface{}{nil},
+				},
+			},
+			"shareable": true,
+		},
+	}
+
+	bootOrder := uint(2)
+	expected := []kubevirtapiv1.Disk{
+		{
+			Name: "rootdisk",
+			DiskDevice: kubevirtapiv1.DiskDevice{
+				Disk: &kubevirtapiv1.DiskTarget{
+					Bus: "virtio",
+				},
+			},
+			BootOrder:         &bootOrder,
+			DedicatedIOThread: utils.PtrToBool(true),
+			Cache:             kubevirtapiv1.CacheWriteThrough,
+			IO:                kubevirtapiv1.IOThreads,
+			BlockSize: &kubevirtapiv1.BlockSize{
+				MatchVolume: &kubevirtapiv1.FeatureState{Enabled: utils.PtrToBool(true)},
+			},
+			Shareable: utils.PtrToBool(true),
+		},
+	}
+
+	output, err := expandDisks(disks)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, expected, output)
+
+	roundTrip, err := expandDisks(flattenDisks(output))
+	assert.NilError(t, err)
+	assert.DeepEqual(t, expected, roundTrip)
+
+	disks[0].(map[string]interface{})["disk_device"] = []interface{}{}
+	_, err = expandDisks(disks)
+	assert.Error(t, err, `disk "rootdisk": exactly one of disk, lun or cdrom must be set in disk_device`)
+}
@@ -4,7 +4,6 @@ import (
 	"fmt"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
-	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
 	"github.com/nrp-nautilus/terraform-provider-kubevirt/kubevirt/utils"
 	kubevirtapiv1 "kubevirt.io/api/core/v1"
 )
@@ -36,6 +35,11 @@ func domainSpecFields() map[string]*schema.Schema {
 				},
 			},
 		},
+		"cpu":      cpuSchema(),
+		"memory":   memorySchema(),
+		"firmware": firmwareSchema(),
+		"features": featuresSchema(),
+		"clock":    clockSchema(),
 		"devices": {
 			Type:        schema.TypeList,
 			Description: "Devices allows adding disks, network interfaces, ...",
@@ -43,78 +47,17 @@ func domainSpecFields() map[string]*schema.Schema {
 			Required:    true,
 			Elem: &schema.Resource{
 				Schema: map[string]*schema.Schema{
-					"disk": {
+					"disk":      diskSchema(),
+					"interface": interfaceSchema(),
+					"filesystem": {
 						Type:        schema.TypeList,
-						Description: "Disks describes disks, cdroms, floppy and luns which are connected to the vmi.",
-						Required:    true,
-						Elem: &schema.Resource{
-							Schema: map[string]*schema.Schema{
-								"name": {
-									Type:        schema.TypeString,
-									Description: "Name is the device name",
-									Required:    true,
-								},
-								"disk_device": {
-									Type:        schema.TypeList,
-									Description: "DiskDevice specifies as which device the disk should be added to the guest.",
-									Required:    true,
-									Elem: &schema.Resource{
-										Schema: map[string]*schema.Schema{
-											"disk": {
-												Type:        schema.TypeList,
-												Description: "Attach a volume as a disk to the vmi.",
-												Optional:    true,
-												Elem: &schema.Resource{
-													Schema: map[string]*schema.Schema{
-														"bus": {
-															Type:        schema.TypeString,
-															Description: "Bus indicates the type of disk device to emulate.",
-															Required:    true,
-														},
-														"read_only": {
-															Type:        schema.TypeBool,
-															Description: "ReadOnly. Defaults to false.",
-															Optional:    true,
-														},
-														"pci_address": {
-															Type:        schema.TypeString,
-															Description: "If specified, the virtual disk will be placed on the guests pci address with the specifed PCI address. For example: 0000:81:01.10",
-															Optional:    true,
-														},
-													},
-												},
-											},
-										},
-									},
-								},
-								"serial": {
-									Type:        schema.TypeString,
-									Description: "Serial provides the ability to specify a serial number for the disk device.",
-									Optional:    true,
-								},
-							},
-						},
-					},
-					"interface": {
-						Type:        schema.TypeList,
-						Description: "Interfaces describe network interfaces which are added to the vmi.",
+						Description: "Filesystems describes filesystems which are connected to the vmi. They are shared with the guest through virtiofs.",
 						Optional:    true,
 						Elem: &schema.Resource{
 							Schema: map[string]*schema.Schema{
 								"name": {
 									Type:        schema.TypeString,
-									Description: "Logical name of the interface as well as a reference to the associated networks.",
-									Required:    true,
-								},
-								"interface_binding_method": {
-									Type: schema.TypeString,
-									ValidateFunc: validation.StringInSlice([]string{
-										"InterfaceBridge",
-										"InterfaceSlirp",
-										"InterfaceMasquerade",
-										"InterfaceSRIOV",
-									}, false),
-									Description: "Represents the method which will be used to connect the interface to the guest.",
+									Description: "Name is the device name.",
 									Required:    true,
 								},
 							},
@@ -158,6 +101,40 @@ func expandDomainSpec(domainSpec []interface{}) (kubevirtapiv1.DomainSpec, error
 		}
 		result.Resources = resources
 	}
+	if v, ok := in["cpu"].([]interface{}); ok {
+		cpu, err := expandCPU(v)
+		if err != nil {
+			return result, err
+		}
+		result.CPU = cpu
+	}
+	if v, ok := in["memory"].([]interface{}); ok {
+		memory, err := expandMemory(v)
+		if err != nil {
+			return result, err
+		}
+		result.Memory = memory
+	}
+	if v, ok := in["firmware"].([]interface{}); ok {
+		firmware, err := expandFirmware(v)
+		if err != nil {
+			return result, err
+		}
+		result.Firmware = firmware
+	}
+	if v, ok := in["features"].([]interface{}); ok {
+		result.Features = expandFeatures(v)
+	}
+	if v, ok := in["clock"].([]interface{}); ok {
+		clock, err := expandClock(v)
+		if err != nil {
+			return result, err
+		}
+		result.Clock = clock
+	}
+	if secureBootEnabled(result.Firmware) && !smmEnabled(result.Features) {
+		return result, fmt.Errorf("secure boot requires features.smm to be enabled")
+	}
 	if v, ok := in["devices"].([]interface{}); ok {
 		devices, err := expandDevices(v)
 		if err != nil {
@@ -209,110 +186,36 @@ func expandDevices(devices []interface{}) (kubevirtapiv1.Devices, error) {
 	in := devices[0].(map[string]interface{})
 
 	if v, ok := in["disk"].([]interface{}); ok {
-		result.Disks = expandDisks(v)
+		disks, err := expandDisks(v)
+		if err != nil {
+			return result, err
+		}
+		result.Disks = disks
 	}
 	if v, ok := in["interface"].([]interface{}); ok {
 		result.Interfaces = expandInterfaces(v)
 	}
-
-	return result, nil
-}
-
-func expandDisks(disks []interface{}) []kubevirtapiv1.Disk {
-	result := make([]kubevirtapiv1.Disk, len(disks))
-
-	if len(disks) == 0 || disks[0] == nil {
-		return result
+	if v, ok := in["filesystem"].([]interface{}); ok {
+		result.Filesystems = expandFilesystems(v)
 	}
 
-	for i, condition := range disks {
-		in := condition.(map[string]interface{})
-
-		if v, ok := in["name"].(string); ok {
-			result[i].Name = v
-		}
-		if v, ok := in["disk_device"].([]interface{}); ok {
-			result[i].DiskDevice = expandDiskDevice(v)
-		}
-		if v, ok := in["serial"].(string); ok {
-			result[i].Serial = v
-		}
-	}
-
-	return result
-}
-
-func expandDiskDevice(diskDevice []interface{}) kubevirtapiv1.DiskDevice {
-	result := kubevirtapiv1.DiskDevice{}
-
-	if len(diskDevice) == 0 || diskDevice[0] == nil {
-		return result
-	}
-
-	in := diskDevice[0].(map[string]interface{})
-
-	if v, ok := in["disk"].([]interface{}); ok {
-		result.Disk = expandDiskTarget(v)
-	}
-
-	return result
+	return result, nil
 }
 
-func expandDiskTarget(disk []interface{}) *kubevirtapiv1.DiskTarget {
-	if len(disk) == 0 || disk[0] == nil {
+func expandFilesystems(filesystems []interface{}) []kubevirtapiv1.Filesystem {
+	if len(filesystems) == 0 {
 		return nil
 	}
 
-	result := &kubevirtapiv1.DiskTarget{}
-
-	in := disk[0].(map[string]interface{})
+	result := make([]kubevirtapiv1.Filesystem, len(filesystems))
 
-	if v, ok := in["bus"].(string); ok {
-		result.Bus = kubevirtapiv1.DiskBus(v)
-	}
-	if v, ok := in["read_only"].(bool); ok {
-		result.ReadOnly = v
-	}
-	if v, ok := in["pci_address"].(string); ok {
-		result.PciAddress = v
-	}
-
-	return result
-}
-
-func expandInterfaces(interfaces []interface{}) []kubevirtapiv1.Interface {
-	result := make([]kubevirtapiv1.Interface, len(interfaces))
-
-	if len(interfaces) == 0 || interfaces[0] == nil {
-		return result
-	}
-
-	for i, condition := range interfaces {
-		in := condition.(map[string]interface{})
+	for i, filesystem := range filesystems {
+		in := filesystem.(map[string]interface{})
 
 		if v, ok := in["name"].(string); ok {
 			result[i].Name = v
 		}
-		if v, ok := in["interface_binding_method"].(string); ok {
-			result[i].InterfaceBindingMethod = expandInterfaceBindingMethod(v)
-		}
-	}
-
-	return result
-}
-
-func expandInterfaceBindingMethod(interfaceBindingMethod string) kubevirtapiv1.InterfaceBindingMethod {
-	result := kubevirtapiv1.InterfaceBindingMethod{}
-
-	switch interfaceBindingMethod {
-	case "InterfaceBridge":
-		result.Bridge = &kubevirtapiv1.InterfaceBridge{}
-	case "InterfaceSlirp":
-		result.Slirp = &kubevirtapiv1.InterfaceSlirp{}
-	case "InterfaceMasquerade":
-		result.Masquerade = &kubevirtapiv1.InterfaceMasquerade{}
-	case "InterfaceSRIOV":
-		result.SRIOV = &kubevirtapiv1.InterfaceSRIOV{}
+		result[i].Virtiofs = &kubevirtapiv1.FilesystemVirtiofs{}
 	}
 
 	return result
@@ -322,6 +225,21 @@ func flattenDomainSpec(in kubevirtapiv1.DomainSpec) []interface{} {
 	att := make(map[string]interface{})
 
 	att["resources"] = flattenResources(in.Resources)
+	if in.CPU != nil {
+		att["cpu"] = flattenCPU(*in.CPU)
+	}
+	if in.Memory != nil {
+		att["memory"] = flattenMemory(*in.Memory)
+	}
+	if in.Firmware != nil {
+		att["firmware"] = flattenFirmware(*in.Firmware)
+	}
+	if in.Features != nil {
+		att["features"] = flattenFeatures(*in.Features)
+	}
+	if in.Clock != nil {
+		att["clock"] = flattenClock(*in.Clock)
+	}
 	att["devices"] = flattenDevices(in.Devices)
 
 	return []interface{}{att}
@@ -342,74 +260,19 @@ func flattenDevices(in kubevirtapiv1.Devices) []interface{} {
 
 	att["disk"] = flattenDisks(in.Disks)
 	att["interface"] = flattenInterfaces(in.Interfaces)
+	att["filesystem"] = flattenFilesystems(in.Filesystems)
 
 	return []interface{}{att}
 }
 
-func flattenDisks(in []kubevirtapiv1.Disk) []interface{} {
-	att := make([]interface{}, len(in))
-
-	for i, v := range in {
-		c := make(map[string]interface{})
-
-		c["name"] = v.Name
-		c["disk_device"] = flattenDiskDevice(v.DiskDevice)
-		c["serial"] = v.Serial
-
-		att[i] = c
-	}
-
-	return att
-}
-
-func flattenDiskDevice(in kubevirtapiv1.DiskDevice) []interface{} {
-	att := make(map[string]interface{})
-
-	if in.Disk != nil {
-		att["disk"] = flattenDiskTarget(*in.Disk)
-	}
-
-	return []interface{}{att}
-}
-
-func flattenDiskTarget(in kubevirtapiv1.DiskTarget) []interface{} {
-	att := make(map[string]interface{})
-
-	att["bus"] = in.Bus
-	att["read_only"] = in.ReadOnly
-	att["pci_address"] = in.PciAddress
-
-	return []interface{}{att}
-}
-
-func flattenInterfaces(in []kubevirtapiv1.Interface) []interface{} {
+func flattenFilesystems(in []kubevirtapiv1.Filesystem) []interface{} {
 	att := make([]interface{}, len(in))
 
 	for i, v := range in {
-		c := make(map[string]interface{})
-
-		c["name"] = v.Name
-		c["interface_binding_method"] = flattenInterfaceBindingMethod(v.InterfaceBindingMethod)
-
-		att[i] = c
+		att[i] = map[string]interface{}{
+			"name": v.Name,
+		}
 	}
 
 	return att
 }
-
-func flattenInterfaceBindingMethod(in kubevirtapiv1.InterfaceBindingMethod) string {
-	if in.Bridge != nil {
-		return "InterfaceBridge"
-	}
-	if in.Slirp != nil {
-		return "InterfaceSlirp"
-	}
-	if in.Masquerade != nil {
-		return "InterfaceMasquerade"
-	}
-	if in.SRIOV != nil {
-		return "InterfaceSRIOV"
-	}
-
-	return ""
-}
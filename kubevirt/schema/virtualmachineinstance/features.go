@@ -0,0 +1,401 @@
+package virtualmachineinstance
+
+import (
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/nrp-nautilus/terraform-provider-kubevirt/kubevirt/utils"
+	kubevirtapiv1 "kubevirt.io/api/core/v1"
+)
+
+func featuresFields() map[string]*schema.Schema {
+	return map[string]*schema.Schema{
+		"acpi": featureStateSchema("ACPI enables/disables ACPI inside the guest. Defaults to enabled."),
+		"apic": {
+			Type:        schema.TypeList,
+			Description: "Defaults to the machine type setting.",
+			Optional:    true,
+			MaxItems:    1,
+			Elem: &schema.Resource{
+				Schema: map[string]*schema.Schema{
+					"enabled": featureEnabledSchema(),
+					"end_of_interrupt": {
+						Type:        schema.TypeBool,
+						Description: "EndOfInterrupt enables the end of interrupt notification in the guest.",
+						Optional:    true,
+					},
+				},
+			},
+		},
+		"hyperv": hypervSchema(),
+		"smm":    featureStateSchema("SMM enables/disables System Management Mode. Secure Boot requires it."),
+		"kvm": {
+			Type:        schema.TypeList,
+			Description: "Configure how KVM presence is exposed to the guest.",
+			Optional:    true,
+			MaxItems:    1,
+			Elem: &schema.Resource{
+				Schema: map[string]*schema.Schema{
+					"hidden": {
+						Type:        schema.TypeBool,
+						Description: "Hide the KVM hypervisor from standard MSR based discovery.",
+						Optional:    true,
+					},
+				},
+			},
+		},
+		"pvspinlock": featureStateSchema("Notify the guest that the host supports paravirtual spinlocks. For older kernels this feature should be explicitly disabled."),
+	}
+}
+
+func featuresSchema() *schema.Schema {
+	fields := featuresFields()
+
+	return &schema.Schema{
+		Type:        schema.TypeList,
+		Description: "Features like acpi, apic, hyperv, smm.",
+		Optional:    true,
+		MaxItems:    1,
+		Elem: &schema.Resource{
+			Schema: fields,
+		},
+	}
+}
+
+func hypervSchema() *schema.Schema {
+	return &schema.Schema{
+		Type:        schema.TypeList,
+		Description: "Defaults to the machine type setting. Hyper-V enlightenments improve the performance of Windows guests.",
+		Optional:    true,
+		MaxItems:    1,
+		Elem: &schema.Resource{
+			Schema: map[string]*schema.Schema{
+				"relaxed": featureStateSchema("Relaxed instructs the guest OS to disable watchdog timeouts."),
+				"vapic":   featureStateSchema("VAPIC improves the paravirtualized handling of interrupts."),
+				"spinlocks": {
+					Type:        schema.TypeList,
+					Description: "Spinlocks allows to configure the spinlock retry attempts.",
+					Optional:    true,
+					MaxItems:    1,
+					Elem: &schema.Resource{
+						Schema: map[string]*schema.Schema{
+							"enabled": featureEnabledSchema(),
+							"retries": {
+								Type:         schema.TypeInt,
+								Description:  "Retries indicates the number of retries. Must be a value greater or equal 4096. Defaults to 4096.",
+								Optional:     true,
+								ValidateFunc: utils.ValidateNonNegativeInteger,
+							},
+						},
+					},
+				},
+				"vpindex": featureStateSchema("VPIndex enables the Virtual Processor Index to help windows identifying virtual processors."),
+				"runtime": featureStateSchema("Runtime improves the time accounting to improve scheduling in the guest."),
+				"synic":   featureStateSchema("SyNIC enables the Synthetic Interrupt Controller."),
+				"synic_timer": {
+					Type:        schema.TypeList,
+					Description: "SyNICTimer enables Synthetic Interrupt Controller Timers, reducing CPU load. Requires synic and vpindex.",
+					Optional:    true,
+					MaxItems:    1,
+					Elem: &schema.Resource{
+						Schema: map[string]*schema.Schema{
+							"enabled": featureEnabledSchema(),
+							"direct":  featureStateSchema("Direct enables direct mode for the synthetic timers."),
+						},
+					},
+				},
+				"reset": featureStateSchema("Reset enables Hyperv reboot/reset for the vmi. Requires synic."),
+				"vendor_id": {
+					Type:        schema.TypeList,
+					Description: "VendorID allows setting the hypervisor vendor id.",
+					Optional:    true,
+					MaxItems:    1,
+					Elem: &schema.Resource{
+						Schema: map[string]*schema.Schema{
+							"enabled": featureEnabledSchema(),
+							"vendor_id": {
+								Type:        schema.TypeString,
+								Description: "VendorID sets the hypervisor vendor id, visible to the vmi. String up to twelve characters.",
+								Optional:    true,
+							},
+						},
+					},
+				},
+				"frequencies":     featureStateSchema("Frequencies improves the TSC clock source handling for Hyper-V on KVM."),
+				"reenlightenment": featureStateSchema("Reenlightenment enables the notifications on TSC frequency changes."),
+				"tlb_flush":       featureStateSchema("TLBFlush improves performances in overcommited environments. Requires vpindex."),
+				"ipi":             featureStateSchema("IPI improves performances in overcommited environments. Requires vpindex."),
+				"evmcs":           featureStateSchema("EVMCS speeds up L2 vmexits, but disables other virtualization features. Requires vapic."),
+			},
+		},
+	}
+}
+
+func featureEnabledSchema() *schema.Schema {
+	return &schema.Schema{
+		Type:        schema.TypeBool,
+		Description: "Enabled determines if the feature should be enabled or disabled on the guest. Defaults to true.",
+		Optional:    true,
+		Default:     true,
+	}
+}
+
+func featureStateSchema(description string) *schema.Schema {
+	return &schema.Schema{
+		Type:        schema.TypeList,
+		Description: description,
+		Optional:    true,
+		MaxItems:    1,
+		Elem: &schema.Resource{
+			Schema: map[string]*schema.Schema{
+				"enabled": featureEnabledSchema(),
+			},
+		},
+	}
+}
+
+func expandFeatures(features []interface{}) *kubevirtapiv1.Features {
+	if len(features) == 0 || features[0] == nil {
+		return nil
+	}
+
+	result := &kubevirtapiv1.Features{}
+
+	in := features[0].(map[string]interface{})
+
+	if v, ok := in["acpi"].([]interface{}); ok {
+		if acpi := expandFeatureState(v); acpi != nil {
+			result.ACPI = *acpi
+		}
+	}
+	if v, ok := in["apic"].([]interface{}); ok && len(v) > 0 {
+		result.APIC = &kubevirtapiv1.FeatureAPIC{
+			Enabled: expandFeatureEnabled(v[0]),
+		}
+		if apic, ok := v[0].(map[string]interface{}); ok {
+			if v, ok := apic["end_of_interrupt"].(bool); ok {
+				result.APIC.EndOfInterrupt = v
+			}
+		}
+	}
+	if v, ok := in["hyperv"].([]interface{}); ok {
+		result.Hyperv = expandHyperv(v)
+	}
+	if v, ok := in["smm"].([]interface{}); ok {
+		result.SMM = expandFeatureState(v)
+	}
+	if v, ok := in["kvm"].([]interface{}); ok && len(v) > 0 {
+		result.KVM = &kubevirtapiv1.FeatureKVM{}
+		if kvm, ok := v[0].(map[string]interface{}); ok {
+			if v, ok := kvm["hidden"].(bool); ok {
+				result.KVM.Hidden = v
+			}
+		}
+	}
+	if v, ok := in["pvspinlock"].([]interface{}); ok {
+		result.Pvspinlock = expandFeatureState(v)
+	}
+
+	return result
+}
+
+func expandHyperv(hyperv []interface{}) *kubevirtapiv1.FeatureHyperv {
+	if len(hyperv) == 0 {
+		return nil
+	}
+
+	result := &kubevirtapiv1.FeatureHyperv{}
+
+	in, ok := hyperv[0].(map[string]interface{})
+	if !ok {
+		return result
+	}
+
+	if v, ok := in["relaxed"].([]interface{}); ok {
+		result.Relaxed = expandFeatureState(v)
+	}
+	if v, ok := in["vapic"].([]interface{}); ok {
+		result.VAPIC = expandFeatureState(v)
+	}
+	if v, ok := in["spinlocks"].([]interface{}); ok && len(v) > 0 {
+		result.Spinlocks = &kubevirtapiv1.FeatureSpinlocks{
+			Enabled: expandFeatureEnabled(v[0]),
+		}
+		if spinlocks, ok := v[0].(map[string]interface{}); ok {
+			if v, ok := spinlocks["retries"].(int); ok && v > 0 {
+				retries := uint32(v)
+				result.Spinlocks.Retries = &retries
+			}
+		}
+	}
+	if v, ok := in["vpindex"].([]interface{}); ok {
+		result.VPIndex = expandFeatureState(v)
+	}
+	if v, ok := in["runtime"].([]interface{}); ok {
+		result.Runtime = expandFeatureState(v)
+	}
+	if v, ok := in["synic"].([]interface{}); ok {
+		result.SyNIC = expandFeatureState(v)
+	}
+	if v, ok := in["synic_timer"].([]interface{}); ok && len(v) > 0 {
+		result.SyNICTimer = &kubevirtapiv1.SyNICTimer{
+			Enabled: expandFeatureEnabled(v[0]),
+		}
+		if synicTimer, ok := v[0].(map[string]interface{}); ok {
+			if v, ok := synicTimer["direct"].([]interface{}); ok {
+				result.SyNICTimer.Direct = expandFeatureState(v)
+			}
+		}
+	}
+	if v, ok := in["reset"].([]interface{}); ok {
+		result.Reset = expandFeatureState(v)
+	}
+	if v, ok := in["vendor_id"].([]interface{}); ok && len(v) > 0 {
+		result.VendorID = &kubevirtapiv1.FeatureVendorID{
+			Enabled: expandFeatureEnabled(v[0]),
+		}
+		if vendorID, ok := v[0].(map[string]interface{}); ok {
+			if v, ok := vendorID["vendor_id"].(string); ok {
+				result.VendorID.VendorID = v
+			}
+		}
+	}
+	if v, ok := in["frequencies"].([]interface{}); ok {
+		result.Frequencies = expandFeatureState(v)
+	}
+	if v, ok := in["reenlightenment"].([]interface{}); ok {
+		result.Reenlightenment = expandFeatureState(v)
+	}
+	if v, ok := in["tlb_flush"].([]interface{}); ok {
+		result.TLBFlush = expandFeatureState(v)
+	}
+	if v, ok := in["ipi"].([]interface{}); ok {
+		result.IPI = expandFeatureState(v)
+	}
+	if v, ok := in["evmcs"].([]interface{}); ok {
+		result.EVMCS = expandFeatureState(v)
+	}
+
+	return result
+}
+
+func expandFeatureState(featureState []interface{}) *kubevirtapiv1.FeatureState {
+	if len(featureState) == 0 {
+		return nil
+	}
+
+	return &kubevirtapiv1.FeatureState{
+		Enabled: expandFeatureEnabled(featureState[0]),
+	}
+}
+
+// expandFeatureEnabled reads the enabled flag of a feature block. An empty block
+// enables the feature, matching the API default.
+func expandFeatureEnabled(feature interface{}) *bool {
+	enabled := true
+	if in, ok := feature.(map[string]interface{}); ok {
+		if v, ok := in["enabled"].(bool); ok {
+			enabled = v
+		}
+	}
+	return &enabled
+}
+
+// smmEnabled reports whether System Management Mode is enabled on the guest.
+func smmEnabled(features *kubevirtapiv1.Features) bool {
+	if features == nil || features.SMM == nil {
+		return false
+	}
+	return features.SMM.Enabled == nil || *features.SMM.Enabled
+}
+
+func flattenFeatures(in kubevirtapiv1.Features) []interface{} {
+	att := make(map[string]interface{})
+
+	// ACPI is not a pointer, so an unset enabled flag means it was never configured.
+	if in.ACPI.Enabled != nil {
+		att["acpi"] = flattenFeatureState(in.ACPI)
+	}
+	if in.APIC != nil {
+		att["apic"] = []interface{}{map[string]interface{}{
+			"enabled":          flattenFeatureEnabled(in.APIC.Enabled),
+			"end_of_interrupt": in.APIC.EndOfInterrupt,
+		}}
+	}
+	if in.Hyperv != nil {
+		att["hyperv"] = flattenHyperv(*in.Hyperv)
+	}
+	if in.SMM != nil {
+		att["smm"] = flattenFeatureState(*in.SMM)
+	}
+	if in.KVM != nil {
+		att["kvm"] = []interface{}{map[string]interface{}{
+			"hidden": in.KVM.Hidden,
+		}}
+	}
+	if in.Pvspinlock != nil {
+		att["pvspinlock"] = flattenFeatureState(*in.Pvspinlock)
+	}
+
+	return []interface{}{att}
+}
+
+func flattenHyperv(in kubevirtapiv1.FeatureHyperv) []interface{} {
+	att := make(map[string]interface{})
+
+	featureStates := map[string]*kubevirtapiv1.FeatureState{
+		"relaxed":         in.Relaxed,
+		"vapic":           in.VAPIC,
+		"vpindex":         in.VPIndex,
+		"runtime":         in.Runtime,
+		"synic":           in.SyNIC,
+		"reset":           in.Reset,
+		"frequencies":     in.Frequencies,
+		"reenlightenment": in.Reenlightenment,
+		"tlb_flush":       in.TLBFlush,
+		"ipi":             in.IPI,
+		"evmcs":           in.EVMCS,
+	}
+	for k, v := range featureStates {
+		if v != nil {
+			att[k] = flattenFeatureState(*v)
+		}
+	}
+	if in.Spinlocks != nil {
+		spinlocks := map[string]interface{}{
+			"enabled": flattenFeatureEnabled(in.Spinlocks.Enabled),
+			"retries": 0,
+		}
+		if in.Spinlocks.Retries != nil {
+			spinlocks["retries"] = int(*in.Spinlocks.Retries)
+		}
+		att["spinlocks"] = []interface{}{spinlocks}
+	}
+	if in.SyNICTimer != nil {
+		synicTimer := map[string]interface{}{
+			"enabled": flattenFeatureEnabled(in.SyNICTimer.Enabled),
+		}
+		if in.SyNICTimer.Direct != nil {
+			synicTimer["direct"] = flattenFeatureState(*in.SyNICTimer.Direct)
+		}
+		att["synic_timer"] = []interface{}{synicTimer}
+	}
+	if in.VendorID != nil {
+		att["vendor_id"] = []interface{}{map[string]interface{}{
+			"enabled":   flattenFeatureEnabled(in.VendorID.Enabled),
+			"vendor_id": in.VendorID.VendorID,
+		}}
+	}
+
+	return []interface{}{att}
+}
+
+func flattenFeatureState(in kubevirtapiv1.FeatureState) []interface{} {
+	att := make(map[string]interface{})
+
+	att["enabled"] = flattenFeatureEnabled(in.Enabled)
+
+	return []interface{}{att}
+}
+
+func flattenFeatureEnabled(enabled *bool) bool {
+	return enabled == nil || *enabled
+}
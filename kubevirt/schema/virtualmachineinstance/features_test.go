@@ -0,0 +1,146 @@
+package virtualmachineinstance
+
+import (
+	"testing"
+
+	"github.com/nrp-nautilus/terraform-provider-kubevirt/kubevirt/utils"
+	kubevirtapiv1 "kubevirt.io/api/core/v1"
+
+	"gotest.tools/assert"
+)
+
+func TestExpandFlattenWindowsFeaturesAndClock(t *testing.T) {
+	features := []interface{}{
+		map[string]interface{}{
+			"acpi": []interface{}{nil},
+			"apic": []interface{}{
+				map[string]interface{}{
+					"enabled":          true,
+					"end_of_interrupt": false,
+				},
+			},
+			"hyperv": []interface{}{
+				map[string]interface{}{
+					"relaxed": []interface{}{nil},
+					"vapic":   []interface{}{nil},
+					"spinlocks": []interface{}{
+						map[string]interface{}{
+							"enabled": true,
+							"retries": 8191,
+						},
+					},
+					"vpindex": []interface{}{nil},
+					"synic":   []interface{}{nil},
+					"synic_timer": []interface{}{
+						map[string]interface{}{
+							"enabled": true,
+							"direct":  []interface{}{nil},
+						},
+					},
+					"vendor_id": []interface{}{
+						map[string]interface{}{
+							"enabled":   true,
+							"vendor_id": "KVMKVMKVM",
+						},
+					},
+					"tlb_flush": []interface{}{},
+				},
+			},
+			"kvm": []interface{}{
+				map[string]interface{}{
+					"hidden": true,
+				},
+			},
+			"pvspinlock": []interface{}{
+				map[string]interface{}{
+					"enabled": false,
+				},
+			},
+		},
+	}
+	clock := []interface{}{
+		map[string]interface{}{
+			"timezone": "America/Los_Angeles",
+			"timer": []interface{}{
+				map[string]interface{}{
+					"hpet": []interface{}{
+						map[string]interface{}{
+							"present": false,
+						},
+					},
+					"pit": []interface{}{
+						map[string]interface{}{
+							"present":     true,
+							"tick_policy": "delay",
+						},
+					},
+					"rtc": []interface{}{
+						map[string]interface{}{
+							"present":     true,
+							"tick_policy": "catchup",
+							"track":       "guest",
+						},
+					},
+					"hyperv": []interface{}{nil},
+				},
+			},
+		},
+	}
+
+	retries := uint32(8191)
+	timezone := kubevirtapiv1.ClockOffsetTimezone("America/Los_Angeles")
+	expectedFeatures := &kubevirtapiv1.Features{
+		ACPI: kubevirtapiv1.FeatureState{Enabled: utils.PtrToBool(true)},
+		APIC: &kubevirtapiv1.FeatureAPIC{Enabled: utils.PtrToBool(true)},
+		Hyperv: &kubevirtapiv1.FeatureHyperv{
+			Relaxed: &kubevirtapiv1.FeatureState{Enabled: utils.PtrToBool(true)},
+			VAPIC:   &kubevirtapiv1.FeatureState{Enabled: utils.PtrToBool(true)},
+			Spinlocks: &kubevirtapiv1.FeatureSpinlocks{
+				Enabled: utils.PtrToBool(true),
+				Retries: &retries,
+			},
+			VPIndex: &kubevirtapiv1.FeatureState{Enabled: utils.PtrToBool(true)},
+			SyNIC:   &kubevirtapiv1.FeatureState{Enabled: utils.PtrToBool(true)},
+			SyNICTimer: &kubevirtapiv1.SyNICTimer{
+				Enabled: utils.PtrToBool(true),
+				Direct:  &kubevirtapiv1.FeatureState{Enabled: utils.PtrToBool(true)},
+			},
+			VendorID: &kubevirtapiv1.FeatureVendorID{
+				Enabled:  utils.PtrToBool(true),
+				VendorID: "KVMKVMKVM",
+			},
+		},
+		KVM:        &kubevirtapiv1.FeatureKVM{Hidden: true},
+		Pvspinlock: &kubevirtapiv1.FeatureState{Enabled: utils.PtrToBool(false)},
+	}
+	expectedClock := &kubevirtapiv1.Clock{
+		ClockOffset: kubevirtapiv1.ClockOffset{
+			Timezone: &timezone,
+		},
+		Timer: &kubevirtapiv1.Timer{
+			HPET: &kubevirtapiv1.HPETTimer{Enabled: utils.PtrToBool(false)},
+			PIT: &kubevirtapiv1.PITTimer{
+				Enabled:    utils.PtrToBool(true),
+				TickPolicy: kubevirtapiv1.PITTickPolicyDelay,
+			},
+			RTC: &kubevirtapiv1.RTCTimer{
+				Enabled:    utils.PtrToBool(true),
+				TickPolicy: kubevirtapiv1.RTCTickPolicyCatchup,
+				Track:      kubevirtapiv1.TrackGuest,
+			},
+			Hyperv: &kubevirtapiv1.HypervTimer{Enabled: utils.PtrToBool(true)},
+		},
+	}
+
+	expandedFeatures := expandFeatures(features)
+	assert.DeepEqual(t, expectedFeatures, expandedFeatures)
+	expandedClock, err := expandClock(clock)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, expectedClock, expandedClock)
+
+	// Flattening has to yield the same objects once expanded again, so that plans stay clean.
+	assert.DeepEqual(t, expectedFeatures, expandFeatures(flattenFeatures(*expandedFeatures)))
+	roundTripClock, err := expandClock(flattenClock(*expandedClock))
+	assert.NilError(t, err)
+	assert.DeepEqual(t, expectedClock, roundTripClock)
+}
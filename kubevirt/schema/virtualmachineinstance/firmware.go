@@ -0,0 +1,298 @@
+package virtualmachineinstance
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	k8sv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	kubevirtapiv1 "kubevirt.io/api/core/v1"
+)
+
+func firmwareFields() map[string]*schema.Schema {
+	return map[string]*schema.Schema{
+		"uuid": {
+			Type:        schema.TypeString,
+			Description: "UUID reported by the vmi bios. Defaults to a random generated uid.",
+			Optional:    true,
+			Computed:    true,
+		},
+		"serial": {
+			Type:        schema.TypeString,
+			Description: "The system-serial-number in SMBIOS.",
+			Optional:    true,
+		},
+		"bootloader": {
+			Type:        schema.TypeList,
+			Description: "Settings to control the bootloader that is used. Exactly one of bios or efi has to be set.",
+			Optional:    true,
+			MaxItems:    1,
+			Elem: &schema.Resource{
+				Schema: map[string]*schema.Schema{
+					"bios": {
+						Type:        schema.TypeList,
+						Description: "If set (default), BIOS will be used.",
+						Optional:    true,
+						MaxItems:    1,
+						Elem: &schema.Resource{
+							Schema: map[string]*schema.Schema{
+								"use_serial": {
+									Type:        schema.TypeBool,
+									Description: "If set, the BIOS output will be transmitted over serial.",
+									Optional:    true,
+								},
+							},
+						},
+					},
+					"efi": {
+						Type:        schema.TypeList,
+						Description: "If set, EFI will be used instead of BIOS.",
+						Optional:    true,
+						MaxItems:    1,
+						Elem: &schema.Resource{
+							Schema: map[string]*schema.Schema{
+								"secure_boot": {
+									Type:        schema.TypeBool,
+									Description: "If set, SecureBoot will be enabled and the OVMF roms will be swapped for SecureBoot-enabled ones. Requires features.smm to be enabled. Defaults to true.",
+									Optional:    true,
+									Default:     true,
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		"kernel_boot": {
+			Type:        schema.TypeList,
+			Description: "Settings to set the kernel for booting.",
+			Optional:    true,
+			MaxItems:    1,
+			Elem: &schema.Resource{
+				Schema: map[string]*schema.Schema{
+					"kernel_args": {
+						Type:        schema.TypeString,
+						Description: "Arguments to be passed to the kernel at boot time.",
+						Optional:    true,
+					},
+					"container": {
+						Type:        schema.TypeList,
+						Description: "Container defines the container that contains kernel artifacts.",
+						Optional:    true,
+						MaxItems:    1,
+						Elem: &schema.Resource{
+							Schema: map[string]*schema.Schema{
+								"image": {
+									Type:        schema.TypeString,
+									Description: "Image that contains initrd / kernel files.",
+									Required:    true,
+								},
+								"image_pull_secret": {
+									Type:        schema.TypeString,
+									Description: "ImagePullSecret is the name of the Docker registry secret required to pull the image.",
+									Optional:    true,
+								},
+								"image_pull_policy": {
+									Type:        schema.TypeString,
+									Description: "Image pull policy. One of Always, Never, IfNotPresent. Defaults to Always if :latest tag is specified, or IfNotPresent otherwise.",
+									Optional:    true,
+									ValidateFunc: validation.StringInSlice([]string{
+										string(k8sv1.PullAlways),
+										string(k8sv1.PullNever),
+										string(k8sv1.PullIfNotPresent),
+									}, false),
+								},
+								"kernel_path": {
+									Type:        schema.TypeString,
+									Description: "The fully-qualified path to the kernel image in the host OS.",
+									Optional:    true,
+								},
+								"initrd_path": {
+									Type:        schema.TypeString,
+									Description: "The fully-qualified path to the ramdisk image in the host OS.",
+									Optional:    true,
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func firmwareSchema() *schema.Schema {
+	fields := firmwareFields()
+
+	return &schema.Schema{
+		Type:        schema.TypeList,
+		Description: "Firmware allows specifying the bootloader, kernel boot and the identifiers reported by the vmi firmware.",
+		Optional:    true,
+		Computed:    true,
+		MaxItems:    1,
+		Elem: &schema.Resource{
+			Schema: fields,
+		},
+	}
+}
+
+func expandFirmware(firmware []interface{}) (*kubevirtapiv1.Firmware, error) {
+	if len(firmware) == 0 || firmware[0] == nil {
+		return nil, nil
+	}
+
+	result := &kubevirtapiv1.Firmware{}
+
+	in := firmware[0].(map[string]interface{})
+
+	if v, ok := in["uuid"].(string); ok {
+		result.UUID = types.UID(v)
+	}
+	if v, ok := in["serial"].(string); ok {
+		result.Serial = v
+	}
+	if v, ok := in["bootloader"].([]interface{}); ok {
+		bootloader, err := expandBootloader(v)
+		if err != nil {
+			return result, err
+		}
+		result.Bootloader = bootloader
+	}
+	if v, ok := in["kernel_boot"].([]interface{}); ok {
+		result.KernelBoot = expandKernelBoot(v)
+	}
+
+	return result, nil
+}
+
+func expandBootloader(bootloader []interface{}) (*kubevirtapiv1.Bootloader, error) {
+	if len(bootloader) == 0 || bootloader[0] == nil {
+		return nil, nil
+	}
+
+	result := &kubevirtapiv1.Bootloader{}
+
+	in := bootloader[0].(map[string]interface{})
+
+	// Both bootloaders may be declared as empty blocks, so presence rather than content selects them.
+	if v, ok := in["bios"].([]interface{}); ok && len(v) > 0 {
+		result.BIOS = &kubevirtapiv1.BIOS{}
+		if bios, ok := v[0].(map[string]interface{}); ok {
+			if useSerial, ok := bios["use_serial"].(bool); ok && useSerial {
+				result.BIOS.UseSerial = &useSerial
+			}
+		}
+	}
+	if v, ok := in["efi"].([]interface{}); ok && len(v) > 0 {
+		secureBoot := true
+		if efi, ok := v[0].(map[string]interface{}); ok {
+			if b, ok := efi["secure_boot"].(bool); ok {
+				secureBoot = b
+			}
+		}
+		result.EFI = &kubevirtapiv1.EFI{
+			SecureBoot: &secureBoot,
+		}
+	}
+
+	if (result.BIOS == nil) == (result.EFI == nil) {
+		return result, fmt.Errorf("exactly one of bios or efi must be set in the bootloader")
+	}
+
+	return result, nil
+}
+
+func expandKernelBoot(kernelBoot []interface{}) *kubevirtapiv1.KernelBoot {
+	if len(kernelBoot) == 0 || kernelBoot[0] == nil {
+		return nil
+	}
+
+	result := &kubevirtapiv1.KernelBoot{}
+
+	in := kernelBoot[0].(map[string]interface{})
+
+	if v, ok := in["kernel_args"].(string); ok {
+		result.KernelArgs = v
+	}
+	if v, ok := in["container"].([]interface{}); ok && len(v) > 0 && v[0] != nil {
+		container := v[0].(map[string]interface{})
+		result.Container = &kubevirtapiv1.KernelBootContainer{}
+		if v, ok := container["image"].(string); ok {
+			result.Container.Image = v
+		}
+		if v, ok := container["image_pull_secret"].(string); ok {
+			result.Container.ImagePullSecret = v
+		}
+		if v, ok := container["image_pull_policy"].(string); ok {
+			result.Container.ImagePullPolicy = k8sv1.PullPolicy(v)
+		}
+		if v, ok := container["kernel_path"].(string); ok {
+			result.Container.KernelPath = v
+		}
+		if v, ok := container["initrd_path"].(string); ok {
+			result.Container.InitrdPath = v
+		}
+	}
+
+	return result
+}
+
+// secureBootEnabled reports whether the firmware boots through EFI with Secure Boot,
+// which is the default once EFI is selected.
+func secureBootEnabled(firmware *kubevirtapiv1.Firmware) bool {
+	if firmware == nil || firmware.Bootloader == nil || firmware.Bootloader.EFI == nil {
+		return false
+	}
+	secureBoot := firmware.Bootloader.EFI.SecureBoot
+	return secureBoot == nil || *secureBoot
+}
+
+func flattenFirmware(in kubevirtapiv1.Firmware) []interface{} {
+	att := make(map[string]interface{})
+
+	att["uuid"] = string(in.UUID)
+	att["serial"] = in.Serial
+	if in.Bootloader != nil {
+		att["bootloader"] = flattenBootloader(*in.Bootloader)
+	}
+	if in.KernelBoot != nil {
+		att["kernel_boot"] = flattenKernelBoot(*in.KernelBoot)
+	}
+
+	return []interface{}{att}
+}
+
+func flattenBootloader(in kubevirtapiv1.Bootloader) []interface{} {
+	att := make(map[string]interface{})
+
+	if in.BIOS != nil {
+		att["bios"] = []interface{}{map[string]interface{}{
+			"use_serial": in.BIOS.UseSerial != nil && *in.BIOS.UseSerial,
+		}}
+	}
+	if in.EFI != nil {
+		att["efi"] = []interface{}{map[string]interface{}{
+			"secure_boot": in.EFI.SecureBoot == nil || *in.EFI.SecureBoot,
+		}}
+	}
+
+	return []interface{}{att}
+}
+
+func flattenKernelBoot(in kubevirtapiv1.KernelBoot) []interface{} {
+	att := make(map[string]interface{})
+
+	att["kernel_args"] = in.KernelArgs
+	if in.Container != nil {
+		att["container"] = []interface{}{map[string]interface{}{
+			"image":             in.Container.Image,
+			"image_pull_secret": in.Container.ImagePullSecret,
+			"image_pull_policy": string(in.Container.ImagePullPolicy),
+			"kernel_path":       in.Container.KernelPath,
+			"initrd_path":       in.Container.InitrdPath,
+		}}
+	}
+
+	return []interface{}{att}
+}
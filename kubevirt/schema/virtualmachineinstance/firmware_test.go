@@ -0,0 +1,156 @@
+package virtualmachineinstance
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+	kubevirtapiv1 "kubevirt.io/api/core/v1"
+
+	"gotest.tools/assert"
+)
+
+func TestExpandDomainSpecSecureBoot(t *testing.T) {
+	efi := func(secureBoot bool) []interface{} {
+		return []interface{}{
+			map[string]interface{}{
+				"bootloader": []interface{}{
+					map[string]interface{}{
+						"efi": []interface{}{
+							map[string]interface{}{
+								"secure_boot": secureBoot,
+							},
+						},
+					},
+				},
+			},
+		}
+	}
+	smm := func(enabled bool) []interface{} {
+		return []interface{}{
+			map[string]interface{}{
+				"smm": []interface{}{
+					map[string]interface{}{
+						"enabled": enabled,
+					},
+				},
+			},
+		}
+	}
+
+	cases := []struct {
+		name          string
+		input         map[string]interface{}
+		expectedError string
+	}{
+		{
+			name: "secure boot with smm",
+			input: map[string]interface{}{
+				"firmware": efi(true),
+				"features": smm(true),
+			},
+		},
+		{
+			name: "efi without secure boot",
+			input: map[string]interface{}{
+				"firmware": efi(false),
+			},
+		},
+		{
+			name: "secure boot without smm",
+			input: map[string]interface{}{
+				"firmware": efi(true),
+			},
+			expectedError: "secure boot requires features.smm to be enabled",
+		},
+		{
+			name: "secure boot with smm disabled",
+			input: map[string]interface{}{
+				"firmware": efi(true),
+				"features": smm(false),
+			},
+			expectedError: "secure boot requires features.smm to be enabled",
+		},
+		{
+			name: "no bootloader selected",
+			input: map[string]interface{}{
+				"firmware": []interface{}{
+					map[string]interface{}{
+						"bootloader": []interface{}{
+							map[string]interface{}{},
+						},
+					},
+				},
+			},
+			expectedError: "exactly one of bios or efi must be set in the bootloader",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := expandDomainSpec([]interface{}{tc.input})
+
+			if tc.expectedError == "" {
+				assert.NilError(t, err)
+			} else {
+				assert.Error(t, err, tc.expectedError)
+			}
+		})
+	}
+}
+
+func TestFirmwareDefaultedUUID(t *testing.T) {
+	useSerial := true
+
+	cases := []struct {
+		name     string
+		firmware kubevirtapiv1.Firmware
+		raw      map[string]interface{}
+	}{
+		{
+			name:     "firmware not configured",
+			firmware: kubevirtapiv1.Firmware{UUID: "5d307ca9-b3ef-428c-8861-06e72d69f223"},
+			raw:      map[string]interface{}{},
+		},
+		{
+			name: "bootloader configured",
+			firmware: kubevirtapiv1.Firmware{
+				UUID: "5d307ca9-b3ef-428c-8861-06e72d69f223",
+				Bootloader: &kubevirtapiv1.Bootloader{
+					BIOS: &kubevirtapiv1.BIOS{UseSerial: &useSerial},
+				},
+			},
+			raw: map[string]interface{}{
+				"firmware": []interface{}{
+					map[string]interface{}{
+						"bootloader": []interface{}{
+							map[string]interface{}{
+								"bios": []interface{}{
+									map[string]interface{}{"use_serial": true},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	resource := &schema.Resource{
+		Schema: map[string]*schema.Schema{"firmware": firmwareSchema()},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			resourceData := resource.TestResourceData()
+			resourceData.SetId("test")
+			assert.NilError(t, resourceData.Set("firmware", flattenFirmware(tc.firmware)))
+
+			diff, err := resource.Diff(context.Background(), resourceData.State(), terraform.NewResourceConfigRaw(tc.raw), nil)
+
+			assert.NilError(t, err)
+			assert.Assert(t, diff.Empty(), "unexpected diff: %v", diff)
+		})
+	}
+}
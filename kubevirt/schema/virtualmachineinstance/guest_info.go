@@ -0,0 +1,223 @@
+package virtualmachineinstance
+
+import (
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	k8sv1 "k8s.io/api/core/v1"
+	kubevirtapiv1 "kubevirt.io/api/core/v1"
+)
+
+// infoSourceGuestAgent marks interface details reported by the guest agent.
+const infoSourceGuestAgent = "guest-agent"
+
+func GuestInfoFields() map[string]*schema.Schema {
+	return map[string]*schema.Schema{
+		"name": {
+			Type:        schema.TypeString,
+			Description: "Name of the virtual machine instance, which is the name of the virtual machine running it.",
+			Required:    true,
+		},
+		"namespace": {
+			Type:        schema.TypeString,
+			Description: "Namespace of the virtual machine instance.",
+			Optional:    true,
+			Default:     "default",
+		},
+		"wait_for_interface": {
+			Type:        schema.TypeString,
+			Description: "Name of an interface, either the network name or the interface name inside the guest, to wait for until the guest agent reports an IP address on it. Waiting is bound by the read timeout.",
+			Optional:    true,
+		},
+		"ip_address": {
+			Type:        schema.TypeString,
+			Description: "IP address of the interface named in wait_for_interface, or of the first interface reporting one.",
+			Computed:    true,
+		},
+		"interfaces": virtualMachineInstanceInterfacesSchema(),
+		"guest_agent_connected": {
+			Type:        schema.TypeBool,
+			Description: "Whether the guest agent is connected. The guest agent attributes are only populated when it is.",
+			Computed:    true,
+		},
+		"guest_agent_version": {
+			Type:        schema.TypeString,
+			Description: "Version of the guest agent.",
+			Computed:    true,
+		},
+		"hostname": {
+			Type:        schema.TypeString,
+			Description: "Hostname of the guest.",
+			Computed:    true,
+		},
+		"timezone": {
+			Type:        schema.TypeString,
+			Description: "Timezone of the guest.",
+			Computed:    true,
+		},
+		"os":          virtualMachineInstanceGuestOSInfoSchema(),
+		"users":       guestUsersSchema(),
+		"filesystems": guestFileSystemsSchema(),
+	}
+}
+
+func guestUsersSchema() *schema.Schema {
+	return &schema.Schema{
+		Type:        schema.TypeList,
+		Description: "Users logged into the guest.",
+		Computed:    true,
+		Elem: &schema.Resource{
+			Schema: map[string]*schema.Schema{
+				"user_name": {
+					Type:        schema.TypeString,
+					Description: "Name of the user.",
+					Computed:    true,
+				},
+				"domain": {
+					Type:        schema.TypeString,
+					Description: "Domain of the user.",
+					Computed:    true,
+				},
+				"login_time": {
+					Type:        schema.TypeFloat,
+					Description: "Login time of the user, in seconds since the epoch.",
+					Computed:    true,
+				},
+			},
+		},
+	}
+}
+
+func guestFileSystemsSchema() *schema.Schema {
+	return &schema.Schema{
+		Type:        schema.TypeList,
+		Description: "File systems mounted in the guest.",
+		Computed:    true,
+		Elem: &schema.Resource{
+			Schema: map[string]*schema.Schema{
+				"disk_name": {
+					Type:        schema.TypeString,
+					Description: "Name of the disk holding the file system.",
+					Computed:    true,
+				},
+				"mount_point": {
+					Type:        schema.TypeString,
+					Description: "Mount point of the file system.",
+					Computed:    true,
+				},
+				"file_system_type": {
+					Type:        schema.TypeString,
+					Description: "Type of the file system.",
+					Computed:    true,
+				},
+				"used_bytes": {
+					Type:        schema.TypeInt,
+					Description: "Used space of the file system, in bytes.",
+					Computed:    true,
+				},
+				"total_bytes": {
+					Type:        schema.TypeInt,
+					Description: "Total size of the file system, in bytes.",
+					Computed:    true,
+				},
+			},
+		},
+	}
+}
+
+// GuestAgentConnected reports whether the guest agent of the virtual machine instance is connected.
+func GuestAgentConnected(vmi *kubevirtapiv1.VirtualMachineInstance) bool {
+	for _, condition := range vmi.Status.Conditions {
+		if condition.Type == kubevirtapiv1.VirtualMachineInstanceAgentConnected {
+			return condition.Status == k8sv1.ConditionTrue
+		}
+	}
+	return false
+}
+
+// GuestAgentIPAddress returns the IP address the guest agent reports on the interface
+// with the given network or guest interface name, or an empty string if there's none yet.
+func GuestAgentIPAddress(vmi *kubevirtapiv1.VirtualMachineInstance, interfaceName string) string {
+	for _, iface := range vmi.Status.Interfaces {
+		if iface.Name != interfaceName && iface.InterfaceName != interfaceName {
+			continue
+		}
+		if iface.IP != "" && strings.Contains(iface.InfoSource, infoSourceGuestAgent) {
+			return iface.IP
+		}
+	}
+	return ""
+}
+
+// IPAddress returns the IP address of the interface with the given network or guest
+// interface name, or of the first interface reporting one if the name is empty.
+func IPAddress(vmi *kubevirtapiv1.VirtualMachineInstance, interfaceName string) string {
+	for _, iface := range vmi.Status.Interfaces {
+		if interfaceName != "" && iface.Name != interfaceName && iface.InterfaceName != interfaceName {
+			continue
+		}
+		if iface.IP != "" {
+			return iface.IP
+		}
+	}
+	return ""
+}
+
+// GuestInfoToResourceData stores the interfaces of the virtual machine instance and
+// the details reported by its guest agent, which are nil when it isn't connected.
+func GuestInfoToResourceData(vmi kubevirtapiv1.VirtualMachineInstance, guestInfo *kubevirtapiv1.VirtualMachineInstanceGuestAgentInfo, users []kubevirtapiv1.VirtualMachineInstanceGuestOSUser, fileSystems []kubevirtapiv1.VirtualMachineInstanceFileSystem, resourceData *schema.ResourceData) error {
+	att := map[string]interface{}{
+		"ip_address":            IPAddress(&vmi, resourceData.Get("wait_for_interface").(string)),
+		"interfaces":            flattenVirtualMachineInstanceInterfaces(vmi.Status.Interfaces),
+		"guest_agent_connected": guestInfo != nil,
+		"guest_agent_version":   "",
+		"hostname":              "",
+		"timezone":              "",
+		"os":                    []interface{}{},
+		"users":                 flattenGuestUsers(users),
+		"filesystems":           flattenGuestFileSystems(fileSystems),
+	}
+	if guestInfo != nil {
+		att["guest_agent_version"] = guestInfo.GAVersion
+		att["hostname"] = guestInfo.Hostname
+		att["timezone"] = guestInfo.Timezone
+		att["os"] = flattenVirtualMachineInstanceGuestOSInfo(guestInfo.OS)
+	}
+
+	for k, v := range att {
+		if err := resourceData.Set(k, v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func flattenGuestUsers(in []kubevirtapiv1.VirtualMachineInstanceGuestOSUser) []interface{} {
+	att := make([]interface{}, len(in))
+
+	for i, v := range in {
+		c := make(map[string]interface{})
+		c["user_name"] = v.UserName
+		c["domain"] = v.Domain
+		c["login_time"] = v.LoginTime
+		att[i] = c
+	}
+
+	return att
+}
+
+func flattenGuestFileSystems(in []kubevirtapiv1.VirtualMachineInstanceFileSystem) []interface{} {
+	att := make([]interface{}, len(in))
+
+	for i, v := range in {
+		c := make(map[string]interface{})
+		c["disk_name"] = v.DiskName
+		c["mount_point"] = v.MountPoint
+		c["file_system_type"] = v.FileSystemType
+		c["used_bytes"] = v.UsedBytes
+		c["total_bytes"] = v.TotalBytes
+		att[i] = c
+	}
+
+	return att
+}
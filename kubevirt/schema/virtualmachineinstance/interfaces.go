@@ -0,0 +1,370 @@
+package virtualmachineinstance
+
+import (
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	kubevirtapiv1 "kubevirt.io/api/core/v1"
+)
+
+func interfaceFields() map[string]*schema.Schema {
+	return map[string]*schema.Schema{
+		"name": {
+			Type:        schema.TypeString,
+			Description: "Logical name of the interface as well as a reference to the associated networks.",
+			Required:    true,
+		},
+		"interface_binding_method": {
+			Type: schema.TypeString,
+			ValidateFunc: validation.StringInSlice([]string{
+				"InterfaceBridge",
+				"InterfaceSlirp",
+				"InterfaceMasquerade",
+				"InterfaceSRIOV",
+				"InterfaceMacvtap",
+				"InterfacePasst",
+			}, false),
+			Description: "Represents the method which will be used to connect the interface to the guest.",
+			Required:    true,
+		},
+		"model": {
+			Type:        schema.TypeString,
+			Description: "Interface model. Defaults to virtio.",
+			Optional:    true,
+			ValidateFunc: validation.StringInSlice([]string{
+				"e1000",
+				"e1000e",
+				"ne2k_pci",
+				"pcnet",
+				"rtl8139",
+				"virtio",
+			}, false),
+		},
+		"mac_address": {
+			Type:        schema.TypeString,
+			Description: "Interface MAC address. For example: de:ad:00:00:be:af or DE-AD-00-00-BE-AF.",
+			Optional:    true,
+		},
+		"ports": {
+			Type:        schema.TypeList,
+			Description: "List of ports to be forwarded to the virtual machine. Only supported by the masquerade, slirp and passt binding methods.",
+			Optional:    true,
+			Elem: &schema.Resource{
+				Schema: map[string]*schema.Schema{
+					"name": {
+						Type:        schema.TypeString,
+						Description: "Name for the port that can be referred to by services. If specified, this must be an IANA_SVC_NAME and unique within the pod.",
+						Optional:    true,
+					},
+					"protocol": {
+						Type:         schema.TypeString,
+						Description:  "Protocol for port. Must be UDP or TCP. Defaults to TCP.",
+						Optional:     true,
+						ValidateFunc: validation.StringInSlice([]string{"TCP", "UDP"}, false),
+					},
+					"port": {
+						Type:         schema.TypeInt,
+						Description:  "Number of port to expose for the virtual machine.",
+						Required:     true,
+						ValidateFunc: validation.IsPortNumber,
+					},
+				},
+			},
+		},
+		"boot_order": {
+			Type:         schema.TypeInt,
+			Description:  "BootOrder is an integer value > 0, used to determine ordering of boot devices. Lower values take precedence. Each interface or disk that has a boot order must have a unique value.",
+			Optional:     true,
+			ValidateFunc: validation.IntAtLeast(1),
+		},
+		"pci_address": {
+			Type:        schema.TypeString,
+			Description: "If specified, the virtual network interface will be placed on the guests pci address with the specified PCI address. For example: 0000:81:01.10",
+			Optional:    true,
+		},
+		"dhcp_options": {
+			Type:        schema.TypeList,
+			Description: "If specified the network interface will pass additional DHCP options to the VMI.",
+			Optional:    true,
+			MaxItems:    1,
+			Elem: &schema.Resource{
+				Schema: map[string]*schema.Schema{
+					"boot_file_name": {
+						Type:        schema.TypeString,
+						Description: "If specified will pass option 67 to interface's DHCP server.",
+						Optional:    true,
+					},
+					"tftp_server_name": {
+						Type:        schema.TypeString,
+						Description: "If specified will pass option 66 to interface's DHCP server.",
+						Optional:    true,
+					},
+					"ntp_servers": {
+						Type:        schema.TypeList,
+						Description: "If specified will pass the configured NTP server to the VM via DHCP option 042.",
+						Optional:    true,
+						Elem: &schema.Schema{
+							Type: schema.TypeString,
+						},
+					},
+					"private_options": {
+						Type:        schema.TypeList,
+						Description: "If specified will pass extra DHCP options for private use.",
+						Optional:    true,
+						Elem: &schema.Resource{
+							Schema: map[string]*schema.Schema{
+								"option": {
+									Type:         schema.TypeInt,
+									Description:  "Option is an Integer value from 224-254.",
+									Required:     true,
+									ValidateFunc: validation.IntBetween(224, 254),
+								},
+								"value": {
+									Type:        schema.TypeString,
+									Description: "Value is a String value for the Option provided.",
+									Required:    true,
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		"acpi_index": {
+			Type:         schema.TypeInt,
+			Description:  "If specified, the ACPI index is used to provide network interface device naming, that is stable across changes in PCI addresses assigned to the device. Must be unique across all devices.",
+			Optional:     true,
+			ValidateFunc: validation.IntBetween(1, 16*1024-1),
+		},
+	}
+}
+
+func interfaceSchema() *schema.Schema {
+	fields := interfaceFields()
+
+	return &schema.Schema{
+		Type:        schema.TypeList,
+		Description: "Interfaces describe network interfaces which are added to the vmi.",
+		Optional:    true,
+		Elem: &schema.Resource{
+			Schema: fields,
+		},
+	}
+}
+
+func expandInterfaces(interfaces []interface{}) []kubevirtapiv1.Interface {
+	result := make([]kubevirtapiv1.Interface, len(interfaces))
+
+	if len(interfaces) == 0 || interfaces[0] == nil {
+		return result
+	}
+
+	for i, condition := range interfaces {
+		in := condition.(map[string]interface{})
+
+		if v, ok := in["name"].(string); ok {
+			result[i].Name = v
+		}
+		if v, ok := in["interface_binding_method"].(string); ok {
+			result[i].InterfaceBindingMethod = expandInterfaceBindingMethod(v)
+		}
+		if v, ok := in["model"].(string); ok {
+			result[i].Model = v
+		}
+		if v, ok := in["mac_address"].(string); ok {
+			result[i].MacAddress = v
+		}
+		if v, ok := in["ports"].([]interface{}); ok {
+			result[i].Ports = expandPorts(v)
+		}
+		if v, ok := in["boot_order"].(int); ok && v > 0 {
+			bootOrder := uint(v)
+			result[i].BootOrder = &bootOrder
+		}
+		if v, ok := in["pci_address"].(string); ok {
+			result[i].PciAddress = v
+		}
+		if v, ok := in["dhcp_options"].([]interface{}); ok {
+			result[i].DHCPOptions = expandDHCPOptions(v)
+		}
+		if v, ok := in["acpi_index"].(int); ok {
+			result[i].ACPIIndex = v
+		}
+	}
+
+	return result
+}
+
+func expandInterfaceBindingMethod(interfaceBindingMethod string) kubevirtapiv1.InterfaceBindingMethod {
+	result := kubevirtapiv1.InterfaceBindingMethod{}
+
+	switch interfaceBindingMethod {
+	case "InterfaceBridge":
+		result.Bridge = &kubevirtapiv1.InterfaceBridge{}
+	case "InterfaceSlirp":
+		result.Slirp = &kubevirtapiv1.InterfaceSlirp{}
+	case "InterfaceMasquerade":
+		result.Masquerade = &kubevirtapiv1.InterfaceMasquerade{}
+	case "InterfaceSRIOV":
+		result.SRIOV = &kubevirtapiv1.InterfaceSRIOV{}
+	case "InterfaceMacvtap":
+		result.Macvtap = &kubevirtapiv1.InterfaceMacvtap{}
+	case "InterfacePasst":
+		result.Passt = &kubevirtapiv1.InterfacePasst{}
+	}
+
+	return result
+}
+
+func expandPorts(ports []interface{}) []kubevirtapiv1.Port {
+	if len(ports) == 0 {
+		return nil
+	}
+
+	result := make([]kubevirtapiv1.Port, 0, len(ports))
+
+	for _, port := range ports {
+		in, ok := port.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		p := kubevirtapiv1.Port{}
+		if v, ok := in["name"].(string); ok {
+			p.Name = v
+		}
+		if v, ok := in["protocol"].(string); ok {
+			p.Protocol = v
+		}
+		if v, ok := in["port"].(int); ok {
+			p.Port = int32(v)
+		}
+		result = append(result, p)
+	}
+
+	return result
+}
+
+func expandDHCPOptions(dhcpOptions []interface{}) *kubevirtapiv1.DHCPOptions {
+	if len(dhcpOptions) == 0 {
+		return nil
+	}
+
+	result := &kubevirtapiv1.DHCPOptions{}
+
+	in, ok := dhcpOptions[0].(map[string]interface{})
+	if !ok {
+		return result
+	}
+
+	if v, ok := in["boot_file_name"].(string); ok {
+		result.BootFileName = v
+	}
+	if v, ok := in["tftp_server_name"].(string); ok {
+		result.TFTPServerName = v
+	}
+	if v, ok := in["ntp_servers"].([]interface{}); ok && len(v) > 0 {
+		for _, server := range v {
+			if server, ok := server.(string); ok {
+				result.NTPServers = append(result.NTPServers, server)
+			}
+		}
+	}
+	if v, ok := in["private_options"].([]interface{}); ok && len(v) > 0 {
+		for _, option := range v {
+			if option, ok := option.(map[string]interface{}); ok {
+				result.PrivateOptions = append(result.PrivateOptions, kubevirtapiv1.DHCPPrivateOptions{
+					Option: option["option"].(int),
+					Value:  option["value"].(string),
+				})
+			}
+		}
+	}
+
+	return result
+}
+
+func flattenInterfaces(in []kubevirtapiv1.Interface) []interface{} {
+	att := make([]interface{}, len(in))
+
+	for i, v := range in {
+		c := make(map[string]interface{})
+
+		c["name"] = v.Name
+		c["interface_binding_method"] = flattenInterfaceBindingMethod(v.InterfaceBindingMethod)
+		c["model"] = v.Model
+		c["mac_address"] = v.MacAddress
+		c["ports"] = flattenPorts(v.Ports)
+		c["boot_order"] = 0
+		if v.BootOrder != nil {
+			c["boot_order"] = int(*v.BootOrder)
+		}
+		c["pci_address"] = v.PciAddress
+		if v.DHCPOptions != nil {
+			c["dhcp_options"] = flattenDHCPOptions(*v.DHCPOptions)
+		}
+		c["acpi_index"] = v.ACPIIndex
+
+		att[i] = c
+	}
+
+	return att
+}
+
+func flattenInterfaceBindingMethod(in kubevirtapiv1.InterfaceBindingMethod) string {
+	if in.Bridge != nil {
+		return "InterfaceBridge"
+	}
+	if in.Slirp != nil {
+		return "InterfaceSlirp"
+	}
+	if in.Masquerade != nil {
+		return "InterfaceMasquerade"
+	}
+	if in.SRIOV != nil {
+		return "InterfaceSRIOV"
+	}
+	if in.Macvtap != nil {
+		return "InterfaceMacvtap"
+	}
+	if in.Passt != nil {
+		return "InterfacePasst"
+	}
+
+	return ""
+}
+
+func flattenPorts(in []kubevirtapiv1.Port) []interface{} {
+	att := make([]interface{}, len(in))
+
+	for i, v := range in {
+		att[i] = map[string]interface{}{
+			"name":     v.Name,
+			"protocol": v.Protocol,
+			"port":     int(v.Port),
+		}
+	}
+
+	return att
+}
+
+func flattenDHCPOptions(in kubevirtapiv1.DHCPOptions) []interface{} {
+	att := make(map[string]interface{})
+
+	att["boot_file_name"] = in.BootFileName
+	att["tftp_server_name"] = in.TFTPServerName
+	ntpServers := make([]interface{}, len(in.NTPServers))
+	for i, v := range in.NTPServers {
+		ntpServers[i] = v
+	}
+	att["ntp_servers"] = ntpServers
+	privateOptions := make([]interface{}, len(in.PrivateOptions))
+	for i, v := range in.PrivateOptions {
+		privateOptions[i] = map[string]interface{}{
+			"option": v.Option,
+			"value":  v.Value,
+		}
+	}
+	att["private_options"] = privateOptions
+
+	return []interface{}{att}
+}
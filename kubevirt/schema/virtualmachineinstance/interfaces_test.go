@@ -0,0 +1,41 @@
+package virtualmachineinstance
+
+import (
+	"testing"
+
+	kubevirtapiv1 "kubevirt.io/api/core/v1"
+
+	"gotest.tools/assert"
+)
+
+func TestExpandFlattenInterfaces(t *testing.T) {
+	bootOrder := uint(1)
+	interfaces := []kubevirtapiv1.Interface{
+		{
+			Name: "default",
+			InterfaceBindingMethod: kubevirtapiv1.InterfaceBindingMethod{
+				Passt: &kubevirtapiv1.InterfacePasst{},
+			},
+			Model: "virtio",
+			Ports: []kubevirtapiv1.Port{
+				{Port: 22},
+				{Name: "dns", Protocol: "UDP", Port: 53},
+			},
+			BootOrder: &bootOrder,
+			DHCPOptions: &kubevirtapiv1.DHCPOptions{
+				NTPServers: []string{"10.0.0.1", "10.0.0.2"},
+			},
+		},
+		{
+			Name: "storage",
+			InterfaceBindingMethod: kubevirtapiv1.InterfaceBindingMethod{
+				Macvtap: &kubevirtapiv1.InterfaceMacvtap{},
+			},
+			MacAddress: "DE-AD-00-00-BE-AF",
+			ACPIIndex:  2,
+		},
+	}
+
+	// Flattening has to yield the same interfaces once expanded again, so that plans stay clean.
+	assert.DeepEqual(t, interfaces, expandInterfaces(flattenInterfaces(interfaces)))
+}
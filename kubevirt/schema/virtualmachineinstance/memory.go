@@ -0,0 +1,89 @@
+package virtualmachineinstance
+
+import (
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/nrp-nautilus/terraform-provider-kubevirt/kubevirt/utils"
+	"k8s.io/apimachinery/pkg/api/resource"
+	kubevirtapiv1 "kubevirt.io/api/core/v1"
+)
+
+func memoryFields() map[string]*schema.Schema {
+	return map[string]*schema.Schema{
+		"guest": {
+			Type:         schema.TypeString,
+			Description:  "Guest allows to specifying the amount of memory which is visible inside the Guest OS. The Guest must lie between Requests and Limits from the resources section. Defaults to the requested memory in the resources section if not specified.",
+			Optional:     true,
+			ValidateFunc: utils.ValidateResourceQuantity,
+		},
+		"hugepages": {
+			Type:        schema.TypeList,
+			Description: "Hugepages allow to use hugepages for the VirtualMachineInstance instead of regular memory.",
+			Optional:    true,
+			MaxItems:    1,
+			Elem: &schema.Resource{
+				Schema: map[string]*schema.Schema{
+					"page_size": {
+						Type:        schema.TypeString,
+						Description: "PageSize specifies the hugepage size, for x86_64 architecture valid values are 1Gi and 2Mi.",
+						Required:    true,
+					},
+				},
+			},
+		},
+	}
+}
+
+func memorySchema() *schema.Schema {
+	fields := memoryFields()
+
+	return &schema.Schema{
+		Type:        schema.TypeList,
+		Description: "Memory allows specifying the VirtualMachineInstance memory features.",
+		Optional:    true,
+		MaxItems:    1,
+		Elem: &schema.Resource{
+			Schema: fields,
+		},
+	}
+}
+
+func expandMemory(memory []interface{}) (*kubevirtapiv1.Memory, error) {
+	if len(memory) == 0 || memory[0] == nil {
+		return nil, nil
+	}
+
+	result := &kubevirtapiv1.Memory{}
+
+	in := memory[0].(map[string]interface{})
+
+	if v, ok := in["guest"].(string); ok && v != "" {
+		guest, err := resource.ParseQuantity(v)
+		if err != nil {
+			return result, err
+		}
+		result.Guest = &guest
+	}
+	if v, ok := in["hugepages"].([]interface{}); ok && len(v) > 0 && v[0] != nil {
+		hugepages := v[0].(map[string]interface{})
+		result.Hugepages = &kubevirtapiv1.Hugepages{
+			PageSize: hugepages["page_size"].(string),
+		}
+	}
+
+	return result, nil
+}
+
+func flattenMemory(in kubevirtapiv1.Memory) []interface{} {
+	att := make(map[string]interface{})
+
+	if in.Guest != nil {
+		att["guest"] = in.Guest.String()
+	}
+	if in.Hugepages != nil {
+		att["hugepages"] = []interface{}{map[string]interface{}{
+			"page_size": in.Hugepages.PageSize,
+		}}
+	}
+
+	return []interface{}{att}
+}
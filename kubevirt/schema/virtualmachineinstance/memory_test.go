@@ -0,0 +1,109 @@
+package virtualmachineinstance
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+	kubevirtapiv1 "kubevirt.io/api/core/v1"
+
+	"gotest.tools/assert"
+)
+
+func TestExpandFlattenMemory(t *testing.T) {
+	guest := resource.MustParse("4Gi")
+
+	cases := []struct {
+		name           string
+		input          []interface{}
+		expectedOutput *kubevirtapiv1.Memory
+	}{
+		{
+			name:  "no memory",
+			input: []interface{}{},
+		},
+		{
+			name: "guest memory",
+			input: []interface{}{
+				map[string]interface{}{
+					"guest": "4Gi",
+				},
+			},
+			expectedOutput: &kubevirtapiv1.Memory{Guest: &guest},
+		},
+		{
+			name: "hugepages",
+			input: []interface{}{
+				map[string]interface{}{
+					"hugepages": []interface{}{
+						map[string]interface{}{
+							"page_size": "2Mi",
+						},
+					},
+				},
+			},
+			expectedOutput: &kubevirtapiv1.Memory{
+				Hugepages: &kubevirtapiv1.Hugepages{PageSize: "2Mi"},
+			},
+		},
+		{
+			name: "guest memory backed by hugepages",
+			input: []interface{}{
+				map[string]interface{}{
+					"guest": "4Gi",
+					"hugepages": []interface{}{
+						map[string]interface{}{
+							"page_size": "1Gi",
+						},
+					},
+				},
+			},
+			expectedOutput: &kubevirtapiv1.Memory{
+				Guest:     &guest,
+				Hugepages: &kubevirtapiv1.Hugepages{PageSize: "1Gi"},
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			output, err := expandMemory(tc.input)
+
+			assert.NilError(t, err)
+			assert.DeepEqual(t, tc.expectedOutput, output)
+			if output != nil {
+				assert.DeepEqual(t, tc.input, flattenMemory(*output))
+			}
+		})
+	}
+}
+
+func TestExpandMemoryInvalidGuest(t *testing.T) {
+	_, err := expandMemory([]interface{}{
+		map[string]interface{}{
+			"guest": "4 gigabytes",
+		},
+	})
+
+	assert.ErrorContains(t, err, "quantities must match the regular expression")
+}
+
+func TestValidateMemoryGuest(t *testing.T) {
+	cases := []struct {
+		guest       string
+		expectError bool
+	}{
+		{guest: "4Gi"},
+		{guest: "512M"},
+		{guest: "4 gigabytes", expectError: true},
+	}
+
+	validate := memoryFields()["guest"].ValidateFunc
+
+	for _, tc := range cases {
+		t.Run(tc.guest, func(t *testing.T) {
+			_, errs := validate(tc.guest, "guest")
+
+			assert.Equal(t, tc.expectError, len(errs) > 0, "errors: %v", errs)
+		})
+	}
+}
@@ -66,16 +66,13 @@ func networksSchema() *schema.Schema {
 	fields := networkFields()
 
 	return &schema.Schema{
-		Type: schema.TypeList,
-
-		Description: fmt.Sprintf("List of networks that can be attached to a vm's virtual interface."),
+		Type:        schema.TypeList,
+		Description: "List of networks that can be attached to a vm's virtual interface. Every network needs an interface with the same name in domain.devices.interface.",
 		Optional:    true,
-		MaxItems:    1,
 		Elem: &schema.Resource{
 			Schema: fields,
 		},
 	}
-
 }
 
 func expandNetworks(networks []interface{}) []kubevirtapiv1.Network {
@@ -86,6 +83,9 @@ func expandNetworks(networks []interface{}) []kubevirtapiv1.Network {
 	}
 
 	for i, network := range networks {
+		if network == nil {
+			continue
+		}
 		in := network.(map[string]interface{})
 
 		if v, ok := in["name"].(string); ok {
@@ -119,9 +119,13 @@ func expandNetworkSource(networkSource []interface{}) kubevirtapiv1.NetworkSourc
 }
 
 func expandPodNetwork(pod []interface{}) *kubevirtapiv1.PodNetwork {
-	result := &kubevirtapiv1.PodNetwork{}
+	if len(pod) == 0 {
+		return nil
+	}
 
-	if len(pod) == 0 || pod[0] == nil {
+	// An empty pod block selects the pod network with the default CIDR.
+	result := &kubevirtapiv1.PodNetwork{}
+	if pod[0] == nil {
 		return result
 	}
 
@@ -152,6 +156,29 @@ func expandMultusNetwork(multus []interface{}) *kubevirtapiv1.MultusNetwork {
 	return result
 }
 
+// validateNetworkInterfaces checks that every network is attached to the guest through
+// an interface of the same name, which KubeVirt requires.
+func validateNetworkInterfaces(networks []kubevirtapiv1.Network, interfaces []kubevirtapiv1.Interface) error {
+	interfaceNames := make(map[string]bool, len(interfaces))
+	for _, iface := range interfaces {
+		interfaceNames[iface.Name] = true
+	}
+
+	networkNames := make(map[string]bool, len(networks))
+	for _, network := range networks {
+		if networkNames[network.Name] {
+			return fmt.Errorf("network %q is defined more than once", network.Name)
+		}
+		networkNames[network.Name] = true
+
+		if !interfaceNames[network.Name] {
+			return fmt.Errorf("network %q has no matching interface in domain.devices.interface", network.Name)
+		}
+	}
+
+	return nil
+}
+
 func flattenNetworks(in []kubevirtapiv1.Network) []interface{} {
 	att := make([]interface{}, len(in))
 
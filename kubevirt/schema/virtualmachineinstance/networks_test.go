@@ -0,0 +1,163 @@
+package virtualmachineinstance
+
+import (
+	"testing"
+
+	kubevirtapiv1 "kubevirt.io/api/core/v1"
+
+	"gotest.tools/assert"
+)
+
+func TestExpandFlattenNetworks(t *testing.T) {
+	input := []interface{}{
+		map[string]interface{}{
+			"name": "default",
+			"network_source": []interface{}{
+				map[string]interface{}{
+					"pod":    []interface{}{nil},
+					"multus": []interface{}{},
+				},
+			},
+		},
+		map[string]interface{}{
+			"name": "storage",
+			"network_source": []interface{}{
+				map[string]interface{}{
+					"pod": []interface{}{},
+					"multus": []interface{}{
+						map[string]interface{}{
+							"network_name": "storage-net",
+							"default":      false,
+						},
+					},
+				},
+			},
+		},
+		map[string]interface{}{
+			"name": "backup",
+			"network_source": []interface{}{
+				map[string]interface{}{
+					"multus": []interface{}{
+						map[string]interface{}{
+							"network_name": "infra/backup-net",
+						},
+					},
+				},
+			},
+		},
+	}
+
+	expectedNetworks := []kubevirtapiv1.Network{
+		{
+			Name: "default",
+			NetworkSource: kubevirtapiv1.NetworkSource{
+				Pod: &kubevirtapiv1.PodNetwork{},
+			},
+		},
+		{
+			Name: "storage",
+			NetworkSource: kubevirtapiv1.NetworkSource{
+				Multus: &kubevirtapiv1.MultusNetwork{
+					NetworkName: "storage-net",
+				},
+			},
+		},
+		{
+			Name: "backup",
+			NetworkSource: kubevirtapiv1.NetworkSource{
+				Multus: &kubevirtapiv1.MultusNetwork{
+					NetworkName: "infra/backup-net",
+				},
+			},
+		},
+	}
+
+	expectedOutput := []interface{}{
+		map[string]interface{}{
+			"name": "default",
+			"network_source": []interface{}{
+				map[string]interface{}{
+					"pod": []interface{}{
+						map[string]interface{}{
+							"vm_network_cidr": "",
+						},
+					},
+				},
+			},
+		},
+		map[string]interface{}{
+			"name": "storage",
+			"network_source": []interface{}{
+				map[string]interface{}{
+					"multus": []interface{}{
+						map[string]interface{}{
+							"network_name": "storage-net",
+							"default":      false,
+						},
+					},
+				},
+			},
+		},
+		map[string]interface{}{
+			"name": "backup",
+			"network_source": []interface{}{
+				map[string]interface{}{
+					"multus": []interface{}{
+						map[string]interface{}{
+							"network_name": "infra/backup-net",
+							"default":      false,
+						},
+					},
+				},
+			},
+		},
+	}
+
+	networks := expandNetworks(input)
+	assert.DeepEqual(t, expectedNetworks, networks)
+	assert.DeepEqual(t, expectedOutput, flattenNetworks(networks))
+}
+
+func TestValidateNetworkInterfaces(t *testing.T) {
+	networks := []kubevirtapiv1.Network{
+		{Name: "default"},
+		{Name: "storage"},
+	}
+
+	cases := []struct {
+		name          string
+		networks      []kubevirtapiv1.Network
+		interfaces    []kubevirtapiv1.Interface
+		expectedError string
+	}{
+		{
+			name:       "every network has an interface",
+			networks:   networks,
+			interfaces: []kubevirtapiv1.Interface{{Name: "storage"}, {Name: "default"}},
+		},
+		{
+			name:          "missing interface",
+			networks:      networks,
+			interfaces:    []kubevirtapiv1.Interface{{Name: "default"}},
+			expectedError: "network \"storage\" has no matching interface in domain.devices.interface",
+		},
+		{
+			name:          "duplicate network",
+			networks:      append(networks, kubevirtapiv1.Network{Name: "default"}),
+			interfaces:    []kubevirtapiv1.Interface{{Name: "default"}, {Name: "storage"}},
+			expectedError: "network \"default\" is defined more than once",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := validateNetworkInterfaces(tc.networks, tc.interfaces)
+
+			if tc.expectedError == "" {
+				assert.NilError(t, err)
+			} else {
+				assert.Error(t, err, tc.expectedError)
+			}
+		})
+	}
+}
@@ -2,14 +2,56 @@ package virtualmachineinstance
 
 import (
 	"fmt"
+	"strconv"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"github.com/nrp-nautilus/terraform-provider-kubevirt/kubevirt/utils"
+	k8sv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
 	kubevirtapiv1 "kubevirt.io/api/core/v1"
 )
 
 func probeFields() map[string]*schema.Schema {
 	return map[string]*schema.Schema{
-		// TODO nargaman
+		"http_get":         probeHTTPGetSchema(),
+		"tcp_socket":       probeTCPSocketSchema(),
+		"exec":             probeExecSchema(),
+		"guest_agent_ping": probeGuestAgentPingSchema(),
+		"initial_delay_seconds": {
+			Type:         schema.TypeInt,
+			Description:  "Number of seconds after the VirtualMachineInstance has started before liveness probes are initiated.",
+			Optional:     true,
+			ValidateFunc: utils.ValidateNonNegativeInteger,
+		},
+		"period_seconds": {
+			Type:         schema.TypeInt,
+			Description:  "How often (in seconds) to perform the probe. Default to 10 seconds. Minimum value is 1.",
+			Optional:     true,
+			Computed:     true,
+			ValidateFunc: utils.ValidatePositiveInteger,
+		},
+		"timeout_seconds": {
+			Type:         schema.TypeInt,
+			Description:  "Number of seconds after which the probe times out. For exec probes the timeout fails the probe but does not terminate the command running on the guest. Defaults to 1 second. Minimum value is 1.",
+			Optional:     true,
+			Computed:     true,
+			ValidateFunc: utils.ValidatePositiveInteger,
+		},
+		"success_threshold": {
+			Type:         schema.TypeInt,
+			Description:  "Minimum consecutive successes for the probe to be considered successful after having failed. Defaults to 1. Must be 1 for liveness. Minimum value is 1.",
+			Optional:     true,
+			Computed:     true,
+			ValidateFunc: utils.ValidatePositiveInteger,
+		},
+		"failure_threshold": {
+			Type:         schema.TypeInt,
+			Description:  "Minimum consecutive failures for the probe to be considered failed after having succeeded. Defaults to 3. Minimum value is 1.",
+			Optional:     true,
+			Computed:     true,
+			ValidateFunc: utils.ValidatePositiveInteger,
+		},
 	}
 }
 
@@ -17,38 +59,316 @@ func probeSchema() *schema.Schema {
 	fields := probeFields()
 
 	return &schema.Schema{
-		Type: schema.TypeList,
-
-		Description: fmt.Sprintf("Specification of the desired behavior of the VirtualMachineInstance on the host."),
+		Type:        schema.TypeList,
+		Description: "Probe describes a health check to be performed against a VirtualMachineInstance to determine whether it is alive or ready to receive traffic. Exactly one of http_get, tcp_socket, exec or guest_agent_ping has to be set.",
 		Optional:    true,
 		MaxItems:    1,
 		Elem: &schema.Resource{
 			Schema: fields,
 		},
 	}
+}
 
+func probeHTTPGetSchema() *schema.Schema {
+	return &schema.Schema{
+		Type:        schema.TypeList,
+		Description: "HTTPGet specifies the http request to perform.",
+		Optional:    true,
+		MaxItems:    1,
+		Elem: &schema.Resource{
+			Schema: map[string]*schema.Schema{
+				"host": {
+					Type:        schema.TypeString,
+					Description: "Host name to connect to, defaults to the VirtualMachineInstance IP.",
+					Optional:    true,
+				},
+				"path": {
+					Type:        schema.TypeString,
+					Description: "Path to access on the HTTP server.",
+					Optional:    true,
+				},
+				"port": {
+					Type:         schema.TypeString,
+					Description:  "Number or name of the port to access on the VirtualMachineInstance. Number must be in the range 1 to 65535. Name must be an IANA_SVC_NAME.",
+					Required:     true,
+					ValidateFunc: utils.ValidatePortNumOrName,
+				},
+				"scheme": {
+					Type:        schema.TypeString,
+					Description: "Scheme to use for connecting to the host, \"HTTP\" or \"HTTPS\". Defaults to HTTP.",
+					Optional:    true,
+					ValidateFunc: validation.StringInSlice([]string{
+						string(k8sv1.URISchemeHTTP),
+						string(k8sv1.URISchemeHTTPS),
+					}, false),
+				},
+				"http_header": {
+					Type:        schema.TypeList,
+					Description: "Custom headers to set in the request. HTTP allows repeated headers.",
+					Optional:    true,
+					Elem: &schema.Resource{
+						Schema: map[string]*schema.Schema{
+							"name": {
+								Type:        schema.TypeString,
+								Description: "The header field name.",
+								Required:    true,
+							},
+							"value": {
+								Type:        schema.TypeString,
+								Description: "The header field value.",
+								Required:    true,
+							},
+						},
+					},
+				},
+			},
+		},
+	}
 }
 
-func expandProbe(probe []interface{}) *kubevirtapiv1.Probe {
+func probeTCPSocketSchema() *schema.Schema {
+	return &schema.Schema{
+		Type:        schema.TypeList,
+		Description: "TCPSocket specifies a connection to a TCP port.",
+		Optional:    true,
+		MaxItems:    1,
+		Elem: &schema.Resource{
+			Schema: map[string]*schema.Schema{
+				"host": {
+					Type:        schema.TypeString,
+					Description: "Host name to connect to, defaults to the VirtualMachineInstance IP.",
+					Optional:    true,
+				},
+				"port": {
+					Type:         schema.TypeString,
+					Description:  "Number or name of the port to access on the VirtualMachineInstance. Number must be in the range 1 to 65535. Name must be an IANA_SVC_NAME.",
+					Required:     true,
+					ValidateFunc: utils.ValidatePortNumOrName,
+				},
+			},
+		},
+	}
+}
+
+func probeExecSchema() *schema.Schema {
+	return &schema.Schema{
+		Type:        schema.TypeList,
+		Description: "Exec specifies a command to run in the guest through the guest agent, which has to be installed.",
+		Optional:    true,
+		MaxItems:    1,
+		Elem: &schema.Resource{
+			Schema: map[string]*schema.Schema{
+				"command": {
+					Type:        schema.TypeList,
+					Description: "Command line to execute inside the guest. The command is simply exec'd, it is not run inside a shell. Exit status of 0 is treated as live/healthy and non-zero is unhealthy.",
+					Required:    true,
+					Elem:        &schema.Schema{Type: schema.TypeString},
+				},
+			},
+		},
+	}
+}
+
+func probeGuestAgentPingSchema() *schema.Schema {
+	return &schema.Schema{
+		Type:        schema.TypeList,
+		Description: "GuestAgentPing contacts the guest agent in the VirtualMachineInstance, which has to be installed.",
+		Optional:    true,
+		MaxItems:    1,
+		Elem: &schema.Resource{
+			Schema: map[string]*schema.Schema{},
+		},
+	}
+}
+
+func expandProbe(probe []interface{}) (*kubevirtapiv1.Probe, error) {
 	if len(probe) == 0 || probe[0] == nil {
-		return nil
+		return nil, nil
 	}
 
 	result := &kubevirtapiv1.Probe{}
 
-	_ = probe[0].(map[string]interface{})
+	in := probe[0].(map[string]interface{})
+
+	handlers := 0
+	if v, ok := in["http_get"].([]interface{}); ok && len(v) > 0 {
+		result.HTTPGet = expandProbeHTTPGet(v)
+		handlers++
+	}
+	if v, ok := in["tcp_socket"].([]interface{}); ok && len(v) > 0 {
+		result.TCPSocket = expandProbeTCPSocket(v)
+		handlers++
+	}
+	if v, ok := in["exec"].([]interface{}); ok && len(v) > 0 {
+		result.Exec = expandProbeExec(v)
+		handlers++
+	}
+	// The guest agent ping has no parameters, so an empty block enables it.
+	if v, ok := in["guest_agent_ping"].([]interface{}); ok && len(v) > 0 {
+		result.GuestAgentPing = &kubevirtapiv1.GuestAgentPing{}
+		handlers++
+	}
+	if handlers != 1 {
+		return result, fmt.Errorf("exactly one of http_get, tcp_socket, exec or guest_agent_ping must be set in a probe")
+	}
+
+	if v, ok := in["initial_delay_seconds"].(int); ok {
+		result.InitialDelaySeconds = int32(v)
+	}
+	if v, ok := in["period_seconds"].(int); ok {
+		result.PeriodSeconds = int32(v)
+	}
+	if v, ok := in["timeout_seconds"].(int); ok {
+		result.TimeoutSeconds = int32(v)
+	}
+	if v, ok := in["success_threshold"].(int); ok {
+		result.SuccessThreshold = int32(v)
+	}
+	if v, ok := in["failure_threshold"].(int); ok {
+		result.FailureThreshold = int32(v)
+	}
+
+	return result, nil
+}
+
+func expandProbeHTTPGet(httpGet []interface{}) *k8sv1.HTTPGetAction {
+	result := &k8sv1.HTTPGetAction{}
 
-	// TODO nargaman
+	if httpGet[0] == nil {
+		return result
+	}
+
+	in := httpGet[0].(map[string]interface{})
+
+	if v, ok := in["host"].(string); ok {
+		result.Host = v
+	}
+	if v, ok := in["path"].(string); ok {
+		result.Path = v
+	}
+	if v, ok := in["port"].(string); ok {
+		result.Port = expandPort(v)
+	}
+	if v, ok := in["scheme"].(string); ok {
+		result.Scheme = k8sv1.URIScheme(v)
+	}
+	if v, ok := in["http_header"].([]interface{}); ok {
+		for _, header := range v {
+			if header == nil {
+				continue
+			}
+			h := header.(map[string]interface{})
+			result.HTTPHeaders = append(result.HTTPHeaders, k8sv1.HTTPHeader{
+				Name:  h["name"].(string),
+				Value: h["value"].(string),
+			})
+		}
+	}
+
+	return result
+}
+
+func expandProbeTCPSocket(tcpSocket []interface{}) *k8sv1.TCPSocketAction {
+	result := &k8sv1.TCPSocketAction{}
+
+	if tcpSocket[0] == nil {
+		return result
+	}
+
+	in := tcpSocket[0].(map[string]interface{})
+
+	if v, ok := in["host"].(string); ok {
+		result.Host = v
+	}
+	if v, ok := in["port"].(string); ok {
+		result.Port = expandPort(v)
+	}
+
+	return result
+}
+
+func expandProbeExec(exec []interface{}) *k8sv1.ExecAction {
+	result := &k8sv1.ExecAction{}
+
+	if exec[0] == nil {
+		return result
+	}
+
+	in := exec[0].(map[string]interface{})
+
+	if v, ok := in["command"].([]interface{}); ok {
+		result.Command = utils.ExpandStringSlice(v)
+	}
 
 	return result
 }
 
+// expandPort turns a port number or name into the matching IntOrString.
+func expandPort(port string) intstr.IntOrString {
+	if v, err := strconv.Atoi(port); err == nil {
+		return intstr.FromInt(v)
+	}
+	return intstr.FromString(port)
+}
+
 func flattenProbe(in kubevirtapiv1.Probe) []interface{} {
 	att := make(map[string]interface{})
 
-	// att["spec"] = flattenVirtualMachineInstanceSpecSpec(in.Spec)
-	// att["status"] = flattenVirtualMachineInstanceSpecStatus(in.Status)
-	// TODO nargaman
+	if in.HTTPGet != nil {
+		att["http_get"] = flattenProbeHTTPGet(*in.HTTPGet)
+	}
+	if in.TCPSocket != nil {
+		att["tcp_socket"] = flattenProbeTCPSocket(*in.TCPSocket)
+	}
+	if in.Exec != nil {
+		att["exec"] = flattenProbeExec(*in.Exec)
+	}
+	if in.GuestAgentPing != nil {
+		att["guest_agent_ping"] = []interface{}{map[string]interface{}{}}
+	}
+	att["initial_delay_seconds"] = int(in.InitialDelaySeconds)
+	att["period_seconds"] = int(in.PeriodSeconds)
+	att["timeout_seconds"] = int(in.TimeoutSeconds)
+	att["success_threshold"] = int(in.SuccessThreshold)
+	att["failure_threshold"] = int(in.FailureThreshold)
+
+	return []interface{}{att}
+}
+
+func flattenProbeHTTPGet(in k8sv1.HTTPGetAction) []interface{} {
+	att := make(map[string]interface{})
+
+	att["host"] = in.Host
+	att["path"] = in.Path
+	att["port"] = in.Port.String()
+	att["scheme"] = string(in.Scheme)
+	if len(in.HTTPHeaders) > 0 {
+		headers := make([]interface{}, len(in.HTTPHeaders))
+		for i, header := range in.HTTPHeaders {
+			headers[i] = map[string]interface{}{
+				"name":  header.Name,
+				"value": header.Value,
+			}
+		}
+		att["http_header"] = headers
+	}
+
+	return []interface{}{att}
+}
+
+func flattenProbeTCPSocket(in k8sv1.TCPSocketAction) []interface{} {
+	att := make(map[string]interface{})
+
+	att["host"] = in.Host
+	att["port"] = in.Port.String()
+
+	return []interface{}{att}
+}
+
+func flattenProbeExec(in k8sv1.ExecAction) []interface{} {
+	att := make(map[string]interface{})
+
+	att["command"] = in.Command
 
 	return []interface{}{att}
 }
@@ -1,6 +1,7 @@
 package virtualmachineinstance
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
@@ -74,6 +75,24 @@ func virtualMachineInstanceSpecFields() map[string]*schema.Schema {
 	}
 }
 
+// ValidateSpecDiff returns a CustomizeDiff that validates the VirtualMachineInstance spec at the
+// given key when planning, rather than once it's sent to the cluster.
+func ValidateSpecDiff(specKey string) schema.CustomizeDiffFunc {
+	return func(ctx context.Context, diff *schema.ResourceDiff, meta interface{}) error {
+		spec, ok := diff.Get(specKey).([]interface{})
+		if !ok || len(spec) == 0 || spec[0] == nil {
+			return nil
+		}
+		in := spec[0].(map[string]interface{})
+
+		if err := validateVolumeReferences(in); err != nil {
+			return err
+		}
+		volumes, _ := in["volume"].([]interface{})
+		return validateCloudInitVolumes(volumes)
+	}
+}
+
 func virtualMachineInstanceSpecSchema() *schema.Schema {
 	fields := virtualMachineInstanceSpecFields()
 
@@ -90,7 +109,7 @@ func virtualMachineInstanceSpecSchema() *schema.Schema {
 
 }
 
-func expandVirtualMachineInstanceSpec(virtualMachineInstanceSpec []interface{}) (kubevirtapiv1.VirtualMachineInstanceSpec, error) {
+func ExpandVirtualMachineInstanceSpec(virtualMachineInstanceSpec []interface{}) (kubevirtapiv1.VirtualMachineInstanceSpec, error) {
 	result := kubevirtapiv1.VirtualMachineInstanceSpec{}
 
 	if len(virtualMachineInstanceSpec) == 0 || virtualMachineInstanceSpec[0] == nil {
@@ -136,13 +155,25 @@ func expandVirtualMachineInstanceSpec(virtualMachineInstanceSpec []interface{})
 		result.TerminationGracePeriodSeconds = &seconds
 	}
 	if v, ok := in["volume"].([]interface{}); ok {
-		result.Volumes = expandVolumes(v)
+		volumes, err := expandVolumes(v)
+		if err != nil {
+			return result, err
+		}
+		result.Volumes = volumes
 	}
 	if v, ok := in["liveness_probe"].([]interface{}); ok {
-		result.LivenessProbe = expandProbe(v)
+		probe, err := expandProbe(v)
+		if err != nil {
+			return result, err
+		}
+		result.LivenessProbe = probe
 	}
 	if v, ok := in["readiness_probe"].([]interface{}); ok {
-		result.ReadinessProbe = expandProbe(v)
+		probe, err := expandProbe(v)
+		if err != nil {
+			return result, err
+		}
+		result.ReadinessProbe = probe
 	}
 	if v, ok := in["hostname"].(string); ok {
 		result.Hostname = v
@@ -152,6 +183,9 @@ func expandVirtualMachineInstanceSpec(virtualMachineInstanceSpec []interface{})
 	}
 	if v, ok := in["network"].([]interface{}); ok {
 		result.Networks = expandNetworks(v)
+		if err := validateNetworkInterfaces(result.Networks, result.Domain.Devices.Interfaces); err != nil {
+			return result, err
+		}
 	}
 	if v, ok := in["dns_policy"].(string); ok {
 		result.DNSPolicy = k8sv1.DNSPolicy(v)
@@ -167,7 +201,7 @@ func expandVirtualMachineInstanceSpec(virtualMachineInstanceSpec []interface{})
 	return result, nil
 }
 
-func flattenVirtualMachineInstanceSpec(in kubevirtapiv1.VirtualMachineInstanceSpec) []interface{} {
+func FlattenVirtualMachineInstanceSpec(in kubevirtapiv1.VirtualMachineInstanceSpec) []interface{} {
 	att := make(map[string]interface{})
 
 	att["priority_class_name"] = in.PriorityClassName
@@ -0,0 +1,262 @@
+package virtualmachineinstance
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubevirtapiv1 "kubevirt.io/api/core/v1"
+)
+
+func virtualMachineInstanceStatusFields() map[string]*schema.Schema {
+	return map[string]*schema.Schema{
+		"node_name": {
+			Type:        schema.TypeString,
+			Description: "Name of the node the VirtualMachineInstance is running on.",
+			Computed:    true,
+		},
+		"phase": {
+			Type:        schema.TypeString,
+			Description: "Phase is the status of the VirtualMachineInstance in kubernetes world, e.g. Pending, Scheduling, Scheduled, Running, Succeeded or Failed.",
+			Computed:    true,
+		},
+		"reason": {
+			Type:        schema.TypeString,
+			Description: "A brief CamelCase message indicating details about why the VirtualMachineInstance is in this state.",
+			Computed:    true,
+		},
+		"interfaces":      virtualMachineInstanceInterfacesSchema(),
+		"guest_os_info":   virtualMachineInstanceGuestOSInfoSchema(),
+		"migration_state": virtualMachineInstanceMigrationStateSchema(),
+	}
+}
+
+func virtualMachineInstanceStatusSchema() *schema.Schema {
+	fields := virtualMachineInstanceStatusFields()
+
+	return &schema.Schema{
+		Type: schema.TypeList,
+
+		Description: fmt.Sprintf("VirtualMachineInstanceStatus represents information about the status of the VirtualMachineInstance."),
+		Computed:    true,
+		Elem: &schema.Resource{
+			Schema: fields,
+		},
+	}
+
+}
+
+func virtualMachineInstanceInterfacesSchema() *schema.Schema {
+	return &schema.Schema{
+		Type:        schema.TypeList,
+		Description: "Interfaces represent the details of available network interfaces.",
+		Computed:    true,
+		Elem: &schema.Resource{
+			Schema: map[string]*schema.Schema{
+				"name": {
+					Type:        schema.TypeString,
+					Description: "Name of the interface, corresponds to the name of the network assigned to it.",
+					Computed:    true,
+				},
+				"interface_name": {
+					Type:        schema.TypeString,
+					Description: "Name of the interface inside the guest, as reported by the guest agent.",
+					Computed:    true,
+				},
+				"mac": {
+					Type:        schema.TypeString,
+					Description: "Hardware address of the interface.",
+					Computed:    true,
+				},
+				"ip_address": {
+					Type:        schema.TypeString,
+					Description: "IP address of the interface.",
+					Computed:    true,
+				},
+				"ip_addresses": {
+					Type:        schema.TypeList,
+					Description: "List of all IP addresses of the interface.",
+					Computed:    true,
+					Elem:        &schema.Schema{Type: schema.TypeString},
+				},
+			},
+		},
+	}
+}
+
+func virtualMachineInstanceGuestOSInfoSchema() *schema.Schema {
+	return &schema.Schema{
+		Type:        schema.TypeList,
+		Description: "Guest OS information, as reported by the guest agent.",
+		Computed:    true,
+		Elem: &schema.Resource{
+			Schema: map[string]*schema.Schema{
+				"name": {
+					Type:        schema.TypeString,
+					Description: "Name of the guest OS.",
+					Computed:    true,
+				},
+				"id": {
+					Type:        schema.TypeString,
+					Description: "Identifier of the guest OS.",
+					Computed:    true,
+				},
+				"pretty_name": {
+					Type:        schema.TypeString,
+					Description: "Human readable name of the guest OS.",
+					Computed:    true,
+				},
+				"version": {
+					Type:        schema.TypeString,
+					Description: "Version of the guest OS.",
+					Computed:    true,
+				},
+				"version_id": {
+					Type:        schema.TypeString,
+					Description: "Version identifier of the guest OS.",
+					Computed:    true,
+				},
+				"kernel_release": {
+					Type:        schema.TypeString,
+					Description: "Kernel release of the guest OS.",
+					Computed:    true,
+				},
+				"kernel_version": {
+					Type:        schema.TypeString,
+					Description: "Kernel version of the guest OS.",
+					Computed:    true,
+				},
+				"machine": {
+					Type:        schema.TypeString,
+					Description: "Machine type of the guest OS.",
+					Computed:    true,
+				},
+			},
+		},
+	}
+}
+
+func virtualMachineInstanceMigrationStateSchema() *schema.Schema {
+	return &schema.Schema{
+		Type:        schema.TypeList,
+		Description: "Status information of the latest live migration of the VirtualMachineInstance.",
+		Computed:    true,
+		Elem: &schema.Resource{
+			Schema: map[string]*schema.Schema{
+				"source_node": {
+					Type:        schema.TypeString,
+					Description: "The source node the VirtualMachineInstance is migrated from.",
+					Computed:    true,
+				},
+				"target_node": {
+					Type:        schema.TypeString,
+					Description: "The target node the VirtualMachineInstance is migrated to.",
+					Computed:    true,
+				},
+				"mode": {
+					Type:        schema.TypeString,
+					Description: "The migration mode in use.",
+					Computed:    true,
+				},
+				"start_timestamp": {
+					Type:        schema.TypeString,
+					Description: "The time the migration action began, in RFC3339 format.",
+					Computed:    true,
+				},
+				"end_timestamp": {
+					Type:        schema.TypeString,
+					Description: "The time the migration action ended, in RFC3339 format.",
+					Computed:    true,
+				},
+				"completed": {
+					Type:        schema.TypeBool,
+					Description: "Indicates the migration completed.",
+					Computed:    true,
+				},
+				"failed": {
+					Type:        schema.TypeBool,
+					Description: "Indicates the migration failed.",
+					Computed:    true,
+				},
+				"abort_status": {
+					Type:        schema.TypeString,
+					Description: "Indicates that the migration has been aborted.",
+					Computed:    true,
+				},
+			},
+		},
+	}
+}
+
+func flattenVirtualMachineInstanceStatus(in kubevirtapiv1.VirtualMachineInstanceStatus) []interface{} {
+	att := make(map[string]interface{})
+
+	att["node_name"] = in.NodeName
+	att["phase"] = string(in.Phase)
+	att["reason"] = in.Reason
+	att["interfaces"] = flattenVirtualMachineInstanceInterfaces(in.Interfaces)
+	att["guest_os_info"] = flattenVirtualMachineInstanceGuestOSInfo(in.GuestOSInfo)
+	if in.MigrationState != nil {
+		att["migration_state"] = flattenVirtualMachineInstanceMigrationState(*in.MigrationState)
+	}
+
+	return []interface{}{att}
+}
+
+func flattenVirtualMachineInstanceInterfaces(in []kubevirtapiv1.VirtualMachineInstanceNetworkInterface) []interface{} {
+	att := make([]interface{}, len(in))
+
+	for i, v := range in {
+		c := make(map[string]interface{})
+		c["name"] = v.Name
+		c["interface_name"] = v.InterfaceName
+		c["mac"] = v.MAC
+		c["ip_address"] = v.IP
+		c["ip_addresses"] = v.IPs
+		att[i] = c
+	}
+
+	return att
+}
+
+func flattenVirtualMachineInstanceGuestOSInfo(in kubevirtapiv1.VirtualMachineInstanceGuestOSInfo) []interface{} {
+	if in == (kubevirtapiv1.VirtualMachineInstanceGuestOSInfo{}) {
+		return []interface{}{}
+	}
+
+	att := make(map[string]interface{})
+
+	att["name"] = in.Name
+	att["id"] = in.ID
+	att["pretty_name"] = in.PrettyName
+	att["version"] = in.Version
+	att["version_id"] = in.VersionID
+	att["kernel_release"] = in.KernelRelease
+	att["kernel_version"] = in.KernelVersion
+	att["machine"] = in.Machine
+
+	return []interface{}{att}
+}
+
+func flattenVirtualMachineInstanceMigrationState(in kubevirtapiv1.VirtualMachineInstanceMigrationState) []interface{} {
+	att := make(map[string]interface{})
+
+	att["source_node"] = in.SourceNode
+	att["target_node"] = in.TargetNode
+	att["mode"] = string(in.Mode)
+	att["start_timestamp"] = flattenTime(in.StartTimestamp)
+	att["end_timestamp"] = flattenTime(in.EndTimestamp)
+	att["completed"] = in.Completed
+	att["failed"] = in.Failed
+	att["abort_status"] = string(in.AbortStatus)
+
+	return []interface{}{att}
+}
+
+func flattenTime(in *metav1.Time) string {
+	if in == nil {
+		return ""
+	}
+	return in.UTC().Format(time.RFC3339)
+}
@@ -44,7 +44,7 @@ func ExpandVirtualMachineInstanceTemplateSpec(virtualMachine []interface{}) (*ku
 		result.ObjectMeta = k8s.ExpandMetadata(v)
 	}
 	if v, ok := in["spec"].([]interface{}); ok {
-		spec, err := expandVirtualMachineInstanceSpec(v)
+		spec, err := ExpandVirtualMachineInstanceSpec(v)
 		if err != nil {
 			return result, err
 		}
@@ -58,7 +58,7 @@ func FlattenVirtualMachineInstanceTemplateSpec(in kubevirtapiv1.VirtualMachineIn
 	att := make(map[string]interface{})
 
 	att["metadata"] = k8s.FlattenMetadata(in.ObjectMeta)
-	att["spec"] = flattenVirtualMachineInstanceSpec(in.Spec)
+	att["spec"] = FlattenVirtualMachineInstanceSpec(in.Spec)
 
 	return []interface{}{att}
 }
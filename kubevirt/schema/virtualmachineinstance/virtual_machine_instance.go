@@ -0,0 +1,170 @@
+package virtualmachineinstance
+
+import (
+	"context"
+	"reflect"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/nrp-nautilus/terraform-provider-kubevirt/kubevirt/schema/k8s"
+	"github.com/nrp-nautilus/terraform-provider-kubevirt/kubevirt/utils/patch"
+	k8sv1 "k8s.io/api/core/v1"
+	kubevirtapiv1 "kubevirt.io/api/core/v1"
+)
+
+func VirtualMachineInstanceFields() map[string]*schema.Schema {
+	// The spec of a running VirtualMachineInstance can't be changed in place.
+	spec := virtualMachineInstanceSpecSchema()
+	spec.Description = "VirtualMachineInstanceSpec is a description of a VirtualMachineInstance."
+	spec.Optional = false
+	spec.Required = true
+	spec.ForceNew = true
+
+	return map[string]*schema.Schema{
+		"metadata": k8s.NamespacedMetadataSchema("VirtualMachineInstance", false),
+		"spec":     spec,
+		"status":   virtualMachineInstanceStatusSchema(),
+	}
+}
+
+// ForceNewOnSpecChange replaces the VirtualMachineInstance when its spec changes. ForceNew on
+// the spec block only covers adding or removing it, not the fields nested in it, so every
+// changed key under the spec is forced instead. Map values have no schema of their own, so
+// the map holding a changed key is forced along with it.
+func ForceNewOnSpecChange(ctx context.Context, diff *schema.ResourceDiff, meta interface{}) error {
+	if diff.Id() == "" || !diff.HasChange("spec") {
+		return nil
+	}
+	for _, key := range diff.GetChangedKeysPrefix("spec") {
+		keys := []string{key}
+		if i := strings.LastIndex(key, "."); i > 0 {
+			keys = append(keys, key[:i])
+		}
+		for _, k := range keys {
+			if !diff.HasChange(k) {
+				continue
+			}
+			if err := diff.ForceNew(k); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func ExpandVirtualMachineInstance(virtualMachineInstance []interface{}) (*kubevirtapiv1.VirtualMachineInstance, error) {
+	result := &kubevirtapiv1.VirtualMachineInstance{}
+
+	if len(virtualMachineInstance) == 0 || virtualMachineInstance[0] == nil {
+		return result, nil
+	}
+
+	in := virtualMachineInstance[0].(map[string]interface{})
+
+	if v, ok := in["metadata"].([]interface{}); ok {
+		result.ObjectMeta = k8s.ExpandMetadata(v)
+	}
+	if v, ok := in["spec"].([]interface{}); ok {
+		spec, err := ExpandVirtualMachineInstanceSpec(v)
+		if err != nil {
+			return result, err
+		}
+		result.Spec = spec
+	}
+
+	return result, nil
+}
+
+func FlattenVirtualMachineInstance(in kubevirtapiv1.VirtualMachineInstance) []interface{} {
+	att := make(map[string]interface{})
+
+	att["metadata"] = k8s.FlattenMetadata(in.ObjectMeta)
+	att["spec"] = FlattenVirtualMachineInstanceSpec(in.Spec)
+	att["status"] = flattenVirtualMachineInstanceStatus(in.Status)
+
+	return []interface{}{att}
+}
+
+func FromResourceData(resourceData *schema.ResourceData) (*kubevirtapiv1.VirtualMachineInstance, error) {
+	result := &kubevirtapiv1.VirtualMachineInstance{}
+
+	result.ObjectMeta = k8s.ExpandMetadata(resourceData.Get("metadata").([]interface{}))
+	spec, err := ExpandVirtualMachineInstanceSpec(resourceData.Get("spec").([]interface{}))
+	if err != nil {
+		return result, err
+	}
+	result.Spec = spec
+
+	return result, nil
+}
+
+func ToResourceData(vmi kubevirtapiv1.VirtualMachineInstance, resourceData *schema.ResourceData) error {
+	if err := resourceData.Set("metadata", k8s.FlattenMetadata(vmi.ObjectMeta)); err != nil {
+		return err
+	}
+	if err := resourceData.Set("spec", FlattenVirtualMachineInstanceSpec(vmi.Spec)); err != nil {
+		return err
+	}
+	if err := resourceData.Set("status", flattenVirtualMachineInstanceStatus(vmi.Status)); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// OmitServerDefaults clears the values the API server defaulted in a spec, unless the configured
+// spec sets them. The spec of a VirtualMachineInstance can't change in place, so reading those
+// defaults back into the state would replace the instance on the next plan.
+func OmitServerDefaults(spec *kubevirtapiv1.VirtualMachineInstanceSpec, configured kubevirtapiv1.VirtualMachineInstanceSpec) {
+	// Without networks the API server attaches the pod network through a default interface.
+	if len(configured.Networks) == 0 && len(configured.Domain.Devices.Interfaces) == 0 &&
+		len(spec.Networks) == 1 && spec.Networks[0].Name == kubevirtapiv1.DefaultPodNetwork().Name && spec.Networks[0].Pod != nil &&
+		len(spec.Domain.Devices.Interfaces) == 1 && spec.Domain.Devices.Interfaces[0].Name == spec.Networks[0].Name {
+		spec.Networks = nil
+		spec.Domain.Devices.Interfaces = nil
+	}
+
+	// The API server always sets the features, with ACPI enabled.
+	if features := spec.Domain.Features; features != nil {
+		if configured.Domain.Features == nil || configured.Domain.Features.ACPI.Enabled == nil {
+			features.ACPI = kubevirtapiv1.FeatureState{}
+		}
+		if configured.Domain.Features == nil && reflect.DeepEqual(*features, kubevirtapiv1.Features{}) {
+			spec.Domain.Features = nil
+		}
+		if features.Hyperv != nil && features.Hyperv.Spinlocks != nil {
+			if configured.Domain.Features == nil || configured.Domain.Features.Hyperv == nil ||
+				configured.Domain.Features.Hyperv.Spinlocks == nil || configured.Domain.Features.Hyperv.Spinlocks.Retries == nil {
+				features.Hyperv.Spinlocks.Retries = nil
+			}
+		}
+	}
+
+	configuredTrays := map[string]kubevirtapiv1.TrayState{}
+	for _, disk := range configured.Domain.Devices.Disks {
+		if disk.CDRom != nil {
+			configuredTrays[disk.Name] = disk.CDRom.Tray
+		}
+	}
+	for _, disk := range spec.Domain.Devices.Disks {
+		if disk.CDRom != nil && configuredTrays[disk.Name] == "" {
+			disk.CDRom.Tray = ""
+		}
+	}
+
+	configuredPullPolicies := map[string]k8sv1.PullPolicy{}
+	for _, volume := range configured.Volumes {
+		if volume.ContainerDisk != nil {
+			configuredPullPolicies[volume.Name] = volume.ContainerDisk.ImagePullPolicy
+		}
+	}
+	for _, volume := range spec.Volumes {
+		if volume.ContainerDisk != nil && configuredPullPolicies[volume.Name] == "" {
+			volume.ContainerDisk.ImagePullPolicy = ""
+		}
+	}
+}
+
+func AppendPatchOps(keyPrefix, pathPrefix string, resourceData *schema.ResourceData, ops []patch.PatchOperation) patch.PatchOperations {
+	return k8s.AppendPatchOps(keyPrefix+"metadata.0.", pathPrefix+"/metadata/", resourceData, ops)
+}
@@ -0,0 +1,715 @@
+package virtualmachineinstance
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+	k8sv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubevirtapiv1 "kubevirt.io/api/core/v1"
+
+	"gotest.tools/assert"
+)
+
+func TestFlattenVirtualMachineInstanceStatus(t *testing.T) {
+	startTimestamp := metav1.NewTime(time.Date(2023, 4, 1, 12, 0, 0, 0, time.UTC))
+
+	cases := []struct {
+		name           string
+		input          kubevirtapiv1.VirtualMachineInstanceStatus
+		expectedOutput []interface{}
+	}{
+		{
+			name:  "empty status",
+			input: kubevirtapiv1.VirtualMachineInstanceStatus{},
+			expectedOutput: []interface{}{
+				map[string]interface{}{
+					"node_name":     "",
+					"phase":         "",
+					"reason":        "",
+					"interfaces":    []interface{}{},
+					"guest_os_info": []interface{}{},
+				},
+			},
+		},
+		{
+			name: "running and migrating",
+			input: kubevirtapiv1.VirtualMachineInstanceStatus{
+				NodeName: "node01",
+				Phase:    kubevirtapiv1.Running,
+				Interfaces: []kubevirtapiv1.VirtualMachineInstanceNetworkInterface{
+					{
+						Name:          "default",
+						InterfaceName: "eth0",
+						MAC:           "02:00:00:00:00:01",
+						IP:            "10.0.0.5",
+						IPs:           []string{"10.0.0.5", "fd00::5"},
+					},
+				},
+				GuestOSInfo: kubevirtapiv1.VirtualMachineInstanceGuestOSInfo{
+					Name:      "Fedora Linux",
+					ID:        "fedora",
+					VersionID: "37",
+				},
+				MigrationState: &kubevirtapiv1.VirtualMachineInstanceMigrationState{
+					SourceNode:     "node01",
+					TargetNode:     "node02",
+					StartTimestamp: &startTimestamp,
+				},
+			},
+			expectedOutput: []interface{}{
+				map[string]interface{}{
+					"node_name": "node01",
+					"phase":     "Running",
+					"reason":    "",
+					"interfaces": []interface{}{
+						map[string]interface{}{
+							"name":           "default",
+							"interface_name": "eth0",
+							"mac":            "02:00:00:00:00:01",
+							"ip_address":     "10.0.0.5",
+							"ip_addresses":   []string{"10.0.0.5", "fd00::5"},
+						},
+					},
+					"guest_os_info": []interface{}{
+						map[string]interface{}{
+							"name":           "Fedora Linux",
+							"id":             "fedora",
+							"pretty_name":    "",
+							"version":        "",
+							"version_id":     "37",
+							"kernel_release": "",
+							"kernel_version": "",
+							"machine":        "",
+						},
+					},
+					"migration_state": []interface{}{
+						map[string]interface{}{
+							"source_node":     "node01",
+							"target_node":     "node02",
+							"mode":            "",
+							"start_timestamp": "2023-04-01T12:00:00Z",
+							"end_timestamp":   "",
+							"completed":       false,
+							"failed":          false,
+							"abort_status":    "",
+						},
+					},
+				},
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			output := flattenVirtualMachineInstanceStatus(tc.input)
+
+			assert.DeepEqual(t, tc.expectedOutput, output)
+		})
+	}
+}
+
+func TestGuestAgentIPAddress(t *testing.T) {
+	vmi := &kubevirtapiv1.VirtualMachineInstance{
+		Status: kubevirtapiv1.VirtualMachineInstanceStatus{
+			Interfaces: []kubevirtapiv1.VirtualMachineInstanceNetworkInterface{
+				{
+					Name:       "default",
+					IP:         "10.0.2.2",
+					InfoSource: "domain",
+				},
+				{
+					Name:          "secondary",
+					InterfaceName: "eth1",
+					IP:            "192.168.0.10",
+					InfoSource:    "domain, guest-agent",
+				},
+			},
+		},
+	}
+
+	cases := []struct {
+		interfaceName      string
+		expectedGuestAgent string
+		expectedIPAddress  string
+	}{
+		{interfaceName: "default", expectedGuestAgent: "", expectedIPAddress: "10.0.2.2"},
+		{interfaceName: "secondary", expectedGuestAgent: "192.168.0.10", expectedIPAddress: "192.168.0.10"},
+		{interfaceName: "eth1", expectedGuestAgent: "192.168.0.10", expectedIPAddress: "192.168.0.10"},
+		{interfaceName: "", expectedGuestAgent: "", expectedIPAddress: "10.0.2.2"},
+		{interfaceName: "missing", expectedGuestAgent: "", expectedIPAddress: ""},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.interfaceName, func(t *testing.T) {
+			assert.Equal(t, tc.expectedGuestAgent, GuestAgentIPAddress(vmi, tc.interfaceName))
+			assert.Equal(t, tc.expectedIPAddress, IPAddress(vmi, tc.interfaceName))
+		})
+	}
+}
+
+func TestGuestInfoToResourceData(t *testing.T) {
+	agentConnected := func(status k8sv1.ConditionStatus) []kubevirtapiv1.VirtualMachineInstanceCondition {
+		return []kubevirtapiv1.VirtualMachineInstanceCondition{
+			{Type: kubevirtapiv1.VirtualMachineInstanceAgentConnected, Status: status},
+		}
+	}
+
+	cases := []struct {
+		name               string
+		status             kubevirtapiv1.VirtualMachineInstanceStatus
+		guestInfo          *kubevirtapiv1.VirtualMachineInstanceGuestAgentInfo
+		expectedConnected  bool
+		expectedHostname   string
+		expectedOS         []interface{}
+		expectedIPAddress  string
+		expectedInterfaces []interface{}
+	}{
+		{
+			name: "connected",
+			status: kubevirtapiv1.VirtualMachineInstanceStatus{
+				Conditions: agentConnected(k8sv1.ConditionTrue),
+				Interfaces: []kubevirtapiv1.VirtualMachineInstanceNetworkInterface{
+					{Name: "default", InterfaceName: "eth0", IP: "10.0.0.5", IPs: []string{"10.0.0.5"}},
+				},
+			},
+			guestInfo: &kubevirtapiv1.VirtualMachineInstanceGuestAgentInfo{
+				Hostname: "test-vm",
+				OS:       kubevirtapiv1.VirtualMachineInstanceGuestOSInfo{Name: "Fedora Linux", ID: "fedora"},
+			},
+			expectedConnected: true,
+			expectedHostname:  "test-vm",
+			expectedOS: []interface{}{
+				map[string]interface{}{
+					"name":           "Fedora Linux",
+					"id":             "fedora",
+					"pretty_name":    "",
+					"version":        "",
+					"version_id":     "",
+					"kernel_release": "",
+					"kernel_version": "",
+					"machine":        "",
+				},
+			},
+			expectedIPAddress: "10.0.0.5",
+			expectedInterfaces: []interface{}{
+				map[string]interface{}{
+					"name":           "default",
+					"interface_name": "eth0",
+					"mac":            "",
+					"ip_address":     "10.0.0.5",
+					"ip_addresses":   []interface{}{"10.0.0.5"},
+				},
+			},
+		},
+		{
+			name: "empty guest os info",
+			status: kubevirtapiv1.VirtualMachineInstanceStatus{
+				Conditions: agentConnected(k8sv1.ConditionTrue),
+			},
+			guestInfo:          &kubevirtapiv1.VirtualMachineInstanceGuestAgentInfo{Hostname: "test-vm"},
+			expectedConnected:  true,
+			expectedHostname:   "test-vm",
+			expectedOS:         []interface{}{},
+			expectedInterfaces: []interface{}{},
+		},
+		{
+			name: "agent not connected",
+			status: kubevirtapiv1.VirtualMachineInstanceStatus{
+				Conditions: agentConnected(k8sv1.ConditionFalse),
+				Interfaces: []kubevirtapiv1.VirtualMachineInstanceNetworkInterface{
+					{Name: "default", IP: "10.0.0.5", IPs: []string{"10.0.0.5"}},
+				},
+			},
+			expectedOS:        []interface{}{},
+			expectedIPAddress: "10.0.0.5",
+			expectedInterfaces: []interface{}{
+				map[string]interface{}{
+					"name":           "default",
+					"interface_name": "",
+					"mac":            "",
+					"ip_address":     "10.0.0.5",
+					"ip_addresses":   []interface{}{"10.0.0.5"},
+				},
+			},
+		},
+		{
+			name: "interface without ips",
+			status: kubevirtapiv1.VirtualMachineInstanceStatus{
+				Conditions: agentConnected(k8sv1.ConditionTrue),
+				Interfaces: []kubevirtapiv1.VirtualMachineInstanceNetworkInterface{
+					{Name: "default", InterfaceName: "eth0", MAC: "02:00:00:00:00:01"},
+				},
+			},
+			guestInfo:         &kubevirtapiv1.VirtualMachineInstanceGuestAgentInfo{},
+			expectedConnected: true,
+			expectedOS:        []interface{}{},
+			expectedInterfaces: []interface{}{
+				map[string]interface{}{
+					"name":           "default",
+					"interface_name": "eth0",
+					"mac":            "02:00:00:00:00:01",
+					"ip_address":     "",
+					"ip_addresses":   []interface{}{},
+				},
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			vmi := kubevirtapiv1.VirtualMachineInstance{Status: tc.status}
+			assert.Equal(t, tc.expectedConnected, GuestAgentConnected(&vmi))
+
+			resourceData := schema.TestResourceDataRaw(t, GuestInfoFields(), map[string]interface{}{"name": "test-vm"})
+			assert.NilError(t, GuestInfoToResourceData(vmi, tc.guestInfo, nil, nil, resourceData))
+
+			assert.Equal(t, tc.expectedConnected, resourceData.Get("guest_agent_connected"))
+			assert.Equal(t, tc.expectedHostname, resourceData.Get("hostname"))
+			assert.DeepEqual(t, tc.expectedOS, resourceData.Get("os"))
+			assert.Equal(t, tc.expectedIPAddress, resourceData.Get("ip_address"))
+			assert.DeepEqual(t, tc.expectedInterfaces, resourceData.Get("interfaces"))
+			assert.DeepEqual(t, []interface{}{}, resourceData.Get("users"))
+		})
+	}
+}
+
+func TestExpandProbe(t *testing.T) {
+	cases := []struct {
+		name          string
+		input         []interface{}
+		expectedError string
+	}{
+		{
+			name: "no handler",
+			input: []interface{}{
+				map[string]interface{}{
+					"period_seconds": 10,
+				},
+			},
+			expectedError: "exactly one of http_get, tcp_socket, exec or guest_agent_ping must be set in a probe",
+		},
+		{
+			name: "two handlers",
+			input: []interface{}{
+				map[string]interface{}{
+					"tcp_socket": []interface{}{
+						map[string]interface{}{
+							"port": "22",
+						},
+					},
+					"guest_agent_ping": []interface{}{nil},
+				},
+			},
+			expectedError: "exactly one of http_get, tcp_socket, exec or guest_agent_ping must be set in a probe",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := expandProbe(tc.input)
+
+			assert.Error(t, err, tc.expectedError)
+		})
+	}
+}
+
+func TestFlattenProbeServerDefaults(t *testing.T) {
+	raw := map[string]interface{}{
+		"liveness_probe": []interface{}{
+			map[string]interface{}{
+				"initial_delay_seconds": 120,
+				"tcp_socket": []interface{}{
+					map[string]interface{}{
+						"port": "22",
+					},
+				},
+			},
+		},
+	}
+
+	probe, err := expandProbe(raw["liveness_probe"].([]interface{}))
+	assert.NilError(t, err)
+	assert.Equal(t, probe.PeriodSeconds, int32(0))
+
+	// The API server defaults what the configuration leaves out.
+	probe.PeriodSeconds = 10
+	probe.TimeoutSeconds = 1
+	probe.SuccessThreshold = 1
+	probe.FailureThreshold = 3
+
+	resource := &schema.Resource{
+		Schema: map[string]*schema.Schema{"liveness_probe": probeSchema()},
+	}
+	resourceData := resource.TestResourceData()
+	resourceData.SetId("test")
+	assert.NilError(t, resourceData.Set("liveness_probe", flattenProbe(*probe)))
+
+	diff, err := resource.Diff(context.Background(), resourceData.State(), terraform.NewResourceConfigRaw(raw), nil)
+
+	assert.NilError(t, err)
+	assert.Assert(t, diff.Empty(), "unexpected diff: %v", diff)
+}
+
+func TestToResourceDataServerDefaults(t *testing.T) {
+	vmiSpec := func(domain map[string]interface{}, spec map[string]interface{}) map[string]interface{} {
+		domain["resources"] = []interface{}{
+			map[string]interface{}{
+				"requests": map[string]interface{}{"memory": "1Gi"},
+			},
+		}
+		spec["domain"] = []interface{}{domain}
+		return map[string]interface{}{
+			"metadata": []interface{}{
+				map[string]interface{}{
+					"name":      "test-vmi",
+					"namespace": "default",
+				},
+			},
+			"spec": []interface{}{spec},
+		}
+	}
+	containerDisk := []interface{}{
+		map[string]interface{}{
+			"name": "rootdisk",
+			"volume_source": []interface{}{
+				map[string]interface{}{
+					"container_disk": []interface{}{
+						map[string]interface{}{"image": "quay.io/containerdisks/fedora:latest"},
+					},
+				},
+			},
+		},
+	}
+	rootDisk := map[string]interface{}{
+		"name": "rootdisk",
+		"disk_device": []interface{}{
+			map[string]interface{}{
+				"disk": []interface{}{
+					map[string]interface{}{"bus": "virtio"},
+				},
+			},
+		},
+	}
+
+	cases := []struct {
+		name string
+		raw  map[string]interface{}
+	}{
+		{
+			name: "minimal",
+			raw: vmiSpec(
+				map[string]interface{}{
+					"devices": []interface{}{
+						map[string]interface{}{
+							"disk": []interface{}{rootDisk},
+						},
+					},
+				},
+				map[string]interface{}{
+					"volume": containerDisk,
+				},
+			),
+		},
+		{
+			name: "defaulted nested fields",
+			raw: vmiSpec(
+				map[string]interface{}{
+					"features": []interface{}{
+						map[string]interface{}{
+							"hyperv": []interface{}{
+								map[string]interface{}{
+									"relaxed":   []interface{}{map[string]interface{}{}},
+									"spinlocks": []interface{}{map[string]interface{}{}},
+								},
+							},
+						},
+					},
+					"clock": []interface{}{
+						map[string]interface{}{
+							"timer": []interface{}{
+								map[string]interface{}{
+									"rtc": []interface{}{
+										map[string]interface{}{"tick_policy": "catchup"},
+									},
+								},
+							},
+						},
+					},
+					"devices": []interface{}{
+						map[string]interface{}{
+							"disk": []interface{}{
+								rootDisk,
+								map[string]interface{}{
+									"name": "installer",
+									"disk_device": []interface{}{
+										map[string]interface{}{
+											"cdrom": []interface{}{
+												map[string]interface{}{"bus": "sata"},
+											},
+										},
+									},
+								},
+							},
+							"interface": []interface{}{
+								map[string]interface{}{
+									"name":                     "default",
+									"interface_binding_method": "InterfaceMasquerade",
+								},
+							},
+						},
+					},
+				},
+				map[string]interface{}{
+					"volume": append(containerDisk, map[string]interface{}{
+						"name": "installer",
+						"volume_source": []interface{}{
+							map[string]interface{}{
+								"container_disk": []interface{}{
+									map[string]interface{}{"image": "quay.io/containerdisks/installer:1.0"},
+								},
+							},
+						},
+					}),
+					"network": []interface{}{
+						map[string]interface{}{
+							"name": "default",
+							"network_source": []interface{}{
+								map[string]interface{}{
+									"pod": []interface{}{map[string]interface{}{}},
+								},
+							},
+						},
+					},
+					"readiness_probe": []interface{}{
+						map[string]interface{}{
+							"guest_agent_ping": []interface{}{map[string]interface{}{}},
+						},
+					},
+				},
+			),
+		},
+		{
+			name: "configured defaults",
+			raw: vmiSpec(
+				map[string]interface{}{
+					"features": []interface{}{
+						map[string]interface{}{
+							"acpi": []interface{}{map[string]interface{}{"enabled": true}},
+							"hyperv": []interface{}{
+								map[string]interface{}{
+									"spinlocks": []interface{}{map[string]interface{}{"retries": 4096}},
+								},
+							},
+						},
+					},
+					"devices": []interface{}{
+						map[string]interface{}{
+							"disk": []interface{}{
+								rootDisk,
+								map[string]interface{}{
+									"name": "installer",
+									"disk_device": []interface{}{
+										map[string]interface{}{
+											"cdrom": []interface{}{
+												map[string]interface{}{"bus": "sata", "tray": "closed"},
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+				map[string]interface{}{
+					"volume": []interface{}{
+						containerDisk[0],
+						map[string]interface{}{
+							"name": "installer",
+							"volume_source": []interface{}{
+								map[string]interface{}{
+									"container_disk": []interface{}{
+										map[string]interface{}{
+											"image":             "quay.io/containerdisks/installer:1.0",
+											"image_pull_policy": "IfNotPresent",
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			),
+		},
+	}
+
+	resource := &schema.Resource{Schema: VirtualMachineInstanceFields()}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			config := terraform.NewResourceConfigRaw(tc.raw)
+
+			diff, err := resource.Diff(context.Background(), nil, config, nil)
+			assert.NilError(t, err)
+			planned, err := schema.InternalMap(resource.Schema).Data(nil, diff)
+			assert.NilError(t, err)
+			configured, err := FromResourceData(planned)
+			assert.NilError(t, err)
+			vmi, err := FromResourceData(planned)
+			assert.NilError(t, err)
+
+			// Default the instance the way the KubeVirt API server does.
+			kubevirtapiv1.SetObjectDefaults_VirtualMachineInstance(vmi)
+			kubevirtapiv1.SetDefaults_NetworkInterface(vmi)
+			for i, volume := range vmi.Spec.Volumes {
+				if volume.ContainerDisk != nil && volume.ContainerDisk.ImagePullPolicy == "" {
+					vmi.Spec.Volumes[i].ContainerDisk.ImagePullPolicy = k8sv1.PullIfNotPresent
+				}
+			}
+
+			OmitServerDefaults(&vmi.Spec, configured.Spec)
+			resourceData := resource.TestResourceData()
+			resourceData.SetId("default/test-vmi")
+			assert.NilError(t, ToResourceData(*vmi, resourceData))
+
+			diff, err = resource.Diff(context.Background(), resourceData.State(), config, nil)
+
+			assert.NilError(t, err)
+			assert.Assert(t, diff.Empty(), "unexpected diff: %s", diffAttributes(diff))
+		})
+	}
+}
+
+func TestSpecRemovedDefaults(t *testing.T) {
+	rawVMI := func(spec map[string]interface{}) map[string]interface{} {
+		spec["domain"] = []interface{}{
+			map[string]interface{}{
+				"resources": []interface{}{
+					map[string]interface{}{
+						"requests": map[string]interface{}{"memory": "1Gi"},
+					},
+				},
+			},
+		}
+		return map[string]interface{}{
+			"metadata": []interface{}{
+				map[string]interface{}{
+					"name":      "test-vmi",
+					"namespace": "default",
+				},
+			},
+			"spec": []interface{}{spec},
+		}
+	}
+
+	// The spec schema is shared with the templates of virtual machines, where removing a block
+	// from the configuration has to remove it from the object too.
+	resource := &schema.Resource{Schema: VirtualMachineInstanceFields()}
+	resourceData := schema.TestResourceDataRaw(t, resource.Schema, rawVMI(map[string]interface{}{
+		"network": []interface{}{
+			map[string]interface{}{
+				"name": "default",
+				"network_source": []interface{}{
+					map[string]interface{}{
+						"pod": []interface{}{map[string]interface{}{}},
+					},
+				},
+			},
+		},
+	}))
+	resourceData.SetId("default/test-vmi")
+
+	diff, err := resource.Diff(context.Background(), resourceData.State(), terraform.NewResourceConfigRaw(rawVMI(map[string]interface{}{})), nil)
+
+	assert.NilError(t, err)
+	assert.Assert(t, diff != nil && diff.Attributes["spec.0.network.#"] != nil, "unexpected diff: %s", diffAttributes(diff))
+	assert.Equal(t, diff.Attributes["spec.0.network.#"].New, "0")
+}
+
+func TestForceNewOnSpecChange(t *testing.T) {
+	rawVMI := func(labels map[string]interface{}, memory string, cores int) map[string]interface{} {
+		return map[string]interface{}{
+			"metadata": []interface{}{
+				map[string]interface{}{
+					"name":      "test-vmi",
+					"namespace": "default",
+					"labels":    labels,
+				},
+			},
+			"spec": []interface{}{
+				map[string]interface{}{
+					"domain": []interface{}{
+						map[string]interface{}{
+							"resources": []interface{}{
+								map[string]interface{}{
+									"requests": map[string]interface{}{"memory": memory},
+								},
+							},
+							"cpu": []interface{}{
+								map[string]interface{}{"cores": cores},
+							},
+						},
+					},
+				},
+			},
+		}
+	}
+
+	cases := []struct {
+		name        string
+		raw         map[string]interface{}
+		requiresNew bool
+	}{
+		{
+			name:        "nested spec field",
+			raw:         rawVMI(map[string]interface{}{"app": "test"}, "1Gi", 2),
+			requiresNew: true,
+		},
+		{
+			name:        "nested spec map value",
+			raw:         rawVMI(map[string]interface{}{"app": "test"}, "2Gi", 1),
+			requiresNew: true,
+		},
+		{
+			name:        "metadata only",
+			raw:         rawVMI(map[string]interface{}{"app": "other"}, "1Gi", 1),
+			requiresNew: false,
+		},
+	}
+
+	resource := &schema.Resource{
+		Schema:        VirtualMachineInstanceFields(),
+		CustomizeDiff: ForceNewOnSpecChange,
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			resourceData := schema.TestResourceDataRaw(t, resource.Schema, rawVMI(map[string]interface{}{"app": "test"}, "1Gi", 1))
+			resourceData.SetId("default/test-vmi")
+
+			diff, err := resource.Diff(context.Background(), resourceData.State(), terraform.NewResourceConfigRaw(tc.raw), nil)
+
+			assert.NilError(t, err)
+			assert.Assert(t, !diff.Empty(), "expected a diff")
+			assert.Equal(t, diff.RequiresNew(), tc.requiresNew, "diff: %s", diffAttributes(diff))
+		})
+	}
+}
+
+// diffAttributes lists the attributes changed by a diff.
+func diffAttributes(diff *terraform.InstanceDiff) []string {
+	if diff == nil {
+		return nil
+	}
+	result := []string{}
+	for k, v := range diff.Attributes {
+		result = append(result, fmt.Sprintf("%s: %q => %q", k, v.Old, v.New))
+	}
+	sort.Strings(result)
+	return result
+}
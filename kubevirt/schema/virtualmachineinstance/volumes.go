@@ -1,13 +1,21 @@
 package virtualmachineinstance
 
 import (
+	"encoding/base64"
 	"fmt"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
 	"github.com/nrp-nautilus/terraform-provider-kubevirt/kubevirt/schema/k8s"
+	"github.com/nrp-nautilus/terraform-provider-kubevirt/kubevirt/utils"
+	k8sv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	kubevirtapiv1 "kubevirt.io/api/core/v1"
 )
 
+// cloudInitDataMaxLen is the largest inline cloud-init user or network data KubeVirt accepts.
+const cloudInitDataMaxLen = 2048
+
 func volumesFields() map[string]*schema.Schema {
 	return map[string]*schema.Schema{
 		"name": {
@@ -34,63 +42,366 @@ func volumesFields() map[string]*schema.Schema {
 									Description: "Name represents the name of the DataVolume in the same namespace.",
 									Required:    true,
 								},
+								"hotpluggable": {
+									Type:        schema.TypeBool,
+									Description: "Hotpluggable indicates whether the volume can be hotplugged and hotunplugged.",
+									Optional:    true,
+								},
+							},
+						},
+					},
+					"cloud_init_config_drive": cloudInitSourceSchema("CloudInitConfigDrive represents a cloud-init Config Drive user-data source.", "config drive"),
+					"cloud_init_no_cloud":     cloudInitSourceSchema("CloudInitNoCloud represents a cloud-init NoCloud user-data source.", "NoCloud"),
+					"sysprep": {
+						Type:        schema.TypeList,
+						Description: "Sysprep represents a Sysprep volume source. Exactly one of secret or config_map must be set.",
+						MaxItems:    1,
+						Optional:    true,
+						Elem: &schema.Resource{
+							Schema: map[string]*schema.Schema{
+								"secret":     k8s.LocalObjectReferenceSchema("Secret references a k8s Secret that contains Sysprep answer file named autounattend.xml that should be attached as disk of CDROM type."),
+								"config_map": k8s.LocalObjectReferenceSchema("ConfigMap references a ConfigMap that contains Sysprep answer file named autounattend.xml that should be attached as disk of CDROM type."),
+							},
+						},
+					},
+					"service_account": {
+						Type:        schema.TypeList,
+						Description: "ServiceAccountVolumeSource represents a reference to a service account.",
+						MaxItems:    1,
+						Optional:    true,
+						Elem: &schema.Resource{
+							Schema: map[string]*schema.Schema{
+								"service_account_name": {
+									Type:        schema.TypeString,
+									Description: "Name of the service account in the pod's namespace to use.",
+									Required:    true,
+								},
 							},
 						},
 					},
-					"cloud_init_config_drive": {
+					"container_disk": {
 						Type:        schema.TypeList,
-						Description: "CloudInitConfigDrive represents a cloud-init Config Drive user-data source.",
+						Description: "ContainerDisk references a docker image, embedding a qcow or raw disk. More info: https://kubevirt.gitbooks.io/user-guide/registry-disk.html",
 						MaxItems:    1,
 						Optional:    true,
 						Elem: &schema.Resource{
 							Schema: map[string]*schema.Schema{
-								"user_data_secret_ref": k8s.LocalObjectReferenceSchema("UserDataSecretRef references a k8s secret that contains config drive userdata."),
-								"user_data_base64": {
+								"image": {
 									Type:        schema.TypeString,
-									Description: "UserDataBase64 contains config drive cloud-init userdata as a base64 encoded string.",
+									Description: "Image is the name of the image with the embedded disk.",
+									Required:    true,
+								},
+								"image_pull_secret": {
+									Type:        schema.TypeString,
+									Description: "ImagePullSecret is the name of the Docker registry secret required to pull the image. The secret must already exist.",
 									Optional:    true,
 								},
-								"user_data": {
+								"path": {
 									Type:        schema.TypeString,
-									Description: "UserData contains config drive inline cloud-init userdata.",
+									Description: "Path defines the path to disk file in the container.",
 									Optional:    true,
 								},
-								"network_data_secret_ref": k8s.LocalObjectReferenceSchema("NetworkDataSecretRef references a k8s secret that contains config drive networkdata."),
-								"network_data_base64": {
+								"image_pull_policy": {
 									Type:        schema.TypeString,
-									Description: "NetworkDataBase64 contains config drive cloud-init networkdata as a base64 encoded string.",
+									Description: "Image pull policy. One of Always, Never, IfNotPresent. Defaults to Always if :latest tag is specified, or IfNotPresent otherwise.",
 									Optional:    true,
+									ValidateFunc: validation.StringInSlice([]string{
+										string(k8sv1.PullAlways),
+										string(k8sv1.PullNever),
+										string(k8sv1.PullIfNotPresent),
+									}, false),
 								},
-								"network_data": {
+							},
+						},
+					},
+					"persistent_volume_claim": persistentVolumeClaimVolumeSourceSchema("PersistentVolumeClaimVolumeSource represents a reference to a PersistentVolumeClaim in the same namespace. Directly attached to the vmi via qemu.", true),
+					"ephemeral": {
+						Type:        schema.TypeList,
+						Description: "Ephemeral is a special volume source that \"wraps\" specified source and provides copy-on-write image on top of it.",
+						MaxItems:    1,
+						Optional:    true,
+						Elem: &schema.Resource{
+							Schema: map[string]*schema.Schema{
+								"persistent_volume_claim": persistentVolumeClaimVolumeSourceSchema("PersistentVolumeClaimVolumeSource represents a reference to a PersistentVolumeClaim in the same namespace.", false),
+							},
+						},
+					},
+					"empty_disk": {
+						Type:        schema.TypeList,
+						Description: "EmptyDisk represents a temporary disk which shares the vmis lifecycle.",
+						MaxItems:    1,
+						Optional:    true,
+						Elem: &schema.Resource{
+							Schema: map[string]*schema.Schema{
+								"capacity": {
+									Type:         schema.TypeString,
+									Description:  "Capacity of the sparse disk.",
+									Required:     true,
+									ValidateFunc: utils.ValidateResourceQuantity,
+								},
+							},
+						},
+					},
+					"host_disk": {
+						Type:        schema.TypeList,
+						Description: "HostDisk represents a disk created on the cluster level.",
+						MaxItems:    1,
+						Optional:    true,
+						Elem: &schema.Resource{
+							Schema: map[string]*schema.Schema{
+								"path": {
 									Type:        schema.TypeString,
-									Description: "NetworkData contains config drive inline cloud-init networkdata.",
+									Description: "The path to HostDisk image located on the cluster.",
+									Required:    true,
+								},
+								"type": {
+									Type:        schema.TypeString,
+									Description: "Contains information if disk.img exists or should be created. Allowed options are 'Disk' and 'DiskOrCreate'.",
+									Required:    true,
+									ValidateFunc: validation.StringInSlice([]string{
+										string(kubevirtapiv1.HostDiskExists),
+										string(kubevirtapiv1.HostDiskExistsOrCreate),
+									}, false),
+								},
+								"capacity": {
+									Type:         schema.TypeString,
+									Description:  "Capacity of the sparse disk.",
+									Optional:     true,
+									ValidateFunc: utils.ValidateResourceQuantity,
+								},
+								"shared": {
+									Type:        schema.TypeBool,
+									Description: "Shared indicate whether the path is shared between nodes.",
 									Optional:    true,
 								},
 							},
 						},
 					},
-					"service_account": {
+					"config_map": {
 						Type:        schema.TypeList,
-						Description: "ServiceAccountVolumeSource represents a reference to a service account.",
+						Description: "ConfigMapSource represents a reference to a ConfigMap in the same namespace.",
 						MaxItems:    1,
 						Optional:    true,
 						Elem: &schema.Resource{
 							Schema: map[string]*schema.Schema{
-								"service_account_name": {
+								"name": {
 									Type:        schema.TypeString,
-									Description: "Name of the service account in the pod's namespace to use.",
+									Description: "Name of the ConfigMap.",
 									Required:    true,
 								},
+								"optional": {
+									Type:        schema.TypeBool,
+									Description: "Specify whether the ConfigMap or it's keys must be defined.",
+									Optional:    true,
+								},
+								"volume_label": {
+									Type:        schema.TypeString,
+									Description: "The volume label of the resulting disk inside the VMI. Different bootstrapping mechanisms require different values. Typical values are \"cidata\" (cloud-init), \"config-2\" (cloud-init) or \"OEMDRV\" (kickstart).",
+									Optional:    true,
+								},
+							},
+						},
+					},
+					"secret": {
+						Type:        schema.TypeList,
+						Description: "SecretVolumeSource represents a reference to a secret data in the same namespace.",
+						MaxItems:    1,
+						Optional:    true,
+						Elem: &schema.Resource{
+							Schema: map[string]*schema.Schema{
+								"secret_name": {
+									Type:        schema.TypeString,
+									Description: "Name of the secret in the pod's namespace to use.",
+									Required:    true,
+								},
+								"optional": {
+									Type:        schema.TypeBool,
+									Description: "Specify whether the Secret or it's keys must be defined.",
+									Optional:    true,
+								},
+								"volume_label": {
+									Type:        schema.TypeString,
+									Description: "The volume label of the resulting disk inside the VMI. Different bootstrapping mechanisms require different values. Typical values are \"cidata\" (cloud-init), \"config-2\" (cloud-init) or \"OEMDRV\" (kickstart).",
+									Optional:    true,
+								},
 							},
 						},
 					},
-					// TODO nargaman - Add other data volume source types
+					"downward_api": {
+						Type:        schema.TypeList,
+						Description: "DownwardAPI represents downward API about the pod that should populate this volume.",
+						MaxItems:    1,
+						Optional:    true,
+						Elem: &schema.Resource{
+							Schema: map[string]*schema.Schema{
+								"field": {
+									Type:        schema.TypeList,
+									Description: "Fields is a list of downward API volume file.",
+									Optional:    true,
+									Elem: &schema.Resource{
+										Schema: downwardAPIVolumeFileFields(),
+									},
+								},
+								"volume_label": {
+									Type:        schema.TypeString,
+									Description: "The volume label of the resulting disk inside the VMI. Different bootstrapping mechanisms require different values. Typical values are \"cidata\" (cloud-init), \"config-2\" (cloud-init) or \"OEMDRV\" (kickstart).",
+									Optional:    true,
+								},
+							},
+						},
+					},
+					"downward_metrics": {
+						Type:        schema.TypeList,
+						Description: "DownwardMetrics adds a very small disk to VMIs which contains a limited view of host and guest metrics. The disk content is compatible with vhostmd and vm-dump-metrics.",
+						MaxItems:    1,
+						Optional:    true,
+						Elem: &schema.Resource{
+							Schema: map[string]*schema.Schema{},
+						},
+					},
+					"memory_dump": persistentVolumeClaimVolumeSourceSchema("MemoryDump is attached to the virt launcher and is populated with a memory dump of the vmi.", true),
 				},
 			},
 		},
 	}
 }
 
+// cloudInitSourceSchema returns the schema shared by the config drive and NoCloud sources,
+// which only differ in how the data is presented to the guest.
+func cloudInitSourceSchema(description string, source string) *schema.Schema {
+	return &schema.Schema{
+		Type:        schema.TypeList,
+		Description: description,
+		MaxItems:    1,
+		Optional:    true,
+		Elem: &schema.Resource{
+			Schema: map[string]*schema.Schema{
+				"user_data_secret_ref": k8s.LocalObjectReferenceSchema(fmt.Sprintf("UserDataSecretRef references a k8s secret that contains %s userdata.", source)),
+				"user_data_base64": {
+					Type:        schema.TypeString,
+					Description: fmt.Sprintf("UserDataBase64 contains %s cloud-init userdata as a base64 encoded string. Limited to %d bytes once decoded, use user_data_secret_ref for larger userdata.", source, cloudInitDataMaxLen),
+					Optional:    true,
+				},
+				"user_data": {
+					Type:        schema.TypeString,
+					Description: fmt.Sprintf("UserData contains %s inline cloud-init userdata. Limited to %d bytes, use store_as_secret or user_data_secret_ref for larger userdata.", source, cloudInitDataMaxLen),
+					Optional:    true,
+				},
+				"network_data_secret_ref": k8s.LocalObjectReferenceSchema(fmt.Sprintf("NetworkDataSecretRef references a k8s secret that contains %s networkdata.", source)),
+				"network_data_base64": {
+					Type:        schema.TypeString,
+					Description: fmt.Sprintf("NetworkDataBase64 contains %s cloud-init networkdata as a base64 encoded string. Limited to %d bytes once decoded, use network_data_secret_ref for larger networkdata.", source, cloudInitDataMaxLen),
+					Optional:    true,
+				},
+				"network_data": {
+					Type:        schema.TypeString,
+					Description: fmt.Sprintf("NetworkData contains %s inline cloud-init networkdata. Limited to %d bytes, use store_as_secret or network_data_secret_ref for larger networkdata.", source, cloudInitDataMaxLen),
+					Optional:    true,
+				},
+				"store_as_secret": {
+					Type:        schema.TypeBool,
+					Description: "Store user_data and network_data in a Secret owned by the virtual machine and reference it, instead of embedding them in the virtual machine. Lifts the size limit of the inline data.",
+					Optional:    true,
+					Default:     false,
+				},
+			},
+		},
+	}
+}
+
+func persistentVolumeClaimVolumeSourceSchema(description string, hotpluggable bool) *schema.Schema {
+	fields := map[string]*schema.Schema{
+		"claim_name": {
+			Type:        schema.TypeString,
+			Description: "ClaimName is the name of a PersistentVolumeClaim in the same namespace as the pod using this volume.",
+			Required:    true,
+		},
+		"read_only": {
+			Type:        schema.TypeBool,
+			Description: "Will force the ReadOnly setting in VolumeMounts. Defaults to false.",
+			Optional:    true,
+		},
+	}
+	if hotpluggable {
+		fields["hotpluggable"] = &schema.Schema{
+			Type:        schema.TypeBool,
+			Description: "Hotpluggable indicates whether the volume can be hotplugged and hotunplugged.",
+			Optional:    true,
+		}
+	}
+
+	return &schema.Schema{
+		Type:        schema.TypeList,
+		Description: description,
+		MaxItems:    1,
+		Optional:    true,
+		Elem: &schema.Resource{
+			Schema: fields,
+		},
+	}
+}
+
+func downwardAPIVolumeFileFields() map[string]*schema.Schema {
+	return map[string]*schema.Schema{
+		"path": {
+			Type:        schema.TypeString,
+			Description: "Path is the relative path name of the file to be created. Must not be absolute or contain the '..' path.",
+			Required:    true,
+		},
+		"field_ref": {
+			Type:        schema.TypeList,
+			Description: "Selects a field of the pod: only annotations, labels, name and namespace are supported.",
+			MaxItems:    1,
+			Optional:    true,
+			Elem: &schema.Resource{
+				Schema: map[string]*schema.Schema{
+					"api_version": {
+						Type:        schema.TypeString,
+						Description: "Version of the schema the FieldPath is written in terms of, defaults to \"v1\".",
+						Optional:    true,
+					},
+					"field_path": {
+						Type:        schema.TypeString,
+						Description: "Path of the field to select in the specified API version.",
+						Required:    true,
+					},
+				},
+			},
+		},
+		"resource_field_ref": {
+			Type:        schema.TypeList,
+			Description: "Selects a resource of the container: only resources limits and requests (limits.cpu, limits.memory, requests.cpu and requests.memory) are currently supported.",
+			MaxItems:    1,
+			Optional:    true,
+			Elem: &schema.Resource{
+				Schema: map[string]*schema.Schema{
+					"container_name": {
+						Type:        schema.TypeString,
+						Description: "Container name: required for volumes, optional for env vars.",
+						Optional:    true,
+					},
+					"resource": {
+						Type:        schema.TypeString,
+						Description: "Required: resource to select.",
+						Required:    true,
+					},
+					"divisor": {
+						Type:         schema.TypeString,
+						Description:  "Specifies the output format of the exposed resources, defaults to \"1\".",
+						Optional:     true,
+						ValidateFunc: utils.ValidateResourceQuantity,
+					},
+				},
+			},
+		},
+		"mode": {
+			Type:        schema.TypeInt,
+			Description: "Mode bits used to set permissions on this file, must be an octal value between 0000 and 0777 or a decimal value between 0 and 511.",
+			Optional:    true,
+		},
+	}
+}
+
 func volumesSchema() *schema.Schema {
 	fields := volumesFields()
 
@@ -106,11 +417,11 @@ func volumesSchema() *schema.Schema {
 
 }
 
-func expandVolumes(volumes []interface{}) []kubevirtapiv1.Volume {
+func expandVolumes(volumes []interface{}) ([]kubevirtapiv1.Volume, error) {
 	result := make([]kubevirtapiv1.Volume, len(volumes))
 
 	if len(volumes) == 0 || volumes[0] == nil {
-		return result
+		return result, nil
 	}
 
 	for i, condition := range volumes {
@@ -120,18 +431,22 @@ func expandVolumes(volumes []interface{}) []kubevirtapiv1.Volume {
 			result[i].Name = v
 		}
 		if v, ok := in["volume_source"].([]interface{}); ok {
-			result[i].VolumeSource = expandVolumeSource(v)
+			volumeSource, err := expandVolumeSource(v)
+			if err != nil {
+				return result, fmt.Errorf("volume %q: %w", result[i].Name, err)
+			}
+			result[i].VolumeSource = volumeSource
 		}
 	}
 
-	return result
+	return result, nil
 }
 
-func expandVolumeSource(volumeSource []interface{}) kubevirtapiv1.VolumeSource {
+func expandVolumeSource(volumeSource []interface{}) (kubevirtapiv1.VolumeSource, error) {
 	result := kubevirtapiv1.VolumeSource{}
 
 	if len(volumeSource) == 0 || volumeSource[0] == nil {
-		return result
+		return result, nil
 	}
 
 	in := volumeSource[0].(map[string]interface{})
@@ -140,13 +455,77 @@ func expandVolumeSource(volumeSource []interface{}) kubevirtapiv1.VolumeSource {
 		result.DataVolume = expandDataVolume(v)
 	}
 	if v, ok := in["cloud_init_config_drive"].([]interface{}); ok {
-		result.CloudInitConfigDrive = expandCloudInitConfigDrive(v)
+		cloudInitConfigDrive, err := expandCloudInitConfigDrive(v)
+		if err != nil {
+			return result, err
+		}
+		result.CloudInitConfigDrive = cloudInitConfigDrive
+	}
+	if v, ok := in["cloud_init_no_cloud"].([]interface{}); ok {
+		cloudInitNoCloud, err := expandCloudInitNoCloud(v)
+		if err != nil {
+			return result, err
+		}
+		result.CloudInitNoCloud = cloudInitNoCloud
+	}
+	if v, ok := in["sysprep"].([]interface{}); ok {
+		sysprep, err := expandSysprep(v)
+		if err != nil {
+			return result, err
+		}
+		result.Sysprep = sysprep
 	}
 	if v, ok := in["service_account"].([]interface{}); ok {
 		result.ServiceAccount = expandServiceAccount(v)
 	}
+	if v, ok := in["container_disk"].([]interface{}); ok {
+		result.ContainerDisk = expandContainerDisk(v)
+	}
+	if v, ok := in["persistent_volume_claim"].([]interface{}); ok {
+		result.PersistentVolumeClaim = expandPersistentVolumeClaimVolumeSource(v)
+	}
+	if v, ok := in["ephemeral"].([]interface{}); ok {
+		result.Ephemeral = expandEphemeral(v)
+	}
+	if v, ok := in["empty_disk"].([]interface{}); ok {
+		emptyDisk, err := expandEmptyDisk(v)
+		if err != nil {
+			return result, err
+		}
+		result.EmptyDisk = emptyDisk
+	}
+	if v, ok := in["host_disk"].([]interface{}); ok {
+		hostDisk, err := expandHostDisk(v)
+		if err != nil {
+			return result, err
+		}
+		result.HostDisk = hostDisk
+	}
+	if v, ok := in["config_map"].([]interface{}); ok {
+		result.ConfigMap = expandConfigMap(v)
+	}
+	if v, ok := in["secret"].([]interface{}); ok {
+		result.Secret = expandSecret(v)
+	}
+	if v, ok := in["downward_api"].([]interface{}); ok {
+		downwardAPI, err := expandDownwardAPI(v)
+		if err != nil {
+			return result, err
+		}
+		result.DownwardAPI = downwardAPI
+	}
+	if v, ok := in["downward_metrics"].([]interface{}); ok && len(v) > 0 {
+		result.DownwardMetrics = &kubevirtapiv1.DownwardMetricsVolumeSource{}
+	}
+	if v, ok := in["memory_dump"].([]interface{}); ok {
+		if pvc := expandPersistentVolumeClaimVolumeSource(v); pvc != nil {
+			result.MemoryDump = &kubevirtapiv1.MemoryDumpVolumeSource{
+				PersistentVolumeClaimVolumeSource: *pvc,
+			}
+		}
+	}
 
-	return result
+	return result, nil
 }
 
 func expandDataVolume(dataVolumeSource []interface{}) *kubevirtapiv1.DataVolumeSource {
@@ -160,13 +539,16 @@ func expandDataVolume(dataVolumeSource []interface{}) *kubevirtapiv1.DataVolumeS
 	if v, ok := in["name"].(string); ok {
 		result.Name = v
 	}
+	if v, ok := in["hotpluggable"].(bool); ok {
+		result.Hotpluggable = v
+	}
 
 	return result
 }
 
-func expandCloudInitConfigDrive(cloudInitConfigDriveSource []interface{}) *kubevirtapiv1.CloudInitConfigDriveSource {
+func expandCloudInitConfigDrive(cloudInitConfigDriveSource []interface{}) (*kubevirtapiv1.CloudInitConfigDriveSource, error) {
 	if len(cloudInitConfigDriveSource) == 0 || cloudInitConfigDriveSource[0] == nil {
-		return nil
+		return nil, nil
 	}
 
 	result := &kubevirtapiv1.CloudInitConfigDriveSource{}
@@ -190,8 +572,161 @@ func expandCloudInitConfigDrive(cloudInitConfigDriveSource []interface{}) *kubev
 	if v, ok := in["network_data"].(string); ok {
 		result.NetworkData = v
 	}
+	if v, ok := in["store_as_secret"].(bool); ok && v {
+		if err := validateCloudInitSecretData(result.UserData, result.UserDataBase64, result.NetworkDataBase64, result.UserDataSecretRef, result.NetworkDataSecretRef); err != nil {
+			return result, fmt.Errorf("cloud_init_config_drive: %w", err)
+		}
+	}
+
+	return result, nil
+}
+
+func expandCloudInitNoCloud(cloudInitNoCloudSource []interface{}) (*kubevirtapiv1.CloudInitNoCloudSource, error) {
+	if len(cloudInitNoCloudSource) == 0 || cloudInitNoCloudSource[0] == nil {
+		return nil, nil
+	}
+
+	result := &kubevirtapiv1.CloudInitNoCloudSource{}
+	in := cloudInitNoCloudSource[0].(map[string]interface{})
+
+	if v, ok := in["user_data_secret_ref"].([]interface{}); ok {
+		result.UserDataSecretRef = k8s.ExpandLocalObjectReferences(v)
+	}
+	if v, ok := in["user_data_base64"].(string); ok {
+		result.UserDataBase64 = v
+	}
+	if v, ok := in["user_data"].(string); ok {
+		result.UserData = v
+	}
+	if v, ok := in["network_data_secret_ref"].([]interface{}); ok {
+		result.NetworkDataSecretRef = k8s.ExpandLocalObjectReferences(v)
+	}
+	if v, ok := in["network_data_base64"].(string); ok {
+		result.NetworkDataBase64 = v
+	}
+	if v, ok := in["network_data"].(string); ok {
+		result.NetworkData = v
+	}
+	if v, ok := in["store_as_secret"].(bool); ok && v {
+		if err := validateCloudInitSecretData(result.UserData, result.UserDataBase64, result.NetworkDataBase64, result.UserDataSecretRef, result.NetworkDataSecretRef); err != nil {
+			return result, fmt.Errorf("cloud_init_no_cloud: %w", err)
+		}
+	}
 
-	return result
+	return result, nil
+}
+
+// validateCloudInitVolumes enforces the size KubeVirt accepts for the inline cloud-init data
+// of the volumes, except for the data stored as a secret.
+func validateCloudInitVolumes(volumes []interface{}) error {
+	for _, v := range volumes {
+		volume, ok := v.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		for key, in := range rawCloudInitSources(volume) {
+			if storeAsSecret, _ := in["store_as_secret"].(bool); storeAsSecret {
+				continue
+			}
+			userData, _ := in["user_data"].(string)
+			userDataBase64, _ := in["user_data_base64"].(string)
+			networkData, _ := in["network_data"].(string)
+			networkDataBase64, _ := in["network_data_base64"].(string)
+			if err := validateCloudInitData(userData, userDataBase64, networkData, networkDataBase64); err != nil {
+				return fmt.Errorf("volume %q: %s: %w", volume["name"], key, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// rawCloudInitSources returns the cloud-init blocks of a volume block by key.
+func rawCloudInitSources(volume map[string]interface{}) map[string]map[string]interface{} {
+	sources := make(map[string]map[string]interface{})
+
+	volumeSource, ok := volume["volume_source"].([]interface{})
+	if !ok || len(volumeSource) == 0 || volumeSource[0] == nil {
+		return sources
+	}
+	for _, key := range []string{"cloud_init_config_drive", "cloud_init_no_cloud"} {
+		cloudInit, ok := volumeSource[0].(map[string]interface{})[key].([]interface{})
+		if !ok || len(cloudInit) == 0 || cloudInit[0] == nil {
+			continue
+		}
+		sources[key] = cloudInit[0].(map[string]interface{})
+	}
+
+	return sources
+}
+
+// validateCloudInitData enforces the size KubeVirt accepts for inline cloud-init data,
+// so that oversized user data fails before the VM is sent to the cluster.
+func validateCloudInitData(userData, userDataBase64, networkData, networkDataBase64 string) error {
+	userDataLen, err := cloudInitDataLen(userData, userDataBase64)
+	if err != nil {
+		return fmt.Errorf("user_data_base64: %w", err)
+	}
+	if userDataLen > cloudInitDataMaxLen {
+		return fmt.Errorf("inline user data exceeds %d bytes, set store_as_secret or store it in a Secret and reference it with user_data_secret_ref", cloudInitDataMaxLen)
+	}
+	networkDataLen, err := cloudInitDataLen(networkData, networkDataBase64)
+	if err != nil {
+		return fmt.Errorf("network_data_base64: %w", err)
+	}
+	if networkDataLen > cloudInitDataMaxLen {
+		return fmt.Errorf("inline network data exceeds %d bytes, set store_as_secret or store it in a Secret and reference it with network_data_secret_ref", cloudInitDataMaxLen)
+	}
+
+	return nil
+}
+
+// cloudInitDataLen returns the size of the plain or base64 encoded cloud-init data, whichever is set.
+func cloudInitDataLen(data, dataBase64 string) (int, error) {
+	if dataBase64 == "" {
+		return len(data), nil
+	}
+	decoded, err := base64.StdEncoding.DecodeString(dataBase64)
+	if err != nil {
+		return 0, err
+	}
+	return len(decoded), nil
+}
+
+// validateCloudInitSecretData checks that a source stored as a secret only carries
+// the plain user and network data the provider moves into the Secret.
+func validateCloudInitSecretData(userData, userDataBase64, networkDataBase64 string, userDataSecretRef, networkDataSecretRef *k8sv1.LocalObjectReference) error {
+	if userDataBase64 != "" || networkDataBase64 != "" || userDataSecretRef != nil || networkDataSecretRef != nil {
+		return fmt.Errorf("store_as_secret only supports user_data and network_data")
+	}
+	if userData == "" {
+		return fmt.Errorf("store_as_secret requires user_data")
+	}
+
+	return nil
+}
+
+func expandSysprep(sysprep []interface{}) (*kubevirtapiv1.SysprepSource, error) {
+	if len(sysprep) == 0 {
+		return nil, nil
+	}
+
+	result := &kubevirtapiv1.SysprepSource{}
+
+	if in, ok := sysprep[0].(map[string]interface{}); ok {
+		if v, ok := in["secret"].([]interface{}); ok {
+			result.Secret = k8s.ExpandLocalObjectReferences(v)
+		}
+		if v, ok := in["config_map"].([]interface{}); ok {
+			result.ConfigMap = k8s.ExpandLocalObjectReferences(v)
+		}
+	}
+
+	if (result.Secret == nil) == (result.ConfigMap == nil) {
+		return result, fmt.Errorf("exactly one of secret or config_map must be set in sysprep")
+	}
+
+	return result, nil
 }
 
 func expandServiceAccount(serviceAccountSource []interface{}) *kubevirtapiv1.ServiceAccountVolumeSource {
@@ -209,6 +744,277 @@ func expandServiceAccount(serviceAccountSource []interface{}) *kubevirtapiv1.Ser
 	return result
 }
 
+func expandContainerDisk(containerDisk []interface{}) *kubevirtapiv1.ContainerDiskSource {
+	if len(containerDisk) == 0 || containerDisk[0] == nil {
+		return nil
+	}
+
+	result := &kubevirtapiv1.ContainerDiskSource{}
+	in := containerDisk[0].(map[string]interface{})
+
+	if v, ok := in["image"].(string); ok {
+		result.Image = v
+	}
+	if v, ok := in["image_pull_secret"].(string); ok {
+		result.ImagePullSecret = v
+	}
+	if v, ok := in["path"].(string); ok {
+		result.Path = v
+	}
+	if v, ok := in["image_pull_policy"].(string); ok {
+		result.ImagePullPolicy = k8sv1.PullPolicy(v)
+	}
+
+	return result
+}
+
+func expandPersistentVolumeClaimVolumeSource(pvc []interface{}) *kubevirtapiv1.PersistentVolumeClaimVolumeSource {
+	if len(pvc) == 0 || pvc[0] == nil {
+		return nil
+	}
+
+	result := &kubevirtapiv1.PersistentVolumeClaimVolumeSource{}
+	in := pvc[0].(map[string]interface{})
+
+	if v, ok := in["claim_name"].(string); ok {
+		result.ClaimName = v
+	}
+	if v, ok := in["read_only"].(bool); ok {
+		result.ReadOnly = v
+	}
+	if v, ok := in["hotpluggable"].(bool); ok {
+		result.Hotpluggable = v
+	}
+
+	return result
+}
+
+func expandEphemeral(ephemeral []interface{}) *kubevirtapiv1.EphemeralVolumeSource {
+	if len(ephemeral) == 0 {
+		return nil
+	}
+
+	result := &kubevirtapiv1.EphemeralVolumeSource{}
+
+	in, ok := ephemeral[0].(map[string]interface{})
+	if !ok {
+		return result
+	}
+
+	if v, ok := in["persistent_volume_claim"].([]interface{}); ok {
+		if pvc := expandPersistentVolumeClaimVolumeSource(v); pvc != nil {
+			result.PersistentVolumeClaim = &pvc.PersistentVolumeClaimVolumeSource
+		}
+	}
+
+	return result
+}
+
+func expandEmptyDisk(emptyDisk []interface{}) (*kubevirtapiv1.EmptyDiskSource, error) {
+	if len(emptyDisk) == 0 || emptyDisk[0] == nil {
+		return nil, nil
+	}
+
+	result := &kubevirtapiv1.EmptyDiskSource{}
+	in := emptyDisk[0].(map[string]interface{})
+
+	if v, ok := in["capacity"].(string); ok {
+		capacity, err := resource.ParseQuantity(v)
+		if err != nil {
+			return result, err
+		}
+		result.Capacity = capacity
+	}
+
+	return result, nil
+}
+
+func expandHostDisk(hostDisk []interface{}) (*kubevirtapiv1.HostDisk, error) {
+	if len(hostDisk) == 0 || hostDisk[0] == nil {
+		return nil, nil
+	}
+
+	result := &kubevirtapiv1.HostDisk{}
+	in := hostDisk[0].(map[string]interface{})
+
+	if v, ok := in["path"].(string); ok {
+		result.Path = v
+	}
+	if v, ok := in["type"].(string); ok {
+		result.Type = kubevirtapiv1.HostDiskType(v)
+	}
+	if v, ok := in["capacity"].(string); ok && v != "" {
+		capacity, err := resource.ParseQuantity(v)
+		if err != nil {
+			return result, err
+		}
+		result.Capacity = capacity
+	}
+	if v, ok := in["shared"].(bool); ok && v {
+		result.Shared = &v
+	}
+
+	return result, nil
+}
+
+func expandConfigMap(configMap []interface{}) *kubevirtapiv1.ConfigMapVolumeSource {
+	if len(configMap) == 0 || configMap[0] == nil {
+		return nil
+	}
+
+	result := &kubevirtapiv1.ConfigMapVolumeSource{}
+	in := configMap[0].(map[string]interface{})
+
+	if v, ok := in["name"].(string); ok {
+		result.Name = v
+	}
+	if v, ok := in["optional"].(bool); ok && v {
+		result.Optional = &v
+	}
+	if v, ok := in["volume_label"].(string); ok {
+		result.VolumeLabel = v
+	}
+
+	return result
+}
+
+func expandSecret(secret []interface{}) *kubevirtapiv1.SecretVolumeSource {
+	if len(secret) == 0 || secret[0] == nil {
+		return nil
+	}
+
+	result := &kubevirtapiv1.SecretVolumeSource{}
+	in := secret[0].(map[string]interface{})
+
+	if v, ok := in["secret_name"].(string); ok {
+		result.SecretName = v
+	}
+	if v, ok := in["optional"].(bool); ok && v {
+		result.Optional = &v
+	}
+	if v, ok := in["volume_label"].(string); ok {
+		result.VolumeLabel = v
+	}
+
+	return result
+}
+
+func expandDownwardAPI(downwardAPI []interface{}) (*kubevirtapiv1.DownwardAPIVolumeSource, error) {
+	if len(downwardAPI) == 0 {
+		return nil, nil
+	}
+
+	result := &kubevirtapiv1.DownwardAPIVolumeSource{}
+
+	in, ok := downwardAPI[0].(map[string]interface{})
+	if !ok {
+		return result, nil
+	}
+
+	if v, ok := in["field"].([]interface{}); ok {
+		for _, field := range v {
+			file, err := expandDownwardAPIVolumeFile(field.(map[string]interface{}))
+			if err != nil {
+				return result, err
+			}
+			result.Fields = append(result.Fields, file)
+		}
+	}
+	if v, ok := in["volume_label"].(string); ok {
+		result.VolumeLabel = v
+	}
+
+	return result, nil
+}
+
+func expandDownwardAPIVolumeFile(in map[string]interface{}) (k8sv1.DownwardAPIVolumeFile, error) {
+	result := k8sv1.DownwardAPIVolumeFile{}
+
+	if v, ok := in["path"].(string); ok {
+		result.Path = v
+	}
+	if v, ok := in["field_ref"].([]interface{}); ok && len(v) > 0 && v[0] != nil {
+		fieldRef := v[0].(map[string]interface{})
+		result.FieldRef = &k8sv1.ObjectFieldSelector{
+			APIVersion: fieldRef["api_version"].(string),
+			FieldPath:  fieldRef["field_path"].(string),
+		}
+	}
+	if v, ok := in["resource_field_ref"].([]interface{}); ok && len(v) > 0 && v[0] != nil {
+		resourceFieldRef := v[0].(map[string]interface{})
+		result.ResourceFieldRef = &k8sv1.ResourceFieldSelector{
+			ContainerName: resourceFieldRef["container_name"].(string),
+			Resource:      resourceFieldRef["resource"].(string),
+		}
+		if divisor, ok := resourceFieldRef["divisor"].(string); ok && divisor != "" {
+			quantity, err := resource.ParseQuantity(divisor)
+			if err != nil {
+				return result, err
+			}
+			result.ResourceFieldRef.Divisor = quantity
+		}
+	}
+	if v, ok := in["mode"].(int); ok && v != 0 {
+		mode := int32(v)
+		result.Mode = &mode
+	}
+
+	return result, nil
+}
+
+// validateVolumeReferences checks that every volume of the spec is attached to the guest by
+// exactly one disk or filesystem of the same name, which KubeVirt requires. Memory dump volumes
+// are attached to the virt-launcher pod instead, and names not known yet are skipped.
+func validateVolumeReferences(spec map[string]interface{}) error {
+	references := make(map[string]int)
+	if domain, ok := spec["domain"].([]interface{}); ok && len(domain) > 0 && domain[0] != nil {
+		if devices, ok := domain[0].(map[string]interface{})["devices"].([]interface{}); ok && len(devices) > 0 && devices[0] != nil {
+			for _, key := range []string{"disk", "filesystem"} {
+				attached, _ := devices[0].(map[string]interface{})[key].([]interface{})
+				for _, v := range attached {
+					if device, ok := v.(map[string]interface{}); ok {
+						name, _ := device["name"].(string)
+						references[name]++
+					}
+				}
+			}
+		}
+	}
+
+	volumes, _ := spec["volume"].([]interface{})
+	volumeNames := make(map[string]bool, len(volumes))
+	for _, v := range volumes {
+		volume, ok := v.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		name, _ := volume["name"].(string)
+		if name == "" {
+			continue
+		}
+		if volumeNames[name] {
+			return fmt.Errorf("volume %q is defined more than once", name)
+		}
+		volumeNames[name] = true
+
+		if volumeSource, ok := volume["volume_source"].([]interface{}); ok && len(volumeSource) > 0 && volumeSource[0] != nil {
+			if memoryDump, ok := volumeSource[0].(map[string]interface{})["memory_dump"].([]interface{}); ok && len(memoryDump) > 0 {
+				continue
+			}
+		}
+
+		switch references[name] {
+		case 0:
+			return fmt.Errorf("volume %q has no matching disk or filesystem in domain.devices", name)
+		case 1:
+		default:
+			return fmt.Errorf("volume %q is referenced by more than one disk or filesystem in domain.devices", name)
+		}
+	}
+
+	return nil
+}
+
 func flattenVolumes(in []kubevirtapiv1.Volume) []interface{} {
 	att := make([]interface{}, len(in))
 
@@ -233,9 +1039,69 @@ func flattenVolumeSource(in kubevirtapiv1.VolumeSource) []interface{} {
 	if in.CloudInitConfigDrive != nil {
 		att["cloud_init_config_drive"] = flattenCloudInitConfigDrive(*in.CloudInitConfigDrive)
 	}
+	if in.CloudInitNoCloud != nil {
+		att["cloud_init_no_cloud"] = flattenCloudInitNoCloud(*in.CloudInitNoCloud)
+	}
+	if in.Sysprep != nil {
+		sysprep := make(map[string]interface{})
+		if in.Sysprep.Secret != nil {
+			sysprep["secret"] = k8s.FlattenLocalObjectReferences(*in.Sysprep.Secret)
+		}
+		if in.Sysprep.ConfigMap != nil {
+			sysprep["config_map"] = k8s.FlattenLocalObjectReferences(*in.Sysprep.ConfigMap)
+		}
+		att["sysprep"] = []interface{}{sysprep}
+	}
 	if in.ServiceAccount != nil {
 		att["service_account"] = flattenServiceAccount(*in.ServiceAccount)
 	}
+	if in.ContainerDisk != nil {
+		att["container_disk"] = flattenContainerDisk(*in.ContainerDisk)
+	}
+	if in.PersistentVolumeClaim != nil {
+		att["persistent_volume_claim"] = flattenPersistentVolumeClaimVolumeSource(*in.PersistentVolumeClaim)
+	}
+	if in.Ephemeral != nil {
+		ephemeral := make(map[string]interface{})
+		if in.Ephemeral.PersistentVolumeClaim != nil {
+			ephemeral["persistent_volume_claim"] = []interface{}{map[string]interface{}{
+				"claim_name": in.Ephemeral.PersistentVolumeClaim.ClaimName,
+				"read_only":  in.Ephemeral.PersistentVolumeClaim.ReadOnly,
+			}}
+		}
+		att["ephemeral"] = []interface{}{ephemeral}
+	}
+	if in.EmptyDisk != nil {
+		att["empty_disk"] = []interface{}{map[string]interface{}{
+			"capacity": in.EmptyDisk.Capacity.String(),
+		}}
+	}
+	if in.HostDisk != nil {
+		att["host_disk"] = flattenHostDisk(*in.HostDisk)
+	}
+	if in.ConfigMap != nil {
+		att["config_map"] = []interface{}{map[string]interface{}{
+			"name":         in.ConfigMap.Name,
+			"optional":     in.ConfigMap.Optional != nil && *in.ConfigMap.Optional,
+			"volume_label": in.ConfigMap.VolumeLabel,
+		}}
+	}
+	if in.Secret != nil {
+		att["secret"] = []interface{}{map[string]interface{}{
+			"secret_name":  in.Secret.SecretName,
+			"optional":     in.Secret.Optional != nil && *in.Secret.Optional,
+			"volume_label": in.Secret.VolumeLabel,
+		}}
+	}
+	if in.DownwardAPI != nil {
+		att["downward_api"] = flattenDownwardAPI(*in.DownwardAPI)
+	}
+	if in.DownwardMetrics != nil {
+		att["downward_metrics"] = []interface{}{map[string]interface{}{}}
+	}
+	if in.MemoryDump != nil {
+		att["memory_dump"] = flattenPersistentVolumeClaimVolumeSource(in.MemoryDump.PersistentVolumeClaimVolumeSource)
+	}
 
 	return []interface{}{att}
 }
@@ -244,6 +1110,7 @@ func flattenDataVolume(in kubevirtapiv1.DataVolumeSource) []interface{} {
 	att := make(map[string]interface{})
 
 	att["name"] = in.Name
+	att["hotpluggable"] = in.Hotpluggable
 
 	return []interface{}{att}
 }
@@ -251,6 +1118,25 @@ func flattenDataVolume(in kubevirtapiv1.DataVolumeSource) []interface{} {
 func flattenCloudInitConfigDrive(in kubevirtapiv1.CloudInitConfigDriveSource) []interface{} {
 	att := make(map[string]interface{})
 
+	att["store_as_secret"] = false
+	if in.UserDataSecretRef != nil {
+		att["user_data_secret_ref"] = k8s.FlattenLocalObjectReferences(*in.UserDataSecretRef)
+	}
+	att["user_data_base64"] = in.UserDataBase64
+	att["user_data"] = in.UserData
+	if in.NetworkDataSecretRef != nil {
+		att["network_data_secret_ref"] = k8s.FlattenLocalObjectReferences(*in.NetworkDataSecretRef)
+	}
+	att["network_data_base64"] = in.NetworkDataBase64
+	att["network_data"] = in.NetworkData
+
+	return []interface{}{att}
+}
+
+func flattenCloudInitNoCloud(in kubevirtapiv1.CloudInitNoCloudSource) []interface{} {
+	att := make(map[string]interface{})
+
+	att["store_as_secret"] = false
 	if in.UserDataSecretRef != nil {
 		att["user_data_secret_ref"] = k8s.FlattenLocalObjectReferences(*in.UserDataSecretRef)
 	}
@@ -272,3 +1158,75 @@ func flattenServiceAccount(in kubevirtapiv1.ServiceAccountVolumeSource) []interf
 
 	return []interface{}{att}
 }
+
+func flattenContainerDisk(in kubevirtapiv1.ContainerDiskSource) []interface{} {
+	att := make(map[string]interface{})
+
+	att["image"] = in.Image
+	att["image_pull_secret"] = in.ImagePullSecret
+	att["path"] = in.Path
+	att["image_pull_policy"] = string(in.ImagePullPolicy)
+
+	return []interface{}{att}
+}
+
+func flattenPersistentVolumeClaimVolumeSource(in kubevirtapiv1.PersistentVolumeClaimVolumeSource) []interface{} {
+	att := make(map[string]interface{})
+
+	att["claim_name"] = in.ClaimName
+	att["read_only"] = in.ReadOnly
+	att["hotpluggable"] = in.Hotpluggable
+
+	return []interface{}{att}
+}
+
+func flattenHostDisk(in kubevirtapiv1.HostDisk) []interface{} {
+	att := make(map[string]interface{})
+
+	att["path"] = in.Path
+	att["type"] = string(in.Type)
+	att["capacity"] = ""
+	if !in.Capacity.IsZero() {
+		att["capacity"] = in.Capacity.String()
+	}
+	att["shared"] = in.Shared != nil && *in.Shared
+
+	return []interface{}{att}
+}
+
+func flattenDownwardAPI(in kubevirtapiv1.DownwardAPIVolumeSource) []interface{} {
+	att := make(map[string]interface{})
+
+	fields := make([]interface{}, len(in.Fields))
+	for i, v := range in.Fields {
+		field := map[string]interface{}{
+			"path": v.Path,
+			"mode": 0,
+		}
+		if v.FieldRef != nil {
+			field["field_ref"] = []interface{}{map[string]interface{}{
+				"api_version": v.FieldRef.APIVersion,
+				"field_path":  v.FieldRef.FieldPath,
+			}}
+		}
+		if v.ResourceFieldRef != nil {
+			divisor := ""
+			if !v.ResourceFieldRef.Divisor.IsZero() {
+				divisor = v.ResourceFieldRef.Divisor.String()
+			}
+			field["resource_field_ref"] = []interface{}{map[string]interface{}{
+				"container_name": v.ResourceFieldRef.ContainerName,
+				"resource":       v.ResourceFieldRef.Resource,
+				"divisor":        divisor,
+			}}
+		}
+		if v.Mode != nil {
+			field["mode"] = int(*v.Mode)
+		}
+		fields[i] = field
+	}
+	att["field"] = fields
+	att["volume_label"] = in.VolumeLabel
+
+	return []interface{}{att}
+}
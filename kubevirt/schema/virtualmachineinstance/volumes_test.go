@@ -0,0 +1,421 @@
+package virtualmachineinstance
+
+import (
+	"context"
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+	"github.com/nrp-nautilus/terraform-provider-kubevirt/kubevirt/utils"
+	k8sv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	kubevirtapiv1 "kubevirt.io/api/core/v1"
+
+	"gotest.tools/assert"
+)
+
+func TestExpandFlattenVolumeSources(t *testing.T) {
+	mode := int32(0644)
+	volumes := []kubevirtapiv1.Volume{
+		{
+			Name: "rootdisk",
+			VolumeSource: kubevirtapiv1.VolumeSource{
+				ContainerDisk: &kubevirtapiv1.ContainerDiskSource{
+					Image:           "quay.io/containerdisks/fedora:38",
+					ImagePullSecret: "registry",
+					Path:            "/disk/fedora.qcow2",
+					ImagePullPolicy: k8sv1.PullIfNotPresent,
+				},
+			},
+		},
+		{
+			Name: "data",
+			VolumeSource: kubevirtapiv1.VolumeSource{
+				PersistentVolumeClaim: &kubevirtapiv1.PersistentVolumeClaimVolumeSource{
+					PersistentVolumeClaimVolumeSource: k8sv1.PersistentVolumeClaimVolumeSource{
+						ClaimName: "data",
+					},
+					Hotpluggable: true,
+				},
+			},
+		},
+		{
+			Name: "hotplug",
+			VolumeSource: kubevirtapiv1.VolumeSource{
+				DataVolume: &kubevirtapiv1.DataVolumeSource{
+					Name:         "hotplug",
+					Hotpluggable: true,
+				},
+			},
+		},
+		{
+			Name: "golden",
+			VolumeSource: kubevirtapiv1.VolumeSource{
+				Ephemeral: &kubevirtapiv1.EphemeralVolumeSource{
+					PersistentVolumeClaim: &k8sv1.PersistentVolumeClaimVolumeSource{
+						ClaimName: "golden",
+						ReadOnly:  true,
+					},
+				},
+			},
+		},
+		{
+			Name: "scratch",
+			VolumeSource: kubevirtapiv1.VolumeSource{
+				EmptyDisk: &kubevirtapiv1.EmptyDiskSource{
+					Capacity: resource.MustParse("2Gi"),
+				},
+			},
+		},
+		{
+			Name: "host",
+			VolumeSource: kubevirtapiv1.VolumeSource{
+				HostDisk: &kubevirtapiv1.HostDisk{
+					Path:     "/data/disk.img",
+					Type:     kubevirtapiv1.HostDiskExistsOrCreate,
+					Capacity: resource.MustParse("1Gi"),
+					Shared:   utils.PtrToBool(true),
+				},
+			},
+		},
+		{
+			Name: "config",
+			VolumeSource: kubevirtapiv1.VolumeSource{
+				ConfigMap: &kubevirtapiv1.ConfigMapVolumeSource{
+					LocalObjectReference: k8sv1.LocalObjectReference{Name: "config"},
+					Optional:             utils.PtrToBool(true),
+					VolumeLabel:          "OEMDRV",
+				},
+			},
+		},
+		{
+			Name: "credentials",
+			VolumeSource: kubevirtapiv1.VolumeSource{
+				Secret: &kubevirtapiv1.SecretVolumeSource{
+					SecretName: "credentials",
+				},
+			},
+		},
+		{
+			Name: "podinfo",
+			VolumeSource: kubevirtapiv1.VolumeSource{
+				DownwardAPI: &kubevirtapiv1.DownwardAPIVolumeSource{
+					Fields: []k8sv1.DownwardAPIVolumeFile{
+						{
+							Path: "labels",
+							FieldRef: &k8sv1.ObjectFieldSelector{
+								APIVersion: "v1",
+								FieldPath:  "metadata.labels",
+							},
+							Mode: &mode,
+						},
+						{
+							Path: "memory",
+							ResourceFieldRef: &k8sv1.ResourceFieldSelector{
+								ContainerName: "compute",
+								Resource:      "limits.memory",
+								Divisor:       resource.MustParse("1Mi"),
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			Name: "metrics",
+			VolumeSource: kubevirtapiv1.VolumeSource{
+				DownwardMetrics: &kubevirtapiv1.DownwardMetricsVolumeSource{},
+			},
+		},
+		{
+			Name: "cloudinit",
+			VolumeSource: kubevirtapiv1.VolumeSource{
+				CloudInitNoCloud: &kubevirtapiv1.CloudInitNoCloudSource{
+					UserDataSecretRef: &k8sv1.LocalObjectReference{Name: "userdata"},
+					NetworkData:       "version: 2",
+				},
+			},
+		},
+		{
+			Name: "sysprep",
+			VolumeSource: kubevirtapiv1.VolumeSource{
+				Sysprep: &kubevirtapiv1.SysprepSource{
+					ConfigMap: &k8sv1.LocalObjectReference{Name: "unattend"},
+				},
+			},
+		},
+	}
+
+	// Flattening has to yield the same volumes once expanded again, so that plans stay clean.
+	output, err := expandVolumes(flattenVolumes(volumes))
+	assert.NilError(t, err)
+	assert.DeepEqual(t, volumes, output)
+}
+
+func TestValidateVolumeReferences(t *testing.T) {
+	named := func(names ...string) []interface{} {
+		result := []interface{}{}
+		for _, name := range names {
+			result = append(result, map[string]interface{}{"name": name})
+		}
+		return result
+	}
+	spec := func(volumes, disks, filesystems []interface{}) map[string]interface{} {
+		return map[string]interface{}{
+			"domain": []interface{}{
+				map[string]interface{}{
+					"devices": []interface{}{
+						map[string]interface{}{
+							"disk":       disks,
+							"filesystem": filesystems,
+						},
+					},
+				},
+			},
+			"volume": volumes,
+		}
+	}
+	memoryDump := map[string]interface{}{
+		"name": "dump",
+		"volume_source": []interface{}{
+			map[string]interface{}{
+				"memory_dump": []interface{}{
+					map[string]interface{}{"claim_name": "dump"},
+				},
+			},
+		},
+	}
+
+	cases := []struct {
+		name          string
+		spec          map[string]interface{}
+		expectedError string
+	}{
+		{
+			name: "every volume has a disk or filesystem",
+			spec: spec(named("rootdisk", "shared"), named("rootdisk"), named("shared")),
+		},
+		{
+			name: "memory dump without disk",
+			spec: spec(append(named("rootdisk"), memoryDump), named("rootdisk"), nil),
+		},
+		{
+			name: "name not known yet",
+			spec: spec(named("rootdisk", ""), named("rootdisk"), nil),
+		},
+		{
+			name:          "missing disk",
+			spec:          spec(named("rootdisk", "shared"), named("rootdisk"), nil),
+			expectedError: "volume \"shared\" has no matching disk or filesystem in domain.devices",
+		},
+		{
+			name:          "disk and filesystem for the same volume",
+			spec:          spec(named("rootdisk", "shared"), named("rootdisk", "shared"), named("shared")),
+			expectedError: "volume \"shared\" is referenced by more than one disk or filesystem in domain.devices",
+		},
+		{
+			name:          "duplicate volume",
+			spec:          spec(named("rootdisk", "shared", "rootdisk"), named("rootdisk"), named("shared")),
+			expectedError: "volume \"rootdisk\" is defined more than once",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := validateVolumeReferences(tc.spec)
+
+			if tc.expectedError == "" {
+				assert.NilError(t, err)
+			} else {
+				assert.Error(t, err, tc.expectedError)
+			}
+		})
+	}
+}
+
+func TestValidateSpecDiff(t *testing.T) {
+	data := func(n int) string {
+		return strings.Repeat("#", n)
+	}
+	dataBase64 := func(n int) string {
+		return base64.StdEncoding.EncodeToString([]byte(data(n)))
+	}
+
+	cases := []struct {
+		name          string
+		source        string
+		cloudInit     map[string]interface{}
+		disk          string
+		expectedError string
+	}{
+		{
+			name:          "volume without disk",
+			source:        "cloud_init_no_cloud",
+			cloudInit:     map[string]interface{}{"user_data": data(1)},
+			disk:          "rootdisk",
+			expectedError: `volume "cloudinit" has no matching disk or filesystem in domain.devices`,
+		},
+		{
+			name:      "user data at the limit",
+			source:    "cloud_init_no_cloud",
+			cloudInit: map[string]interface{}{"user_data": data(2048)},
+		},
+		{
+			name:          "user data over the limit",
+			source:        "cloud_init_no_cloud",
+			cloudInit:     map[string]interface{}{"user_data": data(2049)},
+			expectedError: `volume "cloudinit": cloud_init_no_cloud: inline user data exceeds 2048 bytes, set store_as_secret or store it in a Secret and reference it with user_data_secret_ref`,
+		},
+		{
+			name:      "base64 user data at the limit",
+			source:    "cloud_init_config_drive",
+			cloudInit: map[string]interface{}{"user_data_base64": dataBase64(2048)},
+		},
+		{
+			name:      "base64 user data with padding within the limit",
+			source:    "cloud_init_config_drive",
+			cloudInit: map[string]interface{}{"user_data_base64": dataBase64(2047)},
+		},
+		{
+			name:          "base64 user data over the limit",
+			source:        "cloud_init_config_drive",
+			cloudInit:     map[string]interface{}{"user_data_base64": dataBase64(2049)},
+			expectedError: `volume "cloudinit": cloud_init_config_drive: inline user data exceeds 2048 bytes, set store_as_secret or store it in a Secret and reference it with user_data_secret_ref`,
+		},
+		{
+			name:          "base64 network data over the limit",
+			source:        "cloud_init_no_cloud",
+			cloudInit:     map[string]interface{}{"network_data_base64": dataBase64(2049)},
+			expectedError: `volume "cloudinit": cloud_init_no_cloud: inline network data exceeds 2048 bytes, set store_as_secret or store it in a Secret and reference it with network_data_secret_ref`,
+		},
+		{
+			name:          "invalid base64 user data",
+			source:        "cloud_init_no_cloud",
+			cloudInit:     map[string]interface{}{"user_data_base64": "#cloud-config"},
+			expectedError: `volume "cloudinit": cloud_init_no_cloud: user_data_base64: illegal base64 data at input byte 0`,
+		},
+		{
+			name:      "user data over the limit stored as secret",
+			source:    "cloud_init_no_cloud",
+			cloudInit: map[string]interface{}{"user_data": data(2049), "store_as_secret": true},
+		},
+	}
+
+	resource := &schema.Resource{
+		Schema:        VirtualMachineInstanceFields(),
+		CustomizeDiff: ValidateSpecDiff("spec"),
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			disk := tc.disk
+			if disk == "" {
+				disk = "cloudinit"
+			}
+			raw := map[string]interface{}{
+				"metadata": []interface{}{
+					map[string]interface{}{"name": "test-vmi", "namespace": "default"},
+				},
+				"spec": []interface{}{
+					map[string]interface{}{
+						"domain": []interface{}{
+							map[string]interface{}{
+								"devices": []interface{}{
+									map[string]interface{}{
+										"disk": []interface{}{
+											map[string]interface{}{"name": disk},
+										},
+									},
+								},
+							},
+						},
+						"volume": []interface{}{
+							map[string]interface{}{
+								"name": "cloudinit",
+								"volume_source": []interface{}{
+									map[string]interface{}{
+										tc.source: []interface{}{tc.cloudInit},
+									},
+								},
+							},
+						},
+					},
+				},
+			}
+
+			_, err := resource.Diff(context.Background(), nil, terraform.NewResourceConfigRaw(raw), nil)
+
+			if tc.expectedError == "" {
+				assert.NilError(t, err)
+			} else {
+				assert.Error(t, err, tc.expectedError)
+			}
+		})
+	}
+}
+
+func TestExpandVolumeSourceValidation(t *testing.T) {
+	cases := []struct {
+		name          string
+		input         map[string]interface{}
+		expectedError string
+	}{
+		{
+			name: "base64 user data stored as secret",
+			input: map[string]interface{}{
+				"cloud_init_config_drive": []interface{}{
+					map[string]interface{}{
+						"user_data_base64": "IyMj",
+						"store_as_secret":  true,
+					},
+				},
+			},
+			expectedError: "cloud_init_config_drive: store_as_secret only supports user_data and network_data",
+		},
+		{
+			name: "network data only stored as secret",
+			input: map[string]interface{}{
+				"cloud_init_no_cloud": []interface{}{
+					map[string]interface{}{
+						"network_data":    "version: 2",
+						"store_as_secret": true,
+					},
+				},
+			},
+			expectedError: "cloud_init_no_cloud: store_as_secret requires user_data",
+		},
+		{
+			name: "sysprep without answer file",
+			input: map[string]interface{}{
+				"sysprep": []interface{}{nil},
+			},
+			expectedError: "exactly one of secret or config_map must be set in sysprep",
+		},
+		{
+			name: "sysprep with both answer files",
+			input: map[string]interface{}{
+				"sysprep": []interface{}{
+					map[string]interface{}{
+						"secret":     []interface{}{map[string]interface{}{"name": "unattend"}},
+						"config_map": []interface{}{map[string]interface{}{"name": "unattend"}},
+					},
+				},
+			},
+			expectedError: "exactly one of secret or config_map must be set in sysprep",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := expandVolumeSource([]interface{}{tc.input})
+
+			if tc.expectedError == "" {
+				assert.NilError(t, err)
+			} else {
+				assert.Error(t, err, tc.expectedError)
+			}
+		})
+	}
+}
@@ -8,6 +8,7 @@ import (
 	k8sv1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
 	cdiv1 "kubevirt.io/containerized-data-importer-api/pkg/apis/core/v1beta1"
 
 	kubevirtapiv1 "kubevirt.io/api/core/v1"
@@ -31,6 +32,21 @@ func GetBaseInputForDataVolume() interface{} {
 								"url":             "https://cloud.centos.org/centos/7/images/CentOS-7-x86_64-GenericCloud.qcow2",
 								"secret_ref":      "secret_ref",
 								"cert_config_map": "cert_config_map",
+								"extra_headers":   []interface{}{"X-First: first"},
+							},
+						},
+						"s3": []interface{}{
+							map[string]interface{}{
+								"url":             "https://s3.example.com/bucket/disk.qcow2",
+								"secret_ref":      "s3_secret_ref",
+								"cert_config_map": "s3_cert_config_map",
+							},
+						},
+						"registry": []interface{}{
+							map[string]interface{}{
+								"url":         "docker://quay.io/containerdisks/fedora:latest",
+								"pull_method": "node",
+								"secret_ref":  "registry_secret_ref",
 							},
 						},
 						"pvc": []interface{}{
@@ -39,6 +55,36 @@ func GetBaseInputForDataVolume() interface{} {
 								"name":      "name",
 							},
 						},
+						"upload": []interface{}{
+							map[string]interface{}{},
+						},
+						"blank": []interface{}{
+							map[string]interface{}{},
+						},
+						"imageio": []interface{}{
+							map[string]interface{}{
+								"url":             "https://ovirt.example.com/ovirt-engine/api",
+								"disk_id":         "disk_id",
+								"secret_ref":      "imageio_secret_ref",
+								"cert_config_map": "imageio_cert_config_map",
+							},
+						},
+						"vddk": []interface{}{
+							map[string]interface{}{
+								"url":            "https://vcenter.example.com",
+								"uuid":           "uuid",
+								"backing_file":   "[datastore] vm/disk.vmdk",
+								"thumbprint":     "thumbprint",
+								"secret_ref":     "vddk_secret_ref",
+								"init_image_url": "quay.io/example/vddk:latest",
+							},
+						},
+						"snapshot": []interface{}{
+							map[string]interface{}{
+								"namespace": "snapshot_namespace",
+								"name":      "snapshot_name",
+							},
+						},
 					},
 				},
 				"pvc": []interface{}{
@@ -72,11 +118,42 @@ func getDataVolumeSpec() cdiv1.DataVolumeSpec {
 				URL:           "https://cloud.centos.org/centos/7/images/CentOS-7-x86_64-GenericCloud.qcow2",
 				SecretRef:     "secret_ref",
 				CertConfigMap: "cert_config_map",
+				ExtraHeaders:  []string{"X-First: first"},
+			},
+			S3: &cdiv1.DataVolumeSourceS3{
+				URL:           "https://s3.example.com/bucket/disk.qcow2",
+				SecretRef:     "s3_secret_ref",
+				CertConfigMap: "s3_cert_config_map",
+			},
+			Registry: &cdiv1.DataVolumeSourceRegistry{
+				URL:        (func() *string { str := "docker://quay.io/containerdisks/fedora:latest"; return &str })(),
+				PullMethod: (func() *cdiv1.RegistryPullMethod { method := cdiv1.RegistryPullNode; return &method })(),
+				SecretRef:  (func() *string { str := "registry_secret_ref"; return &str })(),
 			},
 			PVC: &cdiv1.DataVolumeSourcePVC{
 				Namespace: "namespace",
 				Name:      "name",
 			},
+			Upload: &cdiv1.DataVolumeSourceUpload{},
+			Blank:  &cdiv1.DataVolumeBlankImage{},
+			Imageio: &cdiv1.DataVolumeSourceImageIO{
+				URL:           "https://ovirt.example.com/ovirt-engine/api",
+				DiskID:        "disk_id",
+				SecretRef:     "imageio_secret_ref",
+				CertConfigMap: "imageio_cert_config_map",
+			},
+			VDDK: &cdiv1.DataVolumeSourceVDDK{
+				URL:          "https://vcenter.example.com",
+				UUID:         "uuid",
+				BackingFile:  "[datastore] vm/disk.vmdk",
+				Thumbprint:   "thumbprint",
+				SecretRef:    "vddk_secret_ref",
+				InitImageURL: "quay.io/example/vddk:latest",
+			},
+			Snapshot: &cdiv1.DataVolumeSourceSnapshot{
+				Namespace: "snapshot_namespace",
+				Name:      "snapshot_name",
+			},
 		},
 		PVC: &k8sv1.PersistentVolumeClaimSpec{
 			AccessModes: []k8sv1.PersistentVolumeAccessMode{
@@ -159,6 +236,69 @@ func GetBaseInputForVirtualMachine() interface{} {
 										"over_commit_guest_overhead": false,
 									},
 								},
+								"cpu": []interface{}{
+									map[string]interface{}{
+										"cores":   4,
+										"sockets": 2,
+										"threads": 1,
+										"model":   "host-passthrough",
+										"feature": []interface{}{
+											map[string]interface{}{
+												"name":   "pcid",
+												"policy": "require",
+											},
+										},
+										"dedicated_cpu_placement": true,
+										"isolate_emulator_thread": true,
+										"numa": []interface{}{
+											map[string]interface{}{
+												"guest_mapping_passthrough": []interface{}{nil},
+											},
+										},
+										"realtime": []interface{}{},
+									},
+								},
+								"memory": []interface{}{
+									map[string]interface{}{
+										"guest": "8Gi",
+										"hugepages": []interface{}{
+											map[string]interface{}{
+												"page_size": "1Gi",
+											},
+										},
+									},
+								},
+								"firmware": []interface{}{
+									map[string]interface{}{
+										"uuid":   "5d307ca9-b3ef-428c-8861-06e72d69f223",
+										"serial": "serial",
+										"bootloader": []interface{}{
+											map[string]interface{}{
+												"bios": []interface{}{},
+												"efi": []interface{}{
+													map[string]interface{}{
+														"secure_boot": true,
+													},
+												},
+											},
+										},
+										"kernel_boot": []interface{}{},
+									},
+								},
+								"features": []interface{}{
+									map[string]interface{}{
+										"smm": []interface{}{nil},
+									},
+								},
+								"clock": []interface{}{
+									map[string]interface{}{
+										"utc": []interface{}{
+											map[string]interface{}{
+												"offset_seconds": 3600,
+											},
+										},
+									},
+								},
 								"devices": []interface{}{
 									map[string]interface{}{
 										"disk": []interface{}{
@@ -177,12 +317,68 @@ func GetBaseInputForVirtualMachine() interface{} {
 												"name":   "test-vm-datavolumedisk1",
 												"serial": "serial",
 											},
+											map[string]interface{}{
+												"disk_device": []interface{}{
+													map[string]interface{}{
+														"cdrom": []interface{}{
+															map[string]interface{}{
+																"bus":       "sata",
+																"read_only": true,
+																"tray":      "",
+															},
+														},
+													},
+												},
+												"name":       "installer",
+												"boot_order": 1,
+												"cache":      "none",
+												"io":         "native",
+												"block_size": []interface{}{
+													map[string]interface{}{
+														"custom": []interface{}{
+															map[string]interface{}{
+																"logical":  512,
+																"physical": 4096,
+															},
+														},
+													},
+												},
+											},
 										},
 										"interface": []interface{}{
 											map[string]interface{}{
 												"interface_binding_method": "InterfaceBridge",
 												"name":                     "main",
 											},
+											map[string]interface{}{
+												"interface_binding_method": "InterfaceMasquerade",
+												"name":                     "secondary",
+												"model":                    "e1000e",
+												"mac_address":              "de:ad:00:00:be:af",
+												"ports": []interface{}{
+													map[string]interface{}{
+														"name":     "http",
+														"protocol": "TCP",
+														"port":     80,
+													},
+												},
+												"boot_order":  2,
+												"pci_address": "0000:81:01.10",
+												"dhcp_options": []interface{}{
+													map[string]interface{}{
+														"boot_file_name":   "pxelinux.0",
+														"tftp_server_name": "tftp.example.com",
+														"ntp_servers":      []interface{}{"pool.ntp.org"},
+														"private_options": []interface{}{
+															map[string]interface{}{
+																"option": 240,
+																"value":  "extra",
+															},
+														},
+													},
+												},
+												"acpi_index": 3,
+											},
 										},
 									},
 								},
@@ -225,6 +421,42 @@ func GetBaseInputForVirtualMachine() interface{} {
 						},
 						"eviction_strategy":                "eviction_strategy",
 						"termination_grace_period_seconds": 120,
+						"liveness_probe": []interface{}{
+							map[string]interface{}{
+								"http_get": []interface{}{
+									map[string]interface{}{
+										"host":   "host",
+										"path":   "/healthz",
+										"port":   "8080",
+										"scheme": "HTTPS",
+										"http_header": []interface{}{
+											map[string]interface{}{
+												"name":  "X-Probe",
+												"value": "liveness",
+											},
+										},
+									},
+								},
+								"tcp_socket":            []interface{}{},
+								"exec":                  []interface{}{},
+								"guest_agent_ping":      []interface{}{},
+								"initial_delay_seconds": 30,
+								"period_seconds":        10,
+								"timeout_seconds":       5,
+								"success_threshold":     1,
+								"failure_threshold":     3,
+							},
+						},
+						"readiness_probe": []interface{}{
+							map[string]interface{}{
+								"exec": []interface{}{
+									map[string]interface{}{
+										"command": []interface{}{"cat", "/tmp/healthy"},
+									},
+								},
+								"period_seconds": 5,
+							},
+						},
 						"volume": []interface{}{
 							map[string]interface{}{
 								"name": "test-vm-datavolumedisk1",
@@ -261,6 +493,21 @@ func GetBaseInputForVirtualMachine() interface{} {
 									},
 								},
 							},
+							map[string]interface{}{
+								"name": "installer",
+								"volume_source": []interface{}{
+									map[string]interface{}{
+										"container_disk": []interface{}{
+											map[string]interface{}{
+												"image":             "quay.io/containerdisks/fedora:latest",
+												"image_pull_secret": "",
+												"path":              "",
+												"image_pull_policy": "Always",
+											},
+										},
+									},
+								},
+							},
 						},
 						"hostname":  "hostname",
 						"subdomain": "subdomain",
@@ -336,6 +583,50 @@ func GetBaseOutputForVirtualMachine() kubevirtapiv1.VirtualMachineSpec {
 						},
 						OvercommitGuestOverhead: false,
 					},
+					CPU: &kubevirtapiv1.CPU{
+						Cores:   4,
+						Sockets: 2,
+						Threads: 1,
+						Model:   "host-passthrough",
+						Features: []kubevirtapiv1.CPUFeature{
+							{
+								Name:   "pcid",
+								Policy: "require",
+							},
+						},
+						DedicatedCPUPlacement: true,
+						IsolateEmulatorThread: true,
+						NUMA: &kubevirtapiv1.NUMA{
+							GuestMappingPassthrough: &kubevirtapiv1.NUMAGuestMappingPassthrough{},
+						},
+					},
+					Memory: &kubevirtapiv1.Memory{
+						Guest: (func() *resource.Quantity { res := resource.MustParse("8Gi"); return &res })(),
+						Hugepages: &kubevirtapiv1.Hugepages{
+							PageSize: "1Gi",
+						},
+					},
+					Firmware: &kubevirtapiv1.Firmware{
+						UUID:   "5d307ca9-b3ef-428c-8861-06e72d69f223",
+						Serial: "serial",
+						Bootloader: &kubevirtapiv1.Bootloader{
+							EFI: &kubevirtapiv1.EFI{
+								SecureBoot: utils.PtrToBool(true),
+							},
+						},
+					},
+					Features: &kubevirtapiv1.Features{
+						SMM: &kubevirtapiv1.FeatureState{
+							Enabled: utils.PtrToBool(true),
+						},
+					},
+					Clock: &kubevirtapiv1.Clock{
+						ClockOffset: kubevirtapiv1.ClockOffset{
+							UTC: &kubevirtapiv1.ClockOffsetUTC{
+								OffsetSeconds: (func() *int { offset := 3600; return &offset })(),
+							},
+						},
+					},
 					Devices: kubevirtapiv1.Devices{
 						Disks: []kubevirtapiv1.Disk{
 							{
@@ -349,6 +640,24 @@ func GetBaseOutputForVirtualMachine() kubevirtapiv1.VirtualMachineSpec {
 									},
 								},
 							},
+							{
+								Name: "installer",
+								DiskDevice: kubevirtapiv1.DiskDevice{
+									CDRom: &kubevirtapiv1.CDRomTarget{
+										Bus:      "sata",
+										ReadOnly: utils.PtrToBool(true),
+									},
+								},
+								BootOrder: (func() *uint { bootOrder := uint(1); return &bootOrder })(),
+								Cache:     kubevirtapiv1.CacheNone,
+								IO:        kubevirtapiv1.IONative,
+								BlockSize: &kubevirtapiv1.BlockSize{
+									Custom: &kubevirtapiv1.CustomBlockSize{
+										Logical:  512,
+										Physical: 4096,
+									},
+								},
+							},
 						},
 						Interfaces: []kubevirtapiv1.Interface{
 							{
@@ -357,6 +666,35 @@ func GetBaseOutputForVirtualMachine() kubevirtapiv1.VirtualMachineSpec {
 									Bridge: &kubevirtapiv1.InterfaceBridge{},
 								},
 							},
+							{
+								Name: "secondary",
+								InterfaceBindingMethod: kubevirtapiv1.InterfaceBindingMethod{
+									Masquerade: &kubevirtapiv1.InterfaceMasquerade{},
+								},
+								Model:      "e1000e",
+								MacAddress: "de:ad:00:00:be:af",
+								Ports: []kubevirtapiv1.Port{
+									{
+										Name:     "http",
+										Protocol: "TCP",
+										Port:     80,
+									},
+								},
+								BootOrder:  (func() *uint { bootOrder := uint(2); return &bootOrder })(),
+								PciAddress: "0000:81:01.10",
+								DHCPOptions: &kubevirtapiv1.DHCPOptions{
+									BootFileName:   "pxelinux.0",
+									TFTPServerName: "tftp.example.com",
+									NTPServers:     []string{"pool.ntp.org"},
+									PrivateOptions: []kubevirtapiv1.DHCPPrivateOptions{
+										{
+											Option: 240,
+											Value:  "extra",
+										},
+									},
+								},
+								ACPIIndex: 3,
+							},
 						},
 					},
 				},
@@ -402,6 +740,35 @@ func GetBaseOutputForVirtualMachine() kubevirtapiv1.VirtualMachineSpec {
 					return &retval
 				})(),
 				TerminationGracePeriodSeconds: utils.PtrToInt64(int64(120)),
+				LivenessProbe: &kubevirtapiv1.Probe{
+					Handler: kubevirtapiv1.Handler{
+						HTTPGet: &k8sv1.HTTPGetAction{
+							Host:   "host",
+							Path:   "/healthz",
+							Port:   intstr.FromInt(8080),
+							Scheme: k8sv1.URISchemeHTTPS,
+							HTTPHeaders: []k8sv1.HTTPHeader{
+								{
+									Name:  "X-Probe",
+									Value: "liveness",
+								},
+							},
+						},
+					},
+					InitialDelaySeconds: 30,
+					PeriodSeconds:       10,
+					TimeoutSeconds:      5,
+					SuccessThreshold:    1,
+					FailureThreshold:    3,
+				},
+				ReadinessProbe: &kubevirtapiv1.Probe{
+					Handler: kubevirtapiv1.Handler{
+						Exec: &k8sv1.ExecAction{
+							Command: []string{"cat", "/tmp/healthy"},
+						},
+					},
+					PeriodSeconds: 5,
+				},
 				Volumes: []kubevirtapiv1.Volume{
 					{
 						Name: "test-vm-datavolumedisk1",
@@ -426,6 +793,15 @@ func GetBaseOutputForVirtualMachine() kubevirtapiv1.VirtualMachineSpec {
 							},
 						},
 					},
+					{
+						Name: "installer",
+						VolumeSource: kubevirtapiv1.VolumeSource{
+							ContainerDisk: &kubevirtapiv1.ContainerDiskSource{
+								Image:           "quay.io/containerdisks/fedora:latest",
+								ImagePullPolicy: k8sv1.PullAlways,
+							},
+						},
+					},
 				},
 				Hostname:  "hostname",
 				Subdomain: "subdomain",
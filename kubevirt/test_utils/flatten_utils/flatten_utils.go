@@ -8,6 +8,7 @@ import (
 	k8sv1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
 	cdiv1 "kubevirt.io/containerized-data-importer-api/pkg/apis/core/v1beta1"
 
 	kubevirtapiv1 "kubevirt.io/api/core/v1"
@@ -20,11 +21,42 @@ func getDataVolumeSpec() cdiv1.DataVolumeSpec {
 				URL:           "https://cloud.centos.org/centos/7/images/CentOS-7-x86_64-GenericCloud.qcow2",
 				SecretRef:     "secret_ref",
 				CertConfigMap: "cert_config_map",
+				ExtraHeaders:  []string{"X-First: first"},
+			},
+			S3: &cdiv1.DataVolumeSourceS3{
+				URL:           "https://s3.example.com/bucket/disk.qcow2",
+				SecretRef:     "s3_secret_ref",
+				CertConfigMap: "s3_cert_config_map",
+			},
+			Registry: &cdiv1.DataVolumeSourceRegistry{
+				URL:        (func() *string { str := "docker://quay.io/containerdisks/fedora:latest"; return &str })(),
+				PullMethod: (func() *cdiv1.RegistryPullMethod { method := cdiv1.RegistryPullNode; return &method })(),
+				SecretRef:  (func() *string { str := "registry_secret_ref"; return &str })(),
 			},
 			PVC: &cdiv1.DataVolumeSourcePVC{
 				Namespace: "namespace",
 				Name:      "name",
 			},
+			Upload: &cdiv1.DataVolumeSourceUpload{},
+			Blank:  &cdiv1.DataVolumeBlankImage{},
+			Imageio: &cdiv1.DataVolumeSourceImageIO{
+				URL:           "https://ovirt.example.com/ovirt-engine/api",
+				DiskID:        "disk_id",
+				SecretRef:     "imageio_secret_ref",
+				CertConfigMap: "imageio_cert_config_map",
+			},
+			VDDK: &cdiv1.DataVolumeSourceVDDK{
+				URL:          "https://vcenter.example.com",
+				UUID:         "uuid",
+				BackingFile:  "[datastore] vm/disk.vmdk",
+				Thumbprint:   "thumbprint",
+				SecretRef:    "vddk_secret_ref",
+				InitImageURL: "quay.io/example/vddk:latest",
+			},
+			Snapshot: &cdiv1.DataVolumeSourceSnapshot{
+				Namespace: "snapshot_namespace",
+				Name:      "snapshot_name",
+			},
 		},
 		PVC: &k8sv1.PersistentVolumeClaimSpec{
 			AccessModes: []k8sv1.PersistentVolumeAccessMode{
@@ -68,6 +100,71 @@ func GetBaseInputForDataVolumeTemplateSpec() kubevirtapiv1.DataVolumeTemplateSpe
 	}
 }
 
+func getDataVolumeSourceOutput() []interface{} {
+	return []interface{}{
+		map[string]interface{}{
+			"http": []interface{}{
+				map[string]interface{}{
+					"url":             "https://cloud.centos.org/centos/7/images/CentOS-7-x86_64-GenericCloud.qcow2",
+					"secret_ref":      "secret_ref",
+					"cert_config_map": "cert_config_map",
+					"extra_headers":   []string{"X-First: first"},
+				},
+			},
+			"s3": []interface{}{
+				map[string]interface{}{
+					"url":             "https://s3.example.com/bucket/disk.qcow2",
+					"secret_ref":      "s3_secret_ref",
+					"cert_config_map": "s3_cert_config_map",
+				},
+			},
+			"registry": []interface{}{
+				map[string]interface{}{
+					"url":         "docker://quay.io/containerdisks/fedora:latest",
+					"pull_method": "node",
+					"secret_ref":  "registry_secret_ref",
+				},
+			},
+			"pvc": []interface{}{
+				map[string]interface{}{
+					"namespace": "namespace",
+					"name":      "name",
+				},
+			},
+			"upload": []interface{}{
+				map[string]interface{}{},
+			},
+			"blank": []interface{}{
+				map[string]interface{}{},
+			},
+			"imageio": []interface{}{
+				map[string]interface{}{
+					"url":             "https://ovirt.example.com/ovirt-engine/api",
+					"disk_id":         "disk_id",
+					"secret_ref":      "imageio_secret_ref",
+					"cert_config_map": "imageio_cert_config_map",
+				},
+			},
+			"vddk": []interface{}{
+				map[string]interface{}{
+					"url":            "https://vcenter.example.com",
+					"uuid":           "uuid",
+					"backing_file":   "[datastore] vm/disk.vmdk",
+					"thumbprint":     "thumbprint",
+					"secret_ref":     "vddk_secret_ref",
+					"init_image_url": "quay.io/example/vddk:latest",
+				},
+			},
+			"snapshot": []interface{}{
+				map[string]interface{}{
+					"namespace": "snapshot_namespace",
+					"name":      "snapshot_name",
+				},
+			},
+		},
+	}
+}
+
 func GetBaseOutputForDataVolume() interface{} {
 	return map[string]interface{}{
 		"metadata": []interface{}{
@@ -108,23 +205,7 @@ func GetBaseOutputForDataVolume() interface{} {
 						"storage_class_name": "standard",
 					},
 				},
-				"source": []interface{}{
-					map[string]interface{}{
-						"http": []interface{}{
-							map[string]interface{}{
-								"url":             "https://cloud.centos.org/centos/7/images/CentOS-7-x86_64-GenericCloud.qcow2",
-								"secret_ref":      "secret_ref",
-								"cert_config_map": "cert_config_map",
-							},
-						},
-						"pvc": []interface{}{
-							map[string]interface{}{
-								"namespace": "namespace",
-								"name":      "name",
-							},
-						},
-					},
-				},
+				"source":       getDataVolumeSourceOutput(),
 				"content_type": "content_type",
 			},
 		},
@@ -197,6 +278,53 @@ func GetBaseInputForVirtualMachine() kubevirtapiv1.VirtualMachineSpec {
 						},
 						OvercommitGuestOverhead: true,
 					},
+					CPU: &kubevirtapiv1.CPU{
+						Cores:                 2,
+						Sockets:               1,
+						Threads:               2,
+						Model:                 "host-model",
+						DedicatedCPUPlacement: true,
+						Realtime: &kubevirtapiv1.Realtime{
+							Mask: "0-1",
+						},
+					},
+					Memory: &kubevirtapiv1.Memory{
+						Guest: (func() *resource.Quantity { res := resource.MustParse("8Gi"); return &res })(),
+					},
+					Firmware: &kubevirtapiv1.Firmware{
+						Bootloader: &kubevirtapiv1.Bootloader{
+							BIOS: &kubevirtapiv1.BIOS{
+								UseSerial: utils.PtrToBool(true),
+							},
+						},
+						KernelBoot: &kubevirtapiv1.KernelBoot{
+							KernelArgs: "console=ttyS0",
+							Container: &kubevirtapiv1.KernelBootContainer{
+								Image:           "quay.io/kubevirt/alpine-ext-kernel-boot-demo",
+								ImagePullPolicy: k8sv1.PullIfNotPresent,
+								KernelPath:      "/boot/vmlinuz-virt",
+								InitrdPath:      "/boot/initramfs-virt",
+							},
+						},
+					},
+					Features: &kubevirtapiv1.Features{
+						SMM: &kubevirtapiv1.FeatureState{
+							Enabled: utils.PtrToBool(false),
+						},
+					},
+					Clock: &kubevirtapiv1.Clock{
+						ClockOffset: kubevirtapiv1.ClockOffset{
+							Timezone: (func() *kubevirtapiv1.ClockOffsetTimezone {
+								tz := kubevirtapiv1.ClockOffsetTimezone("Europe/Berlin")
+								return &tz
+							})(),
+						},
+						Timer: &kubevirtapiv1.Timer{
+							KVM: &kubevirtapiv1.KVMTimer{
+								Enabled: utils.PtrToBool(true),
+							},
+						},
+					},
 					Devices: kubevirtapiv1.Devices{
 						Disks: []kubevirtapiv1.Disk{
 							{
@@ -241,6 +369,24 @@ func GetBaseInputForVirtualMachine() kubevirtapiv1.VirtualMachineSpec {
 					return &retval
 				})(),
 				TerminationGracePeriodSeconds: utils.PtrToInt64(int64(120)),
+				LivenessProbe: &kubevirtapiv1.Probe{
+					Handler: kubevirtapiv1.Handler{
+						TCPSocket: &k8sv1.TCPSocketAction{
+							Port: intstr.FromString("ssh"),
+						},
+					},
+					InitialDelaySeconds: 30,
+					PeriodSeconds:       10,
+					TimeoutSeconds:      5,
+					SuccessThreshold:    1,
+					FailureThreshold:    3,
+				},
+				ReadinessProbe: &kubevirtapiv1.Probe{
+					Handler: kubevirtapiv1.Handler{
+						GuestAgentPing: &kubevirtapiv1.GuestAgentPing{},
+					},
+					PeriodSeconds: 5,
+				},
 				Networks: []kubevirtapiv1.Network{
 					{
 						Name: "main",
@@ -334,23 +480,7 @@ func GetBaseOutputForVirtualMachine() interface{} {
 								"storage_class_name": "standard",
 							},
 						},
-						"source": []interface{}{
-							map[string]interface{}{
-								"http": []interface{}{
-									map[string]interface{}{
-										"url":             "https://cloud.centos.org/centos/7/images/CentOS-7-x86_64-GenericCloud.qcow2",
-										"secret_ref":      "secret_ref",
-										"cert_config_map": "cert_config_map",
-									},
-								},
-								"pvc": []interface{}{
-									map[string]interface{}{
-										"namespace": "namespace",
-										"name":      "name",
-									},
-								},
-							},
-						},
+						"source":       getDataVolumeSourceOutput(),
 						"content_type": "content_type",
 					},
 				},
@@ -437,23 +567,35 @@ func GetBaseOutputForVirtualMachine() interface{} {
 													map[string]interface{}{
 														"disk": []interface{}{
 															map[string]interface{}{
-																"bus":         kubevirtapiv1.DiskBus("virtio"),
+																"bus":         "virtio",
 																"read_only":   true,
 																"pci_address": "pci_address",
 															},
 														},
 													},
 												},
-												"name":   "test-vm-datavolumedisk1",
-												"serial": "serial",
+												"name":                "test-vm-datavolumedisk1",
+												"serial":              "serial",
+												"boot_order":          0,
+												"dedicated_io_thread": false,
+												"cache":               "",
+												"io":                  "",
+												"shareable":           false,
 											},
 										},
 										"interface": []interface{}{
 											map[string]interface{}{
 												"interface_binding_method": "InterfaceBridge",
 												"name":                     "main",
+												"model":                    "",
+												"mac_address":              "",
+												"ports":                    []interface{}{},
+												"boot_order":               0,
+												"pci_address":              "",
+												"acpi_index":               0,
 											},
 										},
+										"filesystem": []interface{}{},
 									},
 								},
 								"resources": []interface{}{
@@ -469,10 +611,108 @@ func GetBaseOutputForVirtualMachine() interface{} {
 										"over_commit_guest_overhead": true,
 									},
 								},
+								"cpu": []interface{}{
+									map[string]interface{}{
+										"cores":                   2,
+										"sockets":                 1,
+										"threads":                 2,
+										"model":                   "host-model",
+										"feature":                 []interface{}{},
+										"dedicated_cpu_placement": true,
+										"isolate_emulator_thread": false,
+										"realtime": []interface{}{
+											map[string]interface{}{
+												"mask": "0-1",
+											},
+										},
+									},
+								},
+								"memory": []interface{}{
+									map[string]interface{}{
+										"guest": "8Gi",
+									},
+								},
+								"firmware": []interface{}{
+									map[string]interface{}{
+										"uuid":   "",
+										"serial": "",
+										"bootloader": []interface{}{
+											map[string]interface{}{
+												"bios": []interface{}{
+													map[string]interface{}{
+														"use_serial": true,
+													},
+												},
+											},
+										},
+										"kernel_boot": []interface{}{
+											map[string]interface{}{
+												"kernel_args": "console=ttyS0",
+												"container": []interface{}{
+													map[string]interface{}{
+														"image":             "quay.io/kubevirt/alpine-ext-kernel-boot-demo",
+														"image_pull_secret": "",
+														"image_pull_policy": "IfNotPresent",
+														"kernel_path":       "/boot/vmlinuz-virt",
+														"initrd_path":       "/boot/initramfs-virt",
+													},
+												},
+											},
+										},
+									},
+								},
+								"features": []interface{}{
+									map[string]interface{}{
+										"smm": []interface{}{
+											map[string]interface{}{
+												"enabled": false,
+											},
+										},
+									},
+								},
+								"clock": []interface{}{
+									map[string]interface{}{
+										"timezone": "Europe/Berlin",
+										"timer": []interface{}{
+											map[string]interface{}{
+												"kvm": []interface{}{
+													map[string]interface{}{
+														"present": true,
+													},
+												},
+											},
+										},
+									},
+								},
 							},
 						},
 						"eviction_strategy":                "eviction_strategy",
 						"termination_grace_period_seconds": int64(120),
+						"liveness_probe": []interface{}{
+							map[string]interface{}{
+								"tcp_socket": []interface{}{
+									map[string]interface{}{
+										"host": "",
+										"port": "ssh",
+									},
+								},
+								"initial_delay_seconds": 30,
+								"period_seconds":        10,
+								"timeout_seconds":       5,
+								"success_threshold":     1,
+								"failure_threshold":     3,
+							},
+						},
+						"readiness_probe": []interface{}{
+							map[string]interface{}{
+								"guest_agent_ping":      []interface{}{map[string]interface{}{}},
+								"initial_delay_seconds": 0,
+								"period_seconds":        5,
+								"timeout_seconds":       0,
+								"success_threshold":     0,
+								"failure_threshold":     0,
+							},
+						},
 						"volume": []interface{}{
 							map[string]interface{}{
 								"name": "test-vm-datavolumedisk1",
@@ -480,7 +720,8 @@ func GetBaseOutputForVirtualMachine() interface{} {
 									map[string]interface{}{
 										"data_volume": []interface{}{
 											map[string]interface{}{
-												"name": "test-vm-bootvolume",
+												"name":         "test-vm-bootvolume",
+												"hotpluggable": false,
 											},
 										},
 										"cloud_init_config_drive": []interface{}{
@@ -499,6 +740,7 @@ func GetBaseOutputForVirtualMachine() interface{} {
 												},
 												"network_data_base64": "network_data_base64",
 												"network_data":        "network_data",
+												"store_as_secret":     false,
 											},
 										},
 										"service_account": []interface{}{
@@ -10,6 +10,10 @@ func GetDataVolume(vm interface{}) interface{} {
 	return vm.(map[string]interface{})["data_volume_templates"].([]interface{})[0]
 }
 
+func GetDataVolumeSpec(dataVolume interface{}) map[string]interface{} {
+	return dataVolume.(map[string]interface{})["spec"].([]interface{})[0].(map[string]interface{})
+}
+
 func GetPVCRequirements(dataVolume interface{}) interface{} {
 	return dataVolume.(map[string]interface{})["spec"].([]interface{})[0].(map[string]interface{})["pvc"].([]interface{})[0].(map[string]interface{})["resources"].([]interface{})[0]
 }
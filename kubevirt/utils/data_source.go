@@ -0,0 +1,37 @@
+package utils
+
+import (
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+// DataSourceSchemaFromResourceSchema turns the fields of a resource into computed
+// fields, so a data source can expose the same attributes the resource manages.
+func DataSourceSchemaFromResourceSchema(fields map[string]*schema.Schema) map[string]*schema.Schema {
+	result := make(map[string]*schema.Schema, len(fields))
+
+	for k, v := range fields {
+		result[k] = dataSourceSchemaFromResourceSchema(v)
+	}
+
+	return result
+}
+
+func dataSourceSchemaFromResourceSchema(in *schema.Schema) *schema.Schema {
+	result := &schema.Schema{
+		Type:        in.Type,
+		Description: in.Description,
+		Computed:    true,
+		Sensitive:   in.Sensitive,
+	}
+
+	switch elem := in.Elem.(type) {
+	case *schema.Resource:
+		result.Elem = &schema.Resource{
+			Schema: DataSourceSchemaFromResourceSchema(elem.Schema),
+		}
+	case *schema.Schema:
+		result.Elem = &schema.Schema{Type: elem.Type}
+	}
+
+	return result
+}
@@ -1,7 +1,9 @@
 package patch
 
 import (
+	"bytes"
 	"encoding/json"
+	"fmt"
 	"reflect"
 	"sort"
 	"strings"
@@ -59,6 +61,152 @@ func DiffStringMap(pathPrefix string, oldV, newV map[string]interface{}) PatchOp
 	return ops
 }
 
+// DiffValue returns the operations needed to turn the value stored at
+// path from oldV into newV, where liveV is the value the API server
+// currently holds there. All values are compared in their JSON form:
+// objects are diffed key by key, so that only the fields that changed
+// are touched and the ones managed outside of TF are left alone. The
+// operations are relative to liveV, so fields the server dropped are
+// added back rather than replaced, and never removed. Lists are only
+// patched item by item while old, new and live list keep the same
+// length, as their items are addressed by index, and replaced whole
+// otherwise. Nil and empty values are treated as absent.
+func DiffValue(path string, oldV, newV, liveV interface{}) PatchOperations {
+	ops := make([]PatchOperation, 0, 1)
+
+	path = strings.TrimRight(path, "/")
+
+	oldJSON, err := toJSONValue(oldV)
+	if err != nil {
+		return append(ops, diffWholeValue(path, oldV, newV, liveV)...)
+	}
+	newJSON, err := toJSONValue(newV)
+	if err != nil {
+		return append(ops, diffWholeValue(path, oldV, newV, liveV)...)
+	}
+	liveJSON, err := toJSONValue(liveV)
+	if err != nil {
+		return append(ops, diffWholeValue(path, oldV, newV, liveV)...)
+	}
+
+	return append(ops, diffJSONValue(path, oldJSON, newJSON, liveJSON)...)
+}
+
+func diffJSONValue(path string, oldV, newV, liveV interface{}) []PatchOperation {
+	if isEmptyValue(oldV) || isEmptyValue(newV) || isEmptyValue(liveV) {
+		return diffWholeValue(path, oldV, newV, liveV)
+	}
+
+	switch newValue := newV.(type) {
+	case map[string]interface{}:
+		oldValue, ok := oldV.(map[string]interface{})
+		if !ok {
+			break
+		}
+		liveValue, ok := liveV.(map[string]interface{})
+		if !ok {
+			break
+		}
+
+		keys := make([]string, 0, len(oldValue)+len(newValue))
+		for k := range oldValue {
+			keys = append(keys, k)
+		}
+		for k := range newValue {
+			if _, ok := oldValue[k]; !ok {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+
+		ops := make([]PatchOperation, 0)
+		for _, k := range keys {
+			ops = append(ops, diffJSONValue(path+"/"+escapeJsonPointer(k), oldValue[k], newValue[k], liveValue[k])...)
+		}
+		return ops
+	case []interface{}:
+		oldValue, ok := oldV.([]interface{})
+		if !ok || len(oldValue) != len(newValue) {
+			break
+		}
+		liveValue, ok := liveV.([]interface{})
+		if !ok || len(liveValue) != len(newValue) {
+			break
+		}
+
+		ops := make([]PatchOperation, 0)
+		for i := range newValue {
+			ops = append(ops, diffJSONValue(fmt.Sprintf("%s/%d", path, i), oldValue[i], newValue[i], liveValue[i])...)
+		}
+		return ops
+	}
+
+	return diffWholeValue(path, oldV, newV, liveV)
+}
+
+func diffWholeValue(path string, oldV, newV, liveV interface{}) []PatchOperation {
+	switch {
+	case reflect.DeepEqual(oldV, newV) || (isEmptyValue(oldV) && isEmptyValue(newV)):
+		// Left alone, whatever the server holds.
+		return nil
+	case isEmptyValue(newV):
+		if isEmptyValue(liveV) {
+			return nil
+		}
+		return []PatchOperation{&RemoveOperation{
+			Path: path,
+		}}
+	case isEmptyValue(liveV):
+		return []PatchOperation{&AddOperation{
+			Path:  path,
+			Value: newV,
+		}}
+	case !reflect.DeepEqual(liveV, newV):
+		return []PatchOperation{&ReplaceOperation{
+			Path:  path,
+			Value: newV,
+		}}
+	}
+
+	return nil
+}
+
+// toJSONValue converts v into the maps, lists and scalars it's encoded
+// as in JSON, keeping numbers as they're written.
+func toJSONValue(v interface{}) (interface{}, error) {
+	if isEmptyValue(v) {
+		return nil, nil
+	}
+
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	var result interface{}
+	decoder := json.NewDecoder(bytes.NewReader(b))
+	decoder.UseNumber()
+	if err := decoder.Decode(&result); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func isEmptyValue(v interface{}) bool {
+	if v == nil {
+		return true
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		return rv.IsNil()
+	case reflect.Slice, reflect.Map, reflect.String:
+		return rv.Len() == 0
+	}
+	return false
+}
+
 // escapeJsonPointer escapes string per RFC 6901
 // so it can be used as path in JSON patch operations
 func escapeJsonPointer(path string) string {
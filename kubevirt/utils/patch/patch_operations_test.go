@@ -1,6 +1,7 @@
 package patch
 
 import (
+	"encoding/json"
 	"fmt"
 	"testing"
 )
@@ -147,6 +148,222 @@ func TestDiffStringMap(t *testing.T) {
 	}
 }
 
+func TestDiffValue(t *testing.T) {
+	testCases := []struct {
+		Path string
+		Old  interface{}
+		New  interface{}
+		// Live defaults to Old, the server holding what the state says.
+		Live        interface{}
+		ExpectedOps PatchOperations
+	}{
+		{
+			Path:        "/spec/runStrategy",
+			Old:         "",
+			New:         "",
+			ExpectedOps: []PatchOperation{},
+		},
+		{
+			Path: "/spec/runStrategy",
+			Old:  "",
+			New:  "Always",
+			ExpectedOps: []PatchOperation{
+				&AddOperation{
+					Path:  "/spec/runStrategy",
+					Value: "Always",
+				},
+			},
+		},
+		{
+			Path: "/spec/runStrategy",
+			Old:  "Always",
+			New:  "Halted",
+			ExpectedOps: []PatchOperation{
+				&ReplaceOperation{
+					Path:  "/spec/runStrategy",
+					Value: "Halted",
+				},
+			},
+		},
+		{
+			Path:        "/spec/runStrategy",
+			Old:         "Always",
+			New:         "Always",
+			ExpectedOps: []PatchOperation{},
+		},
+		{
+			Path:        "/spec/runStrategy",
+			Old:         "Always",
+			New:         "Halted",
+			Live:        "Halted",
+			ExpectedOps: []PatchOperation{},
+		},
+		{
+			Path: "/spec/dataVolumeTemplates/",
+			Old: []interface{}{
+				map[string]interface{}{"name": "one"},
+			},
+			New: []interface{}{},
+			ExpectedOps: []PatchOperation{
+				&RemoveOperation{Path: "/spec/dataVolumeTemplates"},
+			},
+		},
+		{
+			Path: "/spec/template",
+			Old:  (*string)(nil),
+			New:  map[string]interface{}{"spec": "value"},
+			ExpectedOps: []PatchOperation{
+				&AddOperation{
+					Path:  "/spec/template",
+					Value: map[string]interface{}{"spec": "value"},
+				},
+			},
+		},
+		{
+			Path: "/spec/template/spec",
+			Old: map[string]interface{}{
+				"hostname": "one",
+				"domain":   map[string]interface{}{"cpu": map[string]interface{}{"cores": 2, "sockets": 1}},
+			},
+			New: map[string]interface{}{
+				"hostname": "one",
+				"domain":   map[string]interface{}{"cpu": map[string]interface{}{"cores": 4, "sockets": 1}},
+			},
+			ExpectedOps: []PatchOperation{
+				&ReplaceOperation{
+					Path:  "/spec/template/spec/domain/cpu/cores",
+					Value: json.Number("4"),
+				},
+			},
+		},
+		{
+			Path: "/spec/template/spec",
+			Old: struct {
+				Hostname  string `json:"hostname,omitempty"`
+				Subdomain string `json:"subdomain,omitempty"`
+			}{Hostname: "one", Subdomain: "sub"},
+			New: struct {
+				Hostname  string `json:"hostname,omitempty"`
+				Subdomain string `json:"subdomain,omitempty"`
+				Priority  string `json:"priorityClassName,omitempty"`
+			}{Hostname: "one", Priority: "high/priority"},
+			ExpectedOps: []PatchOperation{
+				&RemoveOperation{Path: "/spec/template/spec/subdomain"},
+				&AddOperation{
+					Path:  "/spec/template/spec/priorityClassName",
+					Value: "high/priority",
+				},
+			},
+		},
+		{
+			Path: "/spec/template/spec/volumes",
+			Old: []interface{}{
+				map[string]interface{}{"name": "one", "containerDisk": map[string]interface{}{"image": "a"}},
+				map[string]interface{}{"name": "two"},
+			},
+			New: []interface{}{
+				map[string]interface{}{"name": "one", "containerDisk": map[string]interface{}{"image": "b"}},
+				map[string]interface{}{"name": "two"},
+			},
+			ExpectedOps: []PatchOperation{
+				&ReplaceOperation{
+					Path:  "/spec/template/spec/volumes/0/containerDisk/image",
+					Value: "b",
+				},
+			},
+		},
+		{
+			Path: "/spec/template/spec/volumes",
+			Old: []interface{}{
+				map[string]interface{}{"name": "one"},
+			},
+			New: []interface{}{
+				map[string]interface{}{"name": "one"},
+				map[string]interface{}{"name": "two"},
+			},
+			ExpectedOps: []PatchOperation{
+				&ReplaceOperation{
+					Path: "/spec/template/spec/volumes",
+					Value: []interface{}{
+						map[string]interface{}{"name": "one"},
+						map[string]interface{}{"name": "two"},
+					},
+				},
+			},
+		},
+		{
+			Path: "/spec/template/spec",
+			Old:  map[string]interface{}{"domain": map[string]interface{}{"cpu": map[string]interface{}{"cores": 2}}},
+			New:  map[string]interface{}{"domain": map[string]interface{}{"cpu": "2"}},
+			ExpectedOps: []PatchOperation{
+				&ReplaceOperation{
+					Path:  "/spec/template/spec/domain/cpu",
+					Value: "2",
+				},
+			},
+		},
+		{
+			Path: "/spec/template/spec",
+			Old: map[string]interface{}{
+				"hostname":  "one",
+				"subdomain": "sub",
+				"domain":    map[string]interface{}{"cpu": map[string]interface{}{"cores": 2}},
+			},
+			New: map[string]interface{}{
+				"hostname": "two",
+				"domain":   map[string]interface{}{"cpu": map[string]interface{}{"cores": 2}},
+			},
+			Live: map[string]interface{}{
+				"domain": map[string]interface{}{"cpu": map[string]interface{}{"cores": 2}},
+			},
+			ExpectedOps: []PatchOperation{
+				&AddOperation{
+					Path:  "/spec/template/spec/hostname",
+					Value: "two",
+				},
+			},
+		},
+		{
+			Path: "/spec/template/spec/volumes",
+			Old: []interface{}{
+				map[string]interface{}{"name": "one", "containerDisk": map[string]interface{}{"image": "a"}},
+				map[string]interface{}{"name": "two"},
+			},
+			New: []interface{}{
+				map[string]interface{}{"name": "one", "containerDisk": map[string]interface{}{"image": "b"}},
+				map[string]interface{}{"name": "two"},
+			},
+			Live: []interface{}{
+				map[string]interface{}{"name": "hotplug"},
+				map[string]interface{}{"name": "one", "containerDisk": map[string]interface{}{"image": "a"}},
+				map[string]interface{}{"name": "two"},
+			},
+			ExpectedOps: []PatchOperation{
+				&ReplaceOperation{
+					Path: "/spec/template/spec/volumes",
+					Value: []interface{}{
+						map[string]interface{}{"name": "one", "containerDisk": map[string]interface{}{"image": "b"}},
+						map[string]interface{}{"name": "two"},
+					},
+				},
+			},
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			live := tc.Live
+			if live == nil {
+				live = tc.Old
+			}
+			ops := DiffValue(tc.Path, tc.Old, tc.New, live)
+			if !tc.ExpectedOps.Equal(ops) {
+				t.Fatalf("Operations don't match.\nExpected: %v\nGiven:    %v\n", tc.ExpectedOps, ops)
+			}
+		})
+	}
+}
+
 func TestEscapeJsonPointer(t *testing.T) {
 	testCases := []struct {
 		Input          string
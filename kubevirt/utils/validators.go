@@ -117,7 +117,7 @@ func validatePortName(value interface{}, key string) (ws []string, es []error) {
 	}
 	return
 }
-func validatePortNumOrName(value interface{}, key string) (ws []string, es []error) {
+func ValidatePortNumOrName(value interface{}, key string) (ws []string, es []error) {
 	switch value.(type) {
 	case string:
 		intVal, err := strconv.Atoi(value.(string))
@@ -155,7 +155,7 @@ func validateResourceList(value interface{}, key string) (ws []string, es []erro
 	return
 }
 
-func validateResourceQuantity(value interface{}, key string) (ws []string, es []error) {
+func ValidateResourceQuantity(value interface{}, key string) (ws []string, es []error) {
 	if v, ok := value.(string); ok {
 		_, err := resource.ParseQuantity(v)
 		if err != nil {
@@ -165,7 +165,7 @@ func validateResourceQuantity(value interface{}, key string) (ws []string, es []
 	return
 }
 
-func validateNonNegativeInteger(value interface{}, key string) (ws []string, es []error) {
+func ValidateNonNegativeInteger(value interface{}, key string) (ws []string, es []error) {
 	v := value.(int)
 	if v < 0 {
 		es = append(es, fmt.Errorf("%s must be greater than or equal to 0", key))
@@ -173,7 +173,7 @@ func validateNonNegativeInteger(value interface{}, key string) (ws []string, es
 	return
 }
 
-func validatePositiveInteger(value interface{}, key string) (ws []string, es []error) {
+func ValidatePositiveInteger(value interface{}, key string) (ws []string, es []error) {
 	v := value.(int)
 	if v <= 0 {
 		es = append(es, fmt.Errorf("%s must be greater than 0", key))